@@ -0,0 +1,536 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"task-api/pkg/workflow"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const envPrefix = "TASK_API_"
+
+type Config struct {
+	Server      ServerConfig      `yaml:"server"      toml:"server"`
+	Database    DatabaseConfig    `yaml:"database"    toml:"database"`
+	Log         LogConfig         `yaml:"log"         toml:"log"`
+	CORS        CORSConfig        `yaml:"cors"        toml:"cors"`
+	Limits      LimitsConfig      `yaml:"limits"      toml:"limits"`
+	Auth        AuthConfig        `yaml:"auth"        toml:"auth"`
+	Concurrency ConcurrencyConfig `yaml:"concurrency" toml:"concurrency"`
+	Trash       TrashConfig       `yaml:"trash"       toml:"trash"`
+	Workflow    WorkflowConfig    `yaml:"workflow"    toml:"workflow"`
+	Subtasks    SubtasksConfig    `yaml:"subtasks"    toml:"subtasks"`
+	Reminders   RemindersConfig   `yaml:"reminders"   toml:"reminders"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks"    toml:"webhooks"`
+	Stream      StreamConfig      `yaml:"stream"      toml:"stream"`
+	Realtime    RealtimeConfig    `yaml:"realtime"    toml:"realtime"`
+}
+
+type ServerConfig struct {
+	Addr              string   `yaml:"addr"                toml:"addr"`
+	Mode              string   `yaml:"mode"                toml:"mode"` // gin mode: debug, release or test
+	Swagger           bool     `yaml:"swagger"             toml:"swagger"`
+	ReadTimeout       Duration `yaml:"read_timeout"        toml:"read_timeout"`
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	WriteTimeout      Duration `yaml:"write_timeout"       toml:"write_timeout"`
+	IdleTimeout       Duration `yaml:"idle_timeout"        toml:"idle_timeout"`
+	// DrainDelay keeps serving after readiness flips so load balancers stop
+	// routing new traffic before the listener closes.
+	DrainDelay      Duration `yaml:"drain_delay"      toml:"drain_delay"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+type DatabaseConfig struct {
+	DSN             string   `yaml:"dsn"               toml:"dsn"`
+	AutoMigrate     bool     `yaml:"auto_migrate"      toml:"auto_migrate"`
+	MaxOpenConns    int      `yaml:"max_open_conns"    toml:"max_open_conns"`
+	MaxIdleConns    int      `yaml:"max_idle_conns"    toml:"max_idle_conns"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level" toml:"level"` // debug, info, warn or error
+}
+
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins"`
+}
+
+type LimitsConfig struct {
+	MaxBodyBytes    int64 `yaml:"max_body_bytes"    toml:"max_body_bytes"`
+	DefaultPageSize int   `yaml:"default_page_size" toml:"default_page_size"`
+	MaxPageSize     int   `yaml:"max_page_size"     toml:"max_page_size"`
+	MaxBatchSize    int   `yaml:"max_batch_size"    toml:"max_batch_size"` // operations per POST /tasks:batch
+	MaxTreeDepth    int   `yaml:"max_tree_depth"    toml:"max_tree_depth"` // deepest ?depth= of GET /tasks/{id}/tree
+}
+
+// AuthConfig controls authentication of the task and API key routes. API
+// keys are always accepted when enabled; JWTs only when JWKSFile is set.
+type AuthConfig struct {
+	Enabled  bool   `yaml:"enabled"   toml:"enabled"`
+	JWKSFile string `yaml:"jwks_file" toml:"jwks_file"`
+	Issuer   string `yaml:"issuer"    toml:"issuer"`   // required iss claim, if set
+	Audience string `yaml:"audience"  toml:"audience"` // required aud claim, if set
+}
+
+type ConcurrencyConfig struct {
+	// RequireIfMatch rejects task updates and deletes without an If-Match
+	// header (428) instead of applying them unconditionally.
+	RequireIfMatch bool `yaml:"require_if_match" toml:"require_if_match"`
+}
+
+// TrashConfig controls how long deleted tasks stay restorable.
+type TrashConfig struct {
+	Retention     Duration `yaml:"retention"      toml:"retention"` // 0 keeps deleted tasks forever
+	PurgeInterval Duration `yaml:"purge_interval" toml:"purge_interval"`
+}
+
+// WorkflowConfig replaces the default task status transitions. Each status
+// lists the statuses a task may move to from it; statuses left out have no
+// outgoing transitions. Only the config file can set it.
+type WorkflowConfig struct {
+	Transitions map[string][]string `yaml:"transitions" toml:"transitions"`
+}
+
+// SubtasksConfig decides what happens to the subtasks of a task that is
+// deleted or completed.
+type SubtasksConfig struct {
+	// OnDelete is cascade (subtasks go to the trash and are restored with
+	// the parent), orphan (subtasks become top-level tasks) or restrict
+	// (tasks with subtasks cannot be deleted).
+	OnDelete string `yaml:"on_delete"   toml:"on_delete"`
+	// OnComplete is allow, or restrict to refuse marking a task done while
+	// any of its subtasks is still open.
+	OnComplete string `yaml:"on_complete" toml:"on_complete"`
+}
+
+// RemindersConfig controls the scheduler that reminds of approaching due
+// dates and marks tasks overdue, and where its notifications go.
+type RemindersConfig struct {
+	Interval Duration `yaml:"interval" toml:"interval"` // 0 disables the scheduler
+	Notifier string   `yaml:"notifier" toml:"notifier"` // log, webhook or smtp
+	// WebhookURL receives every notification as a JSON POST.
+	WebhookURL string `yaml:"webhook_url" toml:"webhook_url"`
+	// SMTPAddr is the host:port of a mail server that needs no
+	// authentication, e.g. a local relay or Mailpit.
+	SMTPAddr string   `yaml:"smtp_addr" toml:"smtp_addr"`
+	SMTPFrom string   `yaml:"smtp_from" toml:"smtp_from"`
+	SMTPTo   []string `yaml:"smtp_to"   toml:"smtp_to"`
+}
+
+// WebhooksConfig controls the delivery of queued webhook events. A failed
+// attempt is retried after Backoff, doubled on every further failure, until
+// MaxAttempts have been made.
+type WebhooksConfig struct {
+	Interval    Duration `yaml:"interval"     toml:"interval"` // 0 stops delivery; events are still queued
+	Timeout     Duration `yaml:"timeout"      toml:"timeout"`  // per attempt
+	MaxAttempts int      `yaml:"max_attempts" toml:"max_attempts"`
+	Backoff     Duration `yaml:"backoff"      toml:"backoff"`
+}
+
+// StreamConfig controls GET /tasks/stream. Each open stream polls the event
+// log every PollInterval and sends a comment every Heartbeat to keep proxies
+// from closing an idle connection.
+type StreamConfig struct {
+	PollInterval Duration `yaml:"poll_interval" toml:"poll_interval"`
+	Heartbeat    Duration `yaml:"heartbeat"     toml:"heartbeat"`
+}
+
+// RealtimeConfig controls the WebSocket channel at /realtime. A client whose
+// SendBuffer fills up, because it reads slower than changes arrive, is
+// disconnected rather than slowing down everyone else.
+type RealtimeConfig struct {
+	SendBuffer   int      `yaml:"send_buffer"   toml:"send_buffer"` // messages queued per connection
+	PingInterval Duration `yaml:"ping_interval" toml:"ping_interval"`
+	WriteTimeout Duration `yaml:"write_timeout" toml:"write_timeout"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			Mode:              "debug",
+			Swagger:           true,
+			ReadTimeout:       Duration{15 * time.Second},
+			ReadHeaderTimeout: Duration{5 * time.Second},
+			WriteTimeout:      Duration{30 * time.Second},
+			IdleTimeout:       Duration{60 * time.Second},
+			ShutdownTimeout:   Duration{20 * time.Second},
+		},
+		Database: DatabaseConfig{
+			DSN:          "sqlite://task.db",
+			AutoMigrate:  true,
+			MaxOpenConns: 10,
+			MaxIdleConns: 5,
+		},
+		Log: LogConfig{Level: "info"},
+		Limits: LimitsConfig{
+			MaxBodyBytes:    1 << 20,
+			DefaultPageSize: 20,
+			MaxPageSize:     100,
+			MaxBatchSize:    1000,
+			MaxTreeDepth:    10,
+		},
+		Auth: AuthConfig{Enabled: true},
+		Trash: TrashConfig{
+			Retention:     Duration{30 * 24 * time.Hour},
+			PurgeInterval: Duration{time.Hour},
+		},
+		Subtasks: SubtasksConfig{OnDelete: "cascade", OnComplete: "allow"},
+		Reminders: RemindersConfig{
+			Interval: Duration{time.Minute},
+			Notifier: "log",
+			SMTPAddr: "localhost:1025",
+			SMTPFrom: "task-api@localhost",
+		},
+		Webhooks: WebhooksConfig{
+			Interval:    Duration{5 * time.Second},
+			Timeout:     Duration{10 * time.Second},
+			MaxAttempts: 8,
+			Backoff:     Duration{30 * time.Second},
+		},
+		Stream: StreamConfig{
+			PollInterval: Duration{time.Second},
+			Heartbeat:    Duration{15 * time.Second},
+		},
+		Realtime: RealtimeConfig{
+			SendBuffer:   64,
+			PingInterval: Duration{30 * time.Second},
+			WriteTimeout: Duration{10 * time.Second},
+		},
+	}
+}
+
+// Load builds the configuration from, in increasing precedence: defaults,
+// the config file (-config or TASK_API_CONFIG), TASK_API_* environment
+// variables and command-line flags. It returns the arguments left after the
+// flags, e.g. a subcommand.
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("task-api", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv(envPrefix+"CONFIG"), "path to a YAML or TOML config file")
+	flags := registerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configPath != "" {
+		if err := loadFile(*configPath, cfg); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := loadEnv(cfg); err != nil {
+		return nil, nil, err
+	}
+	// 只有命令列上明確指定的 flag 才覆蓋前面的設定
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		if apply, ok := flags[f.Name]; ok && flagErr == nil {
+			flagErr = apply(cfg, f.Value.String())
+		}
+	})
+	if flagErr != nil {
+		return nil, nil, flagErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("config %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return nil
+}
+
+// setting maps one option to its environment variable and flag.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	apply func(cfg *Config, value string) error
+}
+
+var settings = []setting{
+	{"SERVER_ADDR", "addr", "listen address", setString(func(c *Config) *string { return &c.Server.Addr })},
+	{"SERVER_MODE", "mode", "gin mode (debug, release, test)", setString(func(c *Config) *string { return &c.Server.Mode })},
+	{"SWAGGER", "swagger", "serve Swagger UI at /swagger", setBool(func(c *Config) *bool { return &c.Server.Swagger })},
+	{"SERVER_READ_TIMEOUT", "read-timeout", "maximum duration for reading a request", setDuration(func(c *Config) *Duration { return &c.Server.ReadTimeout })},
+	{"SERVER_READ_HEADER_TIMEOUT", "read-header-timeout", "maximum duration for reading request headers", setDuration(func(c *Config) *Duration { return &c.Server.ReadHeaderTimeout })},
+	{"SERVER_WRITE_TIMEOUT", "write-timeout", "maximum duration for writing a response", setDuration(func(c *Config) *Duration { return &c.Server.WriteTimeout })},
+	{"SERVER_IDLE_TIMEOUT", "idle-timeout", "keep-alive idle timeout", setDuration(func(c *Config) *Duration { return &c.Server.IdleTimeout })},
+	{"SERVER_DRAIN_DELAY", "drain-delay", "time to keep serving after readiness flips on shutdown", setDuration(func(c *Config) *Duration { return &c.Server.DrainDelay })},
+	{"SERVER_SHUTDOWN_TIMEOUT", "shutdown-timeout", "deadline for in-flight requests on shutdown", setDuration(func(c *Config) *Duration { return &c.Server.ShutdownTimeout })},
+	{"DB_DSN", "db-dsn", "database DSN (sqlite://, postgres://, mysql://)", setString(func(c *Config) *string { return &c.Database.DSN })},
+	{"DB_AUTO_MIGRATE", "db-auto-migrate", "apply pending migrations on startup", setBool(func(c *Config) *bool { return &c.Database.AutoMigrate })},
+	{"DB_MAX_OPEN_CONNS", "db-max-open-conns", "maximum open database connections", setInt(func(c *Config) *int { return &c.Database.MaxOpenConns })},
+	{"DB_MAX_IDLE_CONNS", "db-max-idle-conns", "maximum idle database connections", setInt(func(c *Config) *int { return &c.Database.MaxIdleConns })},
+	{"DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "maximum connection lifetime (e.g. 30m)", setDuration(func(c *Config) *Duration { return &c.Database.ConnMaxLifetime })},
+	{"LOG_LEVEL", "log-level", "log level (debug, info, warn, error)", setString(func(c *Config) *string { return &c.Log.Level })},
+	{"CORS_ORIGINS", "cors-origins", "comma separated allowed CORS origins, * for any", setList(func(c *Config) *[]string { return &c.CORS.AllowedOrigins })},
+	{"MAX_BODY_BYTES", "max-body-bytes", "maximum request body size in bytes", setInt64(func(c *Config) *int64 { return &c.Limits.MaxBodyBytes })},
+	{"DEFAULT_PAGE_SIZE", "default-page-size", "page size when limit is omitted", setInt(func(c *Config) *int { return &c.Limits.DefaultPageSize })},
+	{"MAX_PAGE_SIZE", "max-page-size", "largest accepted limit", setInt(func(c *Config) *int { return &c.Limits.MaxPageSize })},
+	{"MAX_BATCH_SIZE", "max-batch-size", "most operations accepted in one batch request", setInt(func(c *Config) *int { return &c.Limits.MaxBatchSize })},
+	{"MAX_TREE_DEPTH", "max-tree-depth", "deepest subtask tree returned by GET /tasks/{id}/tree", setInt(func(c *Config) *int { return &c.Limits.MaxTreeDepth })},
+	{"AUTH_ENABLED", "auth-enabled", "require an API key or JWT on task routes", setBool(func(c *Config) *bool { return &c.Auth.Enabled })},
+	{"AUTH_JWKS_FILE", "auth-jwks-file", "JWKS file with keys for verifying HS256/RS256 JWTs", setString(func(c *Config) *string { return &c.Auth.JWKSFile })},
+	{"AUTH_ISSUER", "auth-issuer", "required JWT issuer", setString(func(c *Config) *string { return &c.Auth.Issuer })},
+	{"AUTH_AUDIENCE", "auth-audience", "required JWT audience", setString(func(c *Config) *string { return &c.Auth.Audience })},
+	{"REQUIRE_IF_MATCH", "require-if-match", "require If-Match on task updates and deletes", setBool(func(c *Config) *bool { return &c.Concurrency.RequireIfMatch })},
+	{"TRASH_RETENTION", "trash-retention", "how long deleted tasks stay restorable (0 = forever)", setDuration(func(c *Config) *Duration { return &c.Trash.Retention })},
+	{"TRASH_PURGE_INTERVAL", "trash-purge-interval", "how often expired tasks are purged from the trash", setDuration(func(c *Config) *Duration { return &c.Trash.PurgeInterval })},
+	{"SUBTASKS_ON_DELETE", "subtasks-on-delete", "subtasks of a deleted task: cascade, orphan or restrict", setString(func(c *Config) *string { return &c.Subtasks.OnDelete })},
+	{"SUBTASKS_ON_COMPLETE", "subtasks-on-complete", "completing a task with open subtasks: allow or restrict", setString(func(c *Config) *string { return &c.Subtasks.OnComplete })},
+	{"REMINDERS_INTERVAL", "reminders-interval", "how often due dates are checked for reminders (0 = never)", setDuration(func(c *Config) *Duration { return &c.Reminders.Interval })},
+	{"REMINDERS_NOTIFIER", "reminders-notifier", "where reminders go: log, webhook or smtp", setString(func(c *Config) *string { return &c.Reminders.Notifier })},
+	{"REMINDERS_WEBHOOK_URL", "reminders-webhook-url", "URL that receives reminders as JSON POSTs", setString(func(c *Config) *string { return &c.Reminders.WebhookURL })},
+	{"REMINDERS_SMTP_ADDR", "reminders-smtp-addr", "host:port of the mail server for reminders", setString(func(c *Config) *string { return &c.Reminders.SMTPAddr })},
+	{"REMINDERS_SMTP_FROM", "reminders-smtp-from", "sender address of reminder mails", setString(func(c *Config) *string { return &c.Reminders.SMTPFrom })},
+	{"REMINDERS_SMTP_TO", "reminders-smtp-to", "comma separated recipients of reminder mails", setList(func(c *Config) *[]string { return &c.Reminders.SMTPTo })},
+	{"WEBHOOKS_INTERVAL", "webhooks-interval", "how often queued webhook deliveries are sent (0 = never)", setDuration(func(c *Config) *Duration { return &c.Webhooks.Interval })},
+	{"WEBHOOKS_TIMEOUT", "webhooks-timeout", "timeout of one webhook delivery attempt", setDuration(func(c *Config) *Duration { return &c.Webhooks.Timeout })},
+	{"WEBHOOKS_MAX_ATTEMPTS", "webhooks-max-attempts", "attempts before a webhook delivery is given up", setInt(func(c *Config) *int { return &c.Webhooks.MaxAttempts })},
+	{"WEBHOOKS_BACKOFF", "webhooks-backoff", "delay before the first webhook retry, doubled on each further one", setDuration(func(c *Config) *Duration { return &c.Webhooks.Backoff })},
+	{"STREAM_POLL_INTERVAL", "stream-poll-interval", "how often task streams check for new events", setDuration(func(c *Config) *Duration { return &c.Stream.PollInterval })},
+	{"STREAM_HEARTBEAT", "stream-heartbeat", "interval of keep-alive comments on idle task streams", setDuration(func(c *Config) *Duration { return &c.Stream.Heartbeat })},
+	{"REALTIME_SEND_BUFFER", "realtime-send-buffer", "messages queued per WebSocket before a slow client is dropped", setInt(func(c *Config) *int { return &c.Realtime.SendBuffer })},
+	{"REALTIME_PING_INTERVAL", "realtime-ping-interval", "interval of WebSocket pings; a client silent for twice as long is dropped", setDuration(func(c *Config) *Duration { return &c.Realtime.PingInterval })},
+	{"REALTIME_WRITE_TIMEOUT", "realtime-write-timeout", "timeout of one WebSocket write", setDuration(func(c *Config) *Duration { return &c.Realtime.WriteTimeout })},
+}
+
+func registerFlags(fs *flag.FlagSet) map[string]func(*Config, string) error {
+	applies := make(map[string]func(*Config, string) error, len(settings))
+	for _, s := range settings {
+		fs.String(s.flag, "", s.usage+" (env "+envPrefix+s.env+")")
+		applies[s.flag] = s.apply
+	}
+	return applies
+}
+
+func loadEnv(cfg *Config) error {
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(envPrefix + s.env); ok {
+			if err := s.apply(cfg, value); err != nil {
+				return fmt.Errorf("%s%s: %w", envPrefix, s.env, err)
+			}
+		}
+	}
+	return nil
+}
+
+func setString(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func setBool(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func setInt(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func setInt64(field func(*Config) *int64) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func setDuration(field func(*Config) *Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		return field(c).UnmarshalText([]byte(v))
+	}
+}
+
+func setList(field func(*Config) *[]string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		var list []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*field(c) = list
+		return nil
+	}
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr is required"))
+	}
+	switch c.Server.Mode {
+	case "debug", "release", "test":
+	default:
+		errs = append(errs, fmt.Errorf("server.mode %q must be debug, release or test", c.Server.Mode))
+	}
+	for name, d := range map[string]Duration{
+		"server.read_timeout":        c.Server.ReadTimeout,
+		"server.read_header_timeout": c.Server.ReadHeaderTimeout,
+		"server.write_timeout":       c.Server.WriteTimeout,
+		"server.idle_timeout":        c.Server.IdleTimeout,
+		"server.drain_delay":         c.Server.DrainDelay,
+		"server.shutdown_timeout":    c.Server.ShutdownTimeout,
+	} {
+		if d.Duration < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", name))
+		}
+	}
+	if c.Server.ShutdownTimeout.Duration == 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+	if c.Database.DSN == "" {
+		errs = append(errs, errors.New("database.dsn is required"))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 || c.Database.ConnMaxLifetime.Duration < 0 {
+		errs = append(errs, errors.New("database pool settings must not be negative"))
+	}
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log.level %q must be debug, info, warn or error", c.Log.Level))
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			errs = append(errs, fmt.Errorf("cors origin %q must be * or start with http:// or https://", origin))
+		}
+	}
+	if c.Limits.MaxBodyBytes <= 0 {
+		errs = append(errs, errors.New("limits.max_body_bytes must be positive"))
+	}
+	if c.Limits.MaxPageSize <= 0 {
+		errs = append(errs, errors.New("limits.max_page_size must be positive"))
+	}
+	if c.Limits.DefaultPageSize <= 0 || c.Limits.DefaultPageSize > c.Limits.MaxPageSize {
+		errs = append(errs, errors.New("limits.default_page_size must be between 1 and limits.max_page_size"))
+	}
+	if c.Limits.MaxBatchSize <= 0 {
+		errs = append(errs, errors.New("limits.max_batch_size must be positive"))
+	}
+	if c.Limits.MaxTreeDepth <= 0 {
+		errs = append(errs, errors.New("limits.max_tree_depth must be positive"))
+	}
+	if c.Trash.Retention.Duration < 0 {
+		errs = append(errs, errors.New("trash.retention must not be negative"))
+	}
+	if c.Trash.Retention.Duration > 0 && c.Trash.PurgeInterval.Duration <= 0 {
+		errs = append(errs, errors.New("trash.purge_interval must be positive"))
+	}
+	switch c.Subtasks.OnDelete {
+	case "cascade", "orphan", "restrict":
+	default:
+		errs = append(errs, fmt.Errorf("subtasks.on_delete %q must be cascade, orphan or restrict", c.Subtasks.OnDelete))
+	}
+	switch c.Subtasks.OnComplete {
+	case "allow", "restrict":
+	default:
+		errs = append(errs, fmt.Errorf("subtasks.on_complete %q must be allow or restrict", c.Subtasks.OnComplete))
+	}
+	if c.Reminders.Interval.Duration < 0 {
+		errs = append(errs, errors.New("reminders.interval must not be negative"))
+	}
+	switch c.Reminders.Notifier {
+	case "log":
+	case "webhook":
+		if !strings.HasPrefix(c.Reminders.WebhookURL, "http://") && !strings.HasPrefix(c.Reminders.WebhookURL, "https://") {
+			errs = append(errs, fmt.Errorf("reminders.webhook_url %q must start with http:// or https://", c.Reminders.WebhookURL))
+		}
+	case "smtp":
+		if c.Reminders.SMTPAddr == "" || c.Reminders.SMTPFrom == "" || len(c.Reminders.SMTPTo) == 0 {
+			errs = append(errs, errors.New("reminders.smtp_addr, smtp_from and smtp_to are required by the smtp notifier"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("reminders.notifier %q must be log, webhook or smtp", c.Reminders.Notifier))
+	}
+	if c.Webhooks.Interval.Duration < 0 {
+		errs = append(errs, errors.New("webhooks.interval must not be negative"))
+	}
+	if c.Webhooks.Timeout.Duration <= 0 || c.Webhooks.Backoff.Duration <= 0 {
+		errs = append(errs, errors.New("webhooks.timeout and webhooks.backoff must be positive"))
+	}
+	if c.Webhooks.MaxAttempts < 1 || c.Webhooks.MaxAttempts > 20 {
+		errs = append(errs, errors.New("webhooks.max_attempts must be between 1 and 20"))
+	}
+	if c.Stream.PollInterval.Duration <= 0 || c.Stream.Heartbeat.Duration <= 0 {
+		errs = append(errs, errors.New("stream.poll_interval and stream.heartbeat must be positive"))
+	}
+	if c.Realtime.SendBuffer < 1 {
+		errs = append(errs, errors.New("realtime.send_buffer must be at least 1"))
+	}
+	if c.Realtime.PingInterval.Duration <= 0 || c.Realtime.WriteTimeout.Duration <= 0 {
+		errs = append(errs, errors.New("realtime.ping_interval and realtime.write_timeout must be positive"))
+	}
+	if _, err := workflow.Parse(c.Workflow.Transitions); err != nil {
+		errs = append(errs, fmt.Errorf("workflow.transitions: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// Duration reads Go duration strings such as "30s" or "5m" from YAML, TOML,
+// environment variables and flags alike.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
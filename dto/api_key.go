@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"time"
+
+	"task-api/model"
+)
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	Role string `json:"role" binding:"omitempty,oneof=viewer member admin" example:"member"` // defaults to member
+	// WorkspaceID binds the key to one workspace; omit for a key valid in all of them.
+	WorkspaceID *uint `json:"workspace_id,omitempty" example:"1"`
+}
+
+type APIKeyResponse struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	Role        string     `json:"role"`
+	WorkspaceID *uint      `json:"workspace_id,omitempty"`
+	Prefix      string     `json:"prefix"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CreatedAPIKeyResponse is only returned on creation; Key is never shown again.
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+func NewAPIKeyResponse(key *model.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:          key.ID,
+		Name:        key.Name,
+		Role:        key.Role,
+		WorkspaceID: key.WorkspaceID,
+		Prefix:      key.Prefix,
+		CreatedAt:   key.CreatedAt,
+		RevokedAt:   key.RevokedAt,
+	}
+}
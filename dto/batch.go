@@ -0,0 +1,33 @@
+package dto
+
+import "encoding/json"
+
+type BatchRequest struct {
+	// Atomic applies all operations in one transaction: if any fails, none is kept.
+	Atomic     bool             `json:"atomic" example:"true"`
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,dive"`
+}
+
+type BatchOperation struct {
+	Op string `json:"op" binding:"required,oneof=create update delete" example:"create"`
+	// ID of the task to update or delete.
+	ID uint `json:"id,omitempty" example:"1"`
+	// Version makes an update or delete conditional, like If-Match. It is
+	// required when the server requires If-Match.
+	Version uint `json:"version,omitempty" example:"3"`
+	// Task is a CreateTaskRequest for create and a ReplaceTaskRequest for update.
+	Task json.RawMessage `json:"task,omitempty" swaggertype:"object"`
+}
+
+type BatchResult struct {
+	Index  int           `json:"index"            example:"0"`
+	Status int           `json:"status"           example:"201"` // HTTP status the operation would have had on its own
+	Task   *TaskResponse `json:"task,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	// RolledBack is set when an atomic batch failed and nothing was applied.
+	RolledBack bool          `json:"rolled_back,omitempty"`
+	Results    []BatchResult `json:"results"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+// RealtimeRequest is a message a client sends over the /realtime WebSocket.
+// Without TaskID it is about the whole workspace.
+type RealtimeRequest struct {
+	Type        string `json:"type" example:"subscribe" enums:"subscribe,unsubscribe,presence"`
+	WorkspaceID uint   `json:"workspace_id" example:"1"`
+	TaskID      uint   `json:"task_id,omitempty" example:"42"`
+	// State is the presence on TaskID; empty clears it.
+	State string `json:"state,omitempty" example:"editing" enums:"viewing,editing"`
+}
+
+// RealtimeMessage is a message the server sends over the /realtime WebSocket.
+type RealtimeMessage struct {
+	Type        string `json:"type" example:"change" enums:"subscribed,unsubscribed,change,presence,error"`
+	WorkspaceID uint   `json:"workspace_id,omitempty" example:"1"`
+	TaskID      uint   `json:"task_id,omitempty" example:"42"`
+	// Event and Change are set on change messages; Change.ID can be passed
+	// to GET /tasks/stream as last_event_id to catch up after a disconnect.
+	Event  string           `json:"event,omitempty" example:"task.updated"`
+	Change *TaskStreamEvent `json:"change,omitempty"`
+	// User and State are set on presence messages; an empty State means the
+	// user left the task.
+	User  string `json:"user,omitempty" example:"alice"`
+	State string `json:"state,omitempty" example:"editing"`
+	// Presence lists who is on the subscribed workspace or task.
+	Presence []PresenceEntry `json:"presence,omitempty"`
+	Message  string          `json:"message,omitempty" example:"task not found"` // error messages
+}
+
+type PresenceEntry struct {
+	TaskID uint   `json:"task_id" example:"42"`
+	User   string `json:"user" example:"alice"`
+	State  string `json:"state" example:"editing"`
+}
@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"time"
+
+	"task-api/model"
+)
+
+// AddDependencyRequest names a task that blocks the task in the path.
+type AddDependencyRequest struct {
+	BlockerID uint `json:"blocker_id" binding:"required,min=1" example:"4"`
+}
+
+type DependencyResponse struct {
+	BlockerID uint      `json:"blocker_id" example:"4"`
+	BlockedID uint      `json:"blocked_id" example:"9"`
+	CreatedAt time.Time `json:"created_at" example:"2025-06-20T10:00:00Z"`
+}
+
+func NewDependencyResponse(dependency *model.TaskDependency) DependencyResponse {
+	return DependencyResponse{
+		BlockerID: dependency.BlockerID,
+		BlockedID: dependency.BlockedID,
+		CreatedAt: dependency.CreatedAt,
+	}
+}
+
+// TaskGraphResponse is the dependency graph around a task. Order lists every
+// task once, blockers before the tasks they block.
+type TaskGraphResponse struct {
+	Tasks []TaskResponse       `json:"tasks"`
+	Edges []DependencyResponse `json:"edges"`
+	Order []uint               `json:"order" example:"4,9"`
+}
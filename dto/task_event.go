@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"time"
+
+	"task-api/model"
+)
+
+type ListTaskEventsRequest struct {
+	Limit  int    `form:"limit" binding:"omitempty,min=1" example:"20"`
+	Cursor string `form:"cursor"`
+}
+
+type TaskEventResponse struct {
+	ID        uint                    `json:"id" example:"7"`
+	TaskID    uint                    `json:"task_id" example:"1"`
+	Type      string                  `json:"type" example:"updated" enums:"created,updated,deleted,restored,reopened,overdue"`
+	Actor     string                  `json:"actor" example:"apikey:3"`
+	Version   uint                    `json:"version" example:"4"`
+	Changes   map[string]model.Change `json:"changes,omitempty" swaggertype:"object"`
+	CreatedAt time.Time               `json:"created_at" example:"2025-06-20T10:00:00Z"`
+}
+
+func NewTaskEventResponse(event *model.TaskEvent) TaskEventResponse {
+	return TaskEventResponse{
+		ID:        event.ID,
+		TaskID:    event.TaskID,
+		Type:      event.Type,
+		Actor:     event.Actor,
+		Version:   event.Version,
+		Changes:   event.Changes,
+		CreatedAt: event.CreatedAt,
+	}
+}
+
+type TaskEventListResponse struct {
+	Data       []TaskEventResponse `json:"data"`
+	NextCursor string              `json:"next_cursor,omitempty" example:"MjA"`
+}
+
+// TaskStreamEvent is the data of a GET /tasks/stream event. Task is the task
+// as it is when the event is sent, so after a reconnect it may already be
+// newer than Version.
+type TaskStreamEvent struct {
+	TaskEventResponse
+	Task TaskResponse `json:"task"`
+}
+
+// StreamReadyEvent is the data of the ready event that opens a stream.
+type StreamReadyEvent struct {
+	LastEventID uint `json:"last_event_id" example:"42"`
+}
@@ -2,6 +2,8 @@ package dto
 
 import (
 	"time"
+
+	"task-api/model"
 )
 
 type CreateTaskRequest struct {
@@ -9,12 +11,75 @@ type CreateTaskRequest struct {
 	DueDate  *time.Time `json:"due_date,omitempty"                 example:"2025-06-20T10:00:00Z"`
 	Assignee string     `json:"assignee,omitempty" binding:"max=10" example:"Barney"`
 	Tags     []string   `json:"tags,omitempty" binding:"max=3,dive,max=10" example:"[\"doc\",\"internal\",\"urgent\"]"`
+	// Priority defaults to P2 when omitted.
+	Priority *model.Priority `json:"priority,omitempty" swaggertype:"string" enums:"P0,P1,P2,P3,P4" example:"P1"`
+	// ParentID makes the task a subtask of another task in the workspace.
+	ParentID *uint `json:"parent_id,omitempty" binding:"omitempty,min=1" example:"3"`
+	// Recurrence is an RFC 5545 RRULE; recurring tasks need a due_date.
+	Recurrence string `json:"recurrence,omitempty" binding:"max=255" example:"FREQ=WEEKLY;BYDAY=MO"`
+	// Reminders are offsets before the due date, such as 24h or 30m, at
+	// which the assignee is reminded of the task.
+	Reminders []string `json:"reminders,omitempty" binding:"max=5,dive,max=16" example:"[\"24h\",\"1h\"]"`
+}
+
+// ReplaceTaskRequest is the full task accepted by PUT, and the document a
+// PATCH must still produce. Omitted optional fields are cleared.
+type ReplaceTaskRequest struct {
+	CreateTaskRequest
+	// Status also accepts the legacy 0 (todo) and 1 (done); omitted means todo.
+	Status model.Status `json:"status,omitempty" binding:"omitempty,oneof=todo in_progress blocked in_review done cancelled" swaggertype:"string" enums:"todo,in_progress,blocked,in_review,done,cancelled" example:"in_progress"`
+}
+
+// TaskFilterRequest holds the filters shared by the task list and stream.
+type TaskFilterRequest struct {
+	Status        string     `form:"status" binding:"omitempty,oneof=todo in_progress blocked in_review done cancelled 0 1" example:"in_progress"`
+	Priority      []string   `form:"priority"                                        example:"P0,P1"`
+	Assignee      string     `form:"assignee"       binding:"omitempty,max=10"       example:"Barney"`
+	Tags          []string   `form:"tags"                                            example:"doc,urgent"`
+	TagsMatch     string     `form:"tags_match"     binding:"omitempty,oneof=any all" example:"any"`
+	DueAfter      *time.Time `form:"due_after"                                       example:"2025-06-01T00:00:00Z"`
+	DueBefore     *time.Time `form:"due_before"                                      example:"2025-07-01T00:00:00Z"`
+	CreatedAfter  *time.Time `form:"created_after"                                   example:"2025-06-01T00:00:00Z"`
+	CreatedBefore *time.Time `form:"created_before"                                  example:"2025-07-01T00:00:00Z"`
+	UpdatedAfter  *time.Time `form:"updated_after"                                   example:"2025-06-01T00:00:00Z"`
+	UpdatedBefore *time.Time `form:"updated_before"                                  example:"2025-07-01T00:00:00Z"`
+	// Overdue lists only open tasks whose due date has passed.
+	Overdue bool `form:"overdue" example:"true"`
+}
+
+type ListTasksRequest struct {
+	TaskFilterRequest
+	Sort   string `form:"sort"                             example:"-due_date"` // 欄位名稱，前綴 - 表示遞減
+	Limit  int    `form:"limit"  binding:"omitempty,min=1" example:"20"`
+	Cursor string `form:"cursor"`
+	// IncludeDeleted also lists tasks in the trash.
+	IncludeDeleted bool `form:"include_deleted" example:"true"`
+}
+
+// StreamTasksRequest is the query of GET /tasks/stream. LastEventID is for
+// clients that cannot send the Last-Event-ID header; the header wins.
+type StreamTasksRequest struct {
+	TaskFilterRequest
+	LastEventID string `form:"last_event_id" example:"42"`
+}
+
+// MoveTaskRequest places a task directly after or directly before another
+// task of the same workspace; exactly one of the two must be set.
+type MoveTaskRequest struct {
+	AfterID  uint `json:"after_id,omitempty"  binding:"required_without=BeforeID,excluded_with=BeforeID" example:"12"`
+	BeforeID uint `json:"before_id,omitempty" binding:"required_without=AfterID"                        example:"7"`
+}
+
+// UpdateSeriesRequest changes every open occurrence of a recurring series.
+// Omitted fields are left alone; an empty recurrence ends the series.
+type UpdateSeriesRequest struct {
+	Name       *string         `json:"name,omitempty" binding:"omitempty,min=1,max=100" example:"check backups"`
+	Assignee   *string         `json:"assignee,omitempty" binding:"omitempty,max=10" example:"Barney"`
+	Tags       *[]string       `json:"tags,omitempty" binding:"omitempty,max=3,dive,max=10" example:"[\"ops\"]"`
+	Priority   *model.Priority `json:"priority,omitempty" swaggertype:"string" enums:"P0,P1,P2,P3,P4" example:"P1"`
+	Recurrence *string         `json:"recurrence,omitempty" binding:"omitempty,max=255" example:"FREQ=WEEKLY;BYDAY=TU"`
 }
 
-type UpdateTaskRequest struct {
-	Name     *string    `json:"name,omitempty"     binding:"omitempty,max=100"   example:"write a blog"`
-	Status   *int       `json:"status,omitempty"   binding:"omitempty,oneof=0 1" example:"1"` // 0 或 1
-	DueDate  *time.Time `json:"due_date,omitempty"                                      example:"2025-06-20T10:00:00Z"`
-	Assignee *string    `json:"assignee,omitempty" binding:"omitempty,max=10"     example:"Barney"`
-	Tags     *[]string  `json:"tags,omitempty"     binding:"omitempty,max=3,dive,max=10" example:"[\"doc\",\"internal\",\"urgent\"]"`
+type TaskTreeRequest struct {
+	Depth int `form:"depth" binding:"omitempty,min=1" example:"3"`
 }
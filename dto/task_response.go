@@ -2,19 +2,72 @@ package dto
 
 import (
 	"time"
+
+	"task-api/model"
 )
 
 type TaskResponse struct {
-	ID        uint       `json:"id" example:"1"`
-	Name      string     `json:"name" example:"write a blog"`
-	Status    int        `json:"status" example:"1"`
-	DueDate   *time.Time `json:"due_date,omitempty" example:"2025-06-20T10:00:00Z"`
-	Assignee  string     `json:"assignee" example:"Barney"`
-	Tags      []string   `json:"tags,omitempty" example:"[\"doc\",\"internal\",\"urgent\"]"`
-	CreatedAt time.Time  `json:"created_at" example:"2025-06-20T10:00:00Z"`
-	UpdatedAt time.Time  `json:"updated_at" example:"2025-06-20T10:00:00Z"`
+	ID          uint           `json:"id" example:"1"`
+	WorkspaceID uint           `json:"workspace_id" example:"1"`
+	ParentID    *uint          `json:"parent_id,omitempty" example:"3"`
+	SeriesID    *uint          `json:"series_id,omitempty" example:"5"` // first occurrence of a recurring series
+	Name        string         `json:"name" example:"write a blog"`
+	Priority    model.Priority `json:"priority" swaggertype:"string" enums:"P0,P1,P2,P3,P4" example:"P1"`
+	Rank        string         `json:"rank" example:"a3"` // position in manual ordering (sort=rank)
+	Status      model.Status   `json:"status" swaggertype:"string" enums:"todo,in_progress,blocked,in_review,done,cancelled" example:"in_progress"`
+	DueDate     *time.Time     `json:"due_date,omitempty" example:"2025-06-20T10:00:00Z"`
+	Assignee    string         `json:"assignee" example:"Barney"`
+	Tags        []string       `json:"tags,omitempty" example:"[\"doc\",\"internal\",\"urgent\"]"`
+	CreatedAt   time.Time      `json:"created_at" example:"2025-06-20T10:00:00Z"`
+	UpdatedAt   time.Time      `json:"updated_at" example:"2025-06-20T10:00:00Z"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty" example:"2025-06-21T08:00:00Z"` // set while the status is done
+	Recurrence  string         `json:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO"`
+	Reminders   []string       `json:"reminders,omitempty" example:"[\"24h\",\"1h\"]"`
+	OverdueAt   *time.Time     `json:"overdue_at,omitempty" example:"2025-06-20T10:01:00Z"` // set once the due date passed while the task was open
+	Version     uint           `json:"version" example:"3"`
+	DeletedAt   *time.Time     `json:"deleted_at,omitempty" example:"2025-06-21T08:00:00Z"` // only set for tasks in the trash
+	Progress    *int           `json:"progress,omitempty" example:"50"`                     // percent of subtasks done, only on tasks that have subtasks
+}
+
+// TaskTreeResponse is a task with its subtasks nested to the requested depth.
+type TaskTreeResponse struct {
+	TaskResponse
+	Children []TaskTreeResponse `json:"children"`
+}
+
+func NewTaskResponse(task *model.Task) TaskResponse {
+	response := TaskResponse{
+		ID:          task.ID,
+		WorkspaceID: task.WorkspaceID,
+		ParentID:    task.ParentID,
+		SeriesID:    task.SeriesID,
+		Name:        task.Name,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		Rank:        task.Rank,
+		DueDate:     task.DueDate,
+		Assignee:    task.Assignee,
+		Tags:        task.Tags,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.UpdatedAt,
+		CompletedAt: task.CompletedAt,
+		Recurrence:  task.Recurrence,
+		Reminders:   task.Reminders,
+		OverdueAt:   task.OverdueAt,
+		Version:     task.Version,
+	}
+	if task.DeletedAt.Valid {
+		response.DeletedAt = &task.DeletedAt.Time
+	}
+	return response
 }
 
 type ErrorResponse struct {
-	Error string `json:"error" example:"invalid id format"`
+	Error     string `json:"error" example:"invalid id format"`
+	RequestID string `json:"request_id,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+}
+
+type TaskListResponse struct {
+	Data       []TaskResponse `json:"data"`
+	NextCursor string         `json:"next_cursor,omitempty" example:"eyJzIjoiaWQiLCJkIjpmYWxzZSwidiI6MjAsImlkIjoyMH0"`
 }
@@ -0,0 +1,100 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"task-api/model"
+)
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required,http_url,max=2048" example:"https://chat.example.com/hooks/tasks"`
+	// Secret signs the payloads; one is generated when omitted.
+	Secret string `json:"secret,omitempty" binding:"omitempty,min=16,max=255" example:"3f1c0e9d2b7a4c58a6e1"`
+	// Events filters what is delivered; omit for every event.
+	Events []string `json:"events,omitempty" binding:"omitempty,dive,oneof=task.created task.updated task.deleted task.completed" example:"[\"task.created\",\"task.completed\"]"`
+}
+
+type WebhookResponse struct {
+	ID          uint      `json:"id" example:"1"`
+	WorkspaceID uint      `json:"workspace_id" example:"1"`
+	URL         string    `json:"url" example:"https://chat.example.com/hooks/tasks"`
+	Events      []string  `json:"events" example:"[\"task.created\",\"task.completed\"]"` // empty for every event
+	CreatedAt   time.Time `json:"created_at" example:"2025-06-20T10:00:00Z"`
+}
+
+// CreatedWebhookResponse is only returned on creation; Secret is never shown again.
+type CreatedWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret" example:"3f1c0e9d2b7a4c58a6e1"`
+}
+
+func NewWebhookResponse(webhook *model.Webhook) WebhookResponse {
+	events := webhook.EventList()
+	if events == nil {
+		events = []string{}
+	}
+	return WebhookResponse{
+		ID:          webhook.ID,
+		WorkspaceID: webhook.WorkspaceID,
+		URL:         webhook.URL,
+		Events:      events,
+		CreatedAt:   webhook.CreatedAt,
+	}
+}
+
+// WebhookPayload is the body POSTed to webhooks. Task is the task as it was
+// right after the change.
+type WebhookPayload struct {
+	Event      string                  `json:"event" example:"task.completed" enums:"task.created,task.updated,task.deleted,task.completed"`
+	OccurredAt time.Time               `json:"occurred_at" example:"2025-06-20T10:00:00Z"`
+	Actor      string                  `json:"actor" example:"apikey:3"`
+	Task       TaskResponse            `json:"task"`
+	Changes    map[string]model.Change `json:"changes,omitempty" swaggertype:"object"`
+}
+
+type ListWebhookDeliveriesRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending delivered failed" example:"failed"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1" example:"20"`
+	Cursor string `form:"cursor"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uint            `json:"id" example:"12"`
+	WebhookID      uint            `json:"webhook_id" example:"1"`
+	TaskID         uint            `json:"task_id" example:"3"`
+	Event          string          `json:"event" example:"task.updated"`
+	Status         string          `json:"status" example:"pending" enums:"pending,delivered,failed"`
+	Attempts       int             `json:"attempts" example:"2"`
+	ResponseStatus int             `json:"response_status,omitempty" example:"502"` // of the last attempt
+	LastError      string          `json:"last_error,omitempty" example:"webhook answered 502 Bad Gateway"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty" example:"2025-06-20T10:01:00Z"` // only while pending
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" example:"2025-06-20T10:00:01Z"`
+	CreatedAt      time.Time       `json:"created_at" example:"2025-06-20T10:00:00Z"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+}
+
+func NewWebhookDeliveryResponse(delivery *model.WebhookDelivery) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		TaskID:         delivery.TaskID,
+		Event:          delivery.Event,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+		Payload:        json.RawMessage(delivery.Payload),
+	}
+	if delivery.Status == model.DeliveryPending {
+		response.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	return response
+}
+
+type WebhookDeliveryListResponse struct {
+	Data       []WebhookDeliveryResponse `json:"data"`
+	NextCursor string                    `json:"next_cursor,omitempty" example:"MTI"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"time"
+
+	"task-api/model"
+)
+
+type CreateWorkspaceRequest struct {
+	Name string `json:"name" binding:"required,max=100" example:"platform-team"`
+}
+
+type WorkspaceResponse struct {
+	ID        uint      `json:"id" example:"1"`
+	Name      string    `json:"name" example:"platform-team"`
+	CreatedAt time.Time `json:"created_at" example:"2025-06-20T10:00:00Z"`
+}
+
+func NewWorkspaceResponse(ws *model.Workspace) WorkspaceResponse {
+	return WorkspaceResponse{ID: ws.ID, Name: ws.Name, CreatedAt: ws.CreatedAt}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+
+	"task-api/dto"
+	"task-api/pkg/auth"
+	"task-api/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	keys       repository.APIKeyRepositoryInterface
+	workspaces repository.WorkspaceRepositoryInterface
+}
+
+func NewAPIKeyHandler(keys repository.APIKeyRepositoryInterface, workspaces repository.WorkspaceRepositoryInterface) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys, workspaces: workspaces}
+}
+
+// CreateAPIKey godoc
+// @Summary      Create an API key
+// @Description  Issue a new API key. The plaintext key is only returned in this response.
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        key body dto.CreateAPIKeyRequest true "Key to create"
+// @Success      201 {object} dto.CreatedAPIKeyResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var request dto.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	role := auth.Role(request.Role)
+	if role == "" {
+		role = auth.RoleMember
+	}
+	if request.WorkspaceID != nil {
+		if _, err := h.workspaces.GetWorkspace(c.Request.Context(), *request.WorkspaceID); err != nil {
+			respondRepoError(c, err)
+			return
+		}
+	}
+	created, key, err := auth.IssueKey(c.Request.Context(), h.keys, request.Name, role, request.WorkspaceID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errInternal)
+		return
+	}
+	c.JSON(http.StatusCreated, dto.CreatedAPIKeyResponse{APIKeyResponse: dto.NewAPIKeyResponse(created), Key: key})
+}
+
+// ListAPIKeys godoc
+// @Summary      List API keys
+// @Description  List all API keys, including revoked ones. Keys themselves are never returned.
+// @Tags         api-keys
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {array}  dto.APIKeyResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.keys.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errInternal)
+		return
+	}
+	response := make([]dto.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		response = append(response, dto.NewAPIKeyResponse(&keys[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key
+// @Description  Revoke an API key; requests using it are rejected immediately
+// @Tags         api-keys
+// @Security     BearerAuth
+// @Param        id path int true "API key ID"
+// @Success      204
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.keys.RevokeAPIKey(c.Request.Context(), id); err != nil {
+		respondRepoError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
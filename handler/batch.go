@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/model"
+	"task-api/pkg/auth"
+	"task-api/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// batchAction is the custom method suffix of POST /tasks:batch.
+const batchAction = ":batch"
+
+// errRollback aborts the transaction of an atomic batch after a failed operation.
+var errRollback = errors.New("batch operation failed")
+
+// BatchTasks godoc
+// @Summary      Create, update and delete tasks in bulk
+// @Description  Apply a list of create, update (full replacement, like PUT) and delete operations. With atomic set, all operations run in one transaction and none is kept if any fails; otherwise each is applied on its own. Every operation gets a result with the status it would have had as a single request; when concurrency.require_if_match is set, updates and deletes without a version fail with 428.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws    path int              true "Workspace ID"
+// @Param        batch body dto.BatchRequest true "Operations to apply"
+// @Success      200 {object} dto.BatchResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      413 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks:batch [post]
+func (h *TaskHandler) BatchTasks(c *gin.Context) {
+	// gin 不支援路徑中的字面冒號，":batch" 以參數方式接收
+	if c.Param("action") != batchAction {
+		respondError(c, http.StatusNotFound, "unknown task action")
+		return
+	}
+
+	var request dto.BatchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if len(request.Operations) > h.limits.MaxBatchSize {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("a batch must not exceed %d operations", h.limits.MaxBatchSize))
+		return
+	}
+
+	ctx := c.Request.Context()
+	b := batch{principal: auth.FromContext(ctx), scope: workspaceScope(c), concurrency: h.concurrency}
+	response := dto.BatchResponse{Results: make([]dto.BatchResult, len(request.Operations))}
+
+	if !request.Atomic {
+		for i, op := range request.Operations {
+			response.Results[i] = b.apply(ctx, h.repo, i, op)
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	failed := -1
+	err := h.repo.Transaction(ctx, func(repo repository.RepositoryInterface) error {
+		for i, op := range request.Operations {
+			response.Results[i] = b.apply(ctx, repo, i, op)
+			if response.Results[i].Error != "" {
+				failed = i
+				return errRollback
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRollback) {
+		respondError(c, http.StatusInternalServerError, errInternal)
+		return
+	}
+	if failed >= 0 {
+		response.RolledBack = true
+		rolledBack(response.Results, failed)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// rolledBack rewrites the results of an atomic batch whose operation failed
+// at index failed: the operations around it were not applied either.
+func rolledBack(results []dto.BatchResult, failed int) {
+	message := fmt.Sprintf("not applied: operation %d failed", failed)
+	for i := range results {
+		if i == failed {
+			continue
+		}
+		results[i] = dto.BatchResult{Index: i, Status: http.StatusFailedDependency, Error: message}
+	}
+}
+
+// batch holds what every operation of one batch request shares.
+type batch struct {
+	principal   *auth.Principal
+	scope       repository.Scope
+	concurrency config.ConcurrencyConfig
+}
+
+func (b batch) apply(ctx context.Context, repo repository.RepositoryInterface, index int, op dto.BatchOperation) dto.BatchResult {
+	var (
+		status int
+		task   *model.Task
+		err    error
+	)
+	switch op.Op {
+	case "create":
+		status, task, err = b.create(ctx, repo, op)
+	case "update":
+		status, task, err = b.update(ctx, repo, op)
+	case "delete":
+		status, err = b.delete(ctx, repo, op)
+	}
+
+	result := dto.BatchResult{Index: index, Status: status}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if task != nil {
+		response := dto.NewTaskResponse(task)
+		result.Task = &response
+	}
+	return result
+}
+
+func (b batch) create(ctx context.Context, repo repository.RepositoryInterface, op dto.BatchOperation) (int, *model.Task, error) {
+	if !b.principal.Can(auth.PermTaskCreate) {
+		return http.StatusForbidden, nil, &auth.ForbiddenError{Role: b.principal.Role, Permission: auth.PermTaskCreate}
+	}
+	var request dto.CreateTaskRequest
+	if err := decodeOperationTask(op, &request); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	if !memberAssignee(b.principal, &request.Assignee) {
+		return http.StatusForbidden, nil, errors.New(errCreateForOthers)
+	}
+
+	task, err := repo.CreateTask(ctx, b.scope, &model.Task{
+		Name:       request.Name,
+		Priority:   priorityOrDefault(request.Priority),
+		DueDate:    request.DueDate,
+		Assignee:   request.Assignee,
+		Tags:       request.Tags,
+		ParentID:   request.ParentID,
+		Recurrence: request.Recurrence,
+		Reminders:  request.Reminders,
+	})
+	if err != nil {
+		status, message := repoErrorStatus(err)
+		return status, nil, errors.New(message)
+	}
+	return http.StatusCreated, task, nil
+}
+
+func (b batch) update(ctx context.Context, repo repository.RepositoryInterface, op dto.BatchOperation) (int, *model.Task, error) {
+	if !b.principal.Can(auth.PermTaskUpdate) {
+		return http.StatusForbidden, nil, &auth.ForbiddenError{Role: b.principal.Role, Permission: auth.PermTaskUpdate}
+	}
+	if op.ID == 0 {
+		return http.StatusBadRequest, nil, errors.New("id is required")
+	}
+	if err := b.requireVersion(op); err != nil {
+		return http.StatusPreconditionRequired, nil, err
+	}
+	var request dto.ReplaceTaskRequest
+	if err := decodeOperationTask(op, &request); err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	scope, ok := ownerScope(b.principal, b.scope, &request)
+	if !ok {
+		return http.StatusForbidden, nil, errors.New(errReassign)
+	}
+
+	task, err := repo.UpdateTask(ctx, scope, replaceFields(request), op.ID, op.Version)
+	if err != nil {
+		status, message := repoErrorStatus(err)
+		return status, nil, errors.New(message)
+	}
+	return http.StatusOK, task, nil
+}
+
+func (b batch) delete(ctx context.Context, repo repository.RepositoryInterface, op dto.BatchOperation) (int, error) {
+	if !b.principal.Can(auth.PermTaskDelete) {
+		return http.StatusForbidden, &auth.ForbiddenError{Role: b.principal.Role, Permission: auth.PermTaskDelete}
+	}
+	if op.ID == 0 {
+		return http.StatusBadRequest, errors.New("id is required")
+	}
+	if err := b.requireVersion(op); err != nil {
+		return http.StatusPreconditionRequired, err
+	}
+	if err := repo.DeleteTask(ctx, b.scope, op.ID, op.Version); err != nil {
+		status, message := repoErrorStatus(err)
+		return status, errors.New(message)
+	}
+	return http.StatusNoContent, nil
+}
+
+// requireVersion is the If-Match requirement of single requests applied to
+// op: without a version an update or delete could overwrite anything.
+func (b batch) requireVersion(op dto.BatchOperation) error {
+	if b.concurrency.RequireIfMatch && op.Version == repository.AnyVersion {
+		return errors.New("version is required")
+	}
+	return nil
+}
+
+// decodeOperationTask decodes and validates op.Task like a request body.
+func decodeOperationTask(op dto.BatchOperation, request interface{}) error {
+	if len(op.Task) == 0 {
+		return errors.New("task is required")
+	}
+	if err := json.Unmarshal(op.Task, request); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(request)
+}
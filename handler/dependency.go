@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"task-api/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddDependency godoc
+// @Summary      Add a blocker to a task
+// @Description  Record that blocker_id blocks the task: the task cannot be moved to done while the blocker is open. A dependency that would close a cycle is rejected with 409 and the cycle in the error.
+// @Tags         dependencies
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws         path int                      true "Workspace ID"
+// @Param        id         path int                      true "Blocked task ID"
+// @Param        dependency body dto.AddDependencyRequest true "Blocking task"
+// @Success      201 {object} dto.DependencyResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/{id}/dependencies [post]
+func (h *TaskHandler) AddDependency(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var request dto.AddDependencyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	dependency, err := h.repo.AddDependency(c.Request.Context(), ownTasks(c), id, request.BlockerID)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, dto.NewDependencyResponse(dependency))
+}
+
+// RemoveDependency godoc
+// @Summary      Remove a blocker from a task
+// @Tags         dependencies
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws         path int true "Workspace ID"
+// @Param        id         path int true "Blocked task ID"
+// @Param        blocker_id path int true "Blocking task ID"
+// @Success      204 "No Content"
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/{id}/dependencies/{blocker_id} [delete]
+func (h *TaskHandler) RemoveDependency(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	blockerID, err := strconv.ParseUint(c.Param("blocker_id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid blocker_id format")
+		return
+	}
+
+	if err := h.repo.RemoveDependency(c.Request.Context(), ownTasks(c), id, uint(blockerID)); err != nil {
+		respondRepoError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetTaskGraph godoc
+// @Summary      Get a task's dependency graph
+// @Description  Get the task with every task that transitively blocks it or is blocked by it, the dependencies between them, and a topological order for planning: blockers first, then by priority and rank. Tasks in the trash are left out.
+// @Tags         dependencies
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws path int true "Workspace ID"
+// @Param        id path int true "Task ID"
+// @Success      200 {object} dto.TaskGraphResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/{id}/graph [get]
+func (h *TaskHandler) GetTaskGraph(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	graph, err := h.repo.GetTaskGraph(c.Request.Context(), workspaceScope(c), id)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+
+	response := dto.TaskGraphResponse{
+		Tasks: make([]dto.TaskResponse, 0, len(graph.Tasks)),
+		Edges: make([]dto.DependencyResponse, 0, len(graph.Edges)),
+		Order: graph.Order,
+	}
+	for i := range graph.Tasks {
+		response.Tasks = append(response.Tasks, dto.NewTaskResponse(&graph.Tasks[i]))
+	}
+	for i := range graph.Edges {
+		response.Edges = append(response.Edges, dto.NewDependencyResponse(&graph.Edges[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"task-api/dto"
+	"task-api/model"
+	"task-api/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// taskETag is the strong entity tag of a task's current version.
+func taskETag(task *model.Task) string {
+	return `"` + strconv.FormatUint(uint64(task.Version), 10) + `"`
+}
+
+// respondTask writes task with its ETag.
+func respondTask(c *gin.Context, status int, task *model.Task) {
+	c.Header("ETag", taskETag(task))
+	c.JSON(status, dto.NewTaskResponse(task))
+}
+
+// ifMatchVersion returns the version a write is conditioned on.
+// An absent header or "*" yields repository.AnyVersion, unless If-Match is
+// required, in which case an absent header is answered with 428.
+func (h *TaskHandler) ifMatchVersion(c *gin.Context) (uint, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		if h.concurrency.RequireIfMatch {
+			respondError(c, http.StatusPreconditionRequired, "If-Match header is required")
+			return 0, false
+		}
+		return repository.AnyVersion, true
+	}
+	if header == "*" {
+		return repository.AnyVersion, true
+	}
+
+	// If-Match uses strong comparison, so weak tags never match.
+	version, err := strconv.ParseUint(strings.Trim(header, `"`), 10, 64)
+	if err != nil || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || version == 0 {
+		respondError(c, http.StatusPreconditionFailed, "If-Match must be a single ETag returned by the API")
+		return 0, false
+	}
+	return uint(version), true
+}
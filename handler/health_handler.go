@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"task-api/dto"
+	"task-api/pkg/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pinger is satisfied by *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// MigrationChecker is satisfied by *migrate.Migrator.
+type MigrationChecker interface {
+	Pending() (int, error)
+}
+
+type HealthHandler struct {
+	readiness  *health.Readiness
+	db         Pinger
+	migrations MigrationChecker
+}
+
+func NewHealthHandler(readiness *health.Readiness, db Pinger, migrations MigrationChecker) *HealthHandler {
+	return &HealthHandler{readiness: readiness, db: db, migrations: migrations}
+}
+
+// Healthz godoc
+// @Summary      Liveness probe
+// @Description  Always 200 while the process is running; does not touch the database
+// @Tags         health
+// @Produce      json
+// @Success      200 {object} dto.HealthResponse
+// @Router       /healthz [get]
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.HealthResponse{Status: "ok"})
+}
+
+// Readyz godoc
+// @Summary      Readiness probe
+// @Description  200 when the database answers a ping and all migrations are applied; 503 otherwise or once shutdown has started
+// @Tags         health
+// @Produce      json
+// @Success      200 {object} dto.HealthResponse
+// @Failure      503 {object} dto.HealthResponse
+// @Router       /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	if h.readiness.Draining() {
+		c.JSON(http.StatusServiceUnavailable, dto.HealthResponse{Status: "draining"})
+		return
+	}
+
+	checks := map[string]string{}
+	ready := true
+	if h.db != nil {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		if err := h.db.PingContext(ctx); err != nil {
+			checks["database"] = err.Error()
+			ready = false
+		} else {
+			checks["database"] = "ok"
+		}
+	}
+	if h.migrations != nil {
+		pending, err := h.migrations.Pending()
+		switch {
+		case err != nil:
+			checks["migrations"] = err.Error()
+			ready = false
+		case pending > 0:
+			checks["migrations"] = fmt.Sprintf("%d pending", pending)
+			ready = false
+		default:
+			checks["migrations"] = "ok"
+		}
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, dto.HealthResponse{Status: "unavailable", Checks: checks})
+		return
+	}
+	c.JSON(http.StatusOK, dto.HealthResponse{Status: "ok", Checks: checks})
+}
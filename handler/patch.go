@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"task-api/dto"
+	"task-api/model"
+	"task-api/pkg/auth"
+	"task-api/repository"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+
+	// maxPatchAttempts bounds the read-patch-write retries of a PATCH sent
+	// without If-Match when another writer keeps winning the race.
+	maxPatchAttempts = 3
+)
+
+// patchFunc applies a patch document to a task document.
+type patchFunc func(doc []byte) ([]byte, error)
+
+// PatchTask godoc
+// @Summary      Patch a task
+// @Description  Partially update a task with a JSON Merge Patch (RFC 7396, null clears a field) or a JSON Patch (RFC 6902). The patched task must pass the same validation as PUT.
+// @Tags         tasks
+// @Accept       application/merge-patch+json,application/json-patch+json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws path int true "Workspace ID"
+// @Param        id path int true "Task ID"
+// @Param        If-Match header string false "ETag the update is conditioned on (required when concurrency.require_if_match is set)"
+// @Param        patch body object true "Merge patch object or JSON Patch operation array"
+// @Success      200 {object} dto.TaskResponse
+// @Header       200 {string} ETag "New version of the task"
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      412 {object} dto.ErrorResponse
+// @Failure      415 {object} dto.ErrorResponse
+// @Failure      422 {object} dto.ErrorResponse
+// @Failure      428 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/{id} [patch]
+func (h *TaskHandler) PatchTask(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	version, ok := h.ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	apply, ok := readPatch(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	principal := auth.FromContext(ctx)
+	for attempt := 1; ; attempt++ {
+		current, err := h.repo.GetTaskByID(ctx, workspaceScope(c), id)
+		if err != nil {
+			respondRepoError(c, err)
+			return
+		}
+		if version != repository.AnyVersion && current.Version != version {
+			respondRepoError(c, repository.ErrVersionMismatch)
+			return
+		}
+		if !principal.Can(auth.PermTaskAny) && current.Assignee != principal.Name {
+			respondRepoError(c, repository.ErrNotOwner)
+			return
+		}
+
+		request, err := patchTask(current, apply)
+		if err != nil {
+			respondError(c, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		scope, ok := updateScope(c, &request)
+		if !ok {
+			return
+		}
+
+		// patch 是依照讀到的版本算出來的，寫入時一律以該版本為條件
+		task, err := h.repo.UpdateTask(ctx, scope, replaceFields(request), id, current.Version)
+		if errors.Is(err, repository.ErrVersionMismatch) && version == repository.AnyVersion && attempt < maxPatchAttempts {
+			continue
+		}
+		if err != nil {
+			respondRepoError(c, err)
+			return
+		}
+		respondTask(c, http.StatusOK, task)
+		return
+	}
+}
+
+// readPatch parses the body according to its Content-Type.
+func readPatch(c *gin.Context) (patchFunc, bool) {
+	mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil || (mediaType != mergePatchType && mediaType != jsonPatchType) {
+		respondError(c, http.StatusUnsupportedMediaType, "Content-Type must be "+mergePatchType+" or "+jsonPatchType)
+		return nil, false
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		respondBindError(c, err)
+		return nil, false
+	}
+
+	if mediaType == mergePatchType {
+		if !json.Valid(body) {
+			respondError(c, http.StatusBadRequest, "invalid merge patch: malformed JSON")
+			return nil, false
+		}
+		return func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, body)
+		}, true
+	}
+
+	patch, err := jsonpatch.DecodePatch(body)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid JSON patch: "+err.Error())
+		return nil, false
+	}
+	return func(doc []byte) ([]byte, error) {
+		return patch.Apply(doc)
+	}, true
+}
+
+// patchTask applies patch to the editable fields of task and validates the
+// result like a PUT body. Its errors are answered with 422.
+func patchTask(task *model.Task, apply patchFunc) (dto.ReplaceTaskRequest, error) {
+	var request dto.ReplaceTaskRequest
+	doc, err := json.Marshal(taskDocument(task))
+	if err != nil {
+		return request, err
+	}
+	patched, err := apply(doc)
+	if err != nil {
+		return request, fmt.Errorf("patch cannot be applied: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		return request, fmt.Errorf("patched task is invalid: %w", err)
+	}
+	if err := binding.Validator.ValidateStruct(&request); err != nil {
+		return request, fmt.Errorf("patched task is invalid: %w", err)
+	}
+	return request, nil
+}
+
+// taskDocument is the JSON document patches operate on. Every editable field
+// is present, so "replace" works on unset fields and "add" on /tags/-.
+func taskDocument(task *model.Task) map[string]interface{} {
+	var assignee *string
+	if task.Assignee != "" {
+		assignee = &task.Assignee
+	}
+	var recurrence *string
+	if task.Recurrence != "" {
+		recurrence = &task.Recurrence
+	}
+	tags := []string(task.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	reminders := []string(task.Reminders)
+	if reminders == nil {
+		reminders = []string{}
+	}
+	return map[string]interface{}{
+		"name":       task.Name,
+		"status":     task.Status,
+		"priority":   task.Priority,
+		"due_date":   task.DueDate,
+		"assignee":   assignee,
+		"tags":       tags,
+		"parent_id":  task.ParentID,
+		"recurrence": recurrence,
+		"reminders":  reminders,
+	}
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"task-api/pkg/auth"
+	"task-api/pkg/realtime"
+	"task-api/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+type RealtimeHandler struct {
+	hub        *realtime.Hub
+	tasks      repository.RepositoryInterface
+	workspaces repository.WorkspaceRepositoryInterface
+	upgrader   websocket.Upgrader
+}
+
+// NewRealtimeHandler accepts WebSocket connections from the page's own host
+// and from origins, like the CORS middleware ("*" allows any).
+func NewRealtimeHandler(hub *realtime.Hub, tasks repository.RepositoryInterface, workspaces repository.WorkspaceRepositoryInterface, origins []string) *RealtimeHandler {
+	return &RealtimeHandler{
+		hub:        hub,
+		tasks:      tasks,
+		workspaces: workspaces,
+		upgrader:   websocket.Upgrader{CheckOrigin: checkOrigin(origins)},
+	}
+}
+
+// Connect godoc
+// @Summary      Open the real-time channel
+// @Description  Upgrade to a WebSocket for live collaboration. Send dto.RealtimeRequest messages to subscribe to (or unsubscribe from) a workspace or one of its tasks, and to set your presence on a task (state viewing, editing or empty to leave). The server sends dto.RealtimeMessage: subscribed (with who is there), change (task.created, task.updated, task.completed or task.deleted with the change of GET /tasks/stream), presence and error. A client that falls more than realtime.send_buffer messages behind is disconnected with close code 1013 and should catch up with GET /tasks/stream?last_event_id= before resubscribing.
+// @Tags         realtime
+// @Security     BearerAuth
+// @Param        name query string false "Name shown in presence when authentication is disabled"
+// @Success      101 {object} dto.RealtimeMessage
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Router       /realtime [get]
+func (h *RealtimeHandler) Connect(c *gin.Context) {
+	principal := auth.FromContext(c.Request.Context())
+	user := c.Query("name")
+	if principal != nil {
+		user = principal.Name
+		if user == "" {
+			user = principal.Subject
+		}
+	}
+	if user == "" {
+		user = "anonymous"
+	}
+
+	// Upgrade 失敗時已回覆 400
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	h.hub.Serve(c.Request.Context(), conn, user, func(ctx context.Context, topic realtime.Topic) error {
+		return h.access(ctx, principal, topic)
+	})
+}
+
+// access allows the topics of the workspaces principal may read; other
+// workspaces and their tasks look as if they did not exist.
+func (h *RealtimeHandler) access(ctx context.Context, principal *auth.Principal, topic realtime.Topic) error {
+	if !principal.CanAccessWorkspace(topic.WorkspaceID) {
+		return repository.ErrWorkspaceNotFound
+	}
+	if _, err := h.workspaces.GetWorkspace(ctx, topic.WorkspaceID); err != nil {
+		_, message := repoErrorStatus(err)
+		return errors.New(message)
+	}
+	if topic.TaskID != 0 {
+		if _, err := h.tasks.GetTaskByID(ctx, repository.Scope{WorkspaceID: topic.WorkspaceID}, topic.TaskID); err != nil {
+			_, message := repoErrorStatus(err)
+			return errors.New(message)
+		}
+	}
+	return nil
+}
+
+// checkOrigin accepts requests without an Origin (non-browser clients), from
+// the same host, and from the allowed origins.
+func checkOrigin(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, o := range allowed {
+			if o == "*" || o == origin {
+				return true
+			}
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+}
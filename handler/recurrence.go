@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net/http"
+
+	"task-api/dto"
+	"task-api/model"
+	"task-api/pkg/auth"
+	"task-api/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSeries godoc
+// @Summary      List the occurrences of a recurring task
+// @Description  Get a page of every occurrence in the series the task belongs to, done ones included. Accepts the filters of GET /tasks.
+// @Tags         recurrence
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws     path  int    true  "Workspace ID"
+// @Param        id     path  int    true  "ID of any occurrence"
+// @Param        sort   query string false "Sort column, prefix with - for descending" default(id)
+// @Param        limit  query int    false "Page size, capped by limits.max_page_size" minimum(1) default(20)
+// @Param        cursor query string false "Cursor from a previous page's next_cursor"
+// @Success      200 {object} dto.TaskListResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/{id}/series [get]
+func (h *TaskHandler) GetSeries(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	task, err := h.repo.GetTaskByID(c.Request.Context(), workspaceScope(c), id)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+	series := task.ID
+	if task.SeriesID != nil {
+		series = *task.SeriesID
+	}
+	h.listTasks(c, func(query *repository.TaskQuery) {
+		query.SeriesID = &series
+	})
+}
+
+// UpdateSeries godoc
+// @Summary      Edit a recurring series
+// @Description  Change every open occurrence of the series the task belongs to, and so the occurrences still to come. Done and cancelled occurrences keep their values; use PUT or PATCH /tasks/{id} to edit a single occurrence. An empty recurrence ends the series.
+// @Tags         recurrence
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws     path int                     true "Workspace ID"
+// @Param        id     path int                     true "ID of any occurrence"
+// @Param        series body dto.UpdateSeriesRequest true "Fields to change"
+// @Success      200 {object} dto.TaskListResponse "The updated occurrences"
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/{id}/series [patch]
+func (h *TaskHandler) UpdateSeries(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var request dto.UpdateSeriesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	fields := map[string]interface{}{}
+	if request.Name != nil {
+		fields["name"] = *request.Name
+	}
+	if request.Assignee != nil {
+		if !memberAssignee(auth.FromContext(c.Request.Context()), request.Assignee) {
+			respondError(c, http.StatusForbidden, errReassign)
+			return
+		}
+		fields["assignee"] = *request.Assignee
+	}
+	if request.Tags != nil {
+		fields["tags"] = model.Tags(*request.Tags)
+	}
+	if request.Priority != nil {
+		fields["priority"] = *request.Priority
+	}
+	if request.Recurrence != nil {
+		fields["recurrence"] = *request.Recurrence
+	}
+	if len(fields) == 0 {
+		respondError(c, http.StatusBadRequest, "no fields to update")
+		return
+	}
+
+	tasks, err := h.repo.UpdateSeries(c.Request.Context(), ownTasks(c), fields, id)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+	responses := make([]dto.TaskResponse, 0, len(tasks))
+	for i := range tasks {
+		responses = append(responses, dto.NewTaskResponse(&tasks[i]))
+	}
+	c.JSON(http.StatusOK, dto.TaskListResponse{Data: responses})
+}
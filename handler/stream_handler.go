@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/repository"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// StreamHandler serves GET /tasks/stream. Streams read the persisted event
+// log, so a client that reconnects with Last-Event-ID misses nothing, and
+// every instance sees the changes made through the others.
+type StreamHandler struct {
+	repo     repository.RepositoryInterface
+	cfg      config.StreamConfig
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func NewStreamHandler(repo repository.RepositoryInterface, cfg config.StreamConfig) *StreamHandler {
+	return &StreamHandler{repo: repo, cfg: cfg, done: make(chan struct{})}
+}
+
+// Close ends every open stream; clients reconnect, to another instance
+// during a rollout. It fits Server.OnDrain.
+func (h *StreamHandler) Close() {
+	h.doneOnce.Do(func() { close(h.done) })
+}
+
+// StreamTasks godoc
+// @Summary      Stream task changes
+// @Description  Server-Sent Events stream of the workspace's task changes. It opens with a "ready" event and then sends one event per change, named task.created, task.updated, task.completed or task.deleted like webhooks, with the workspace's event sequence number as SSE id and a dto.TaskStreamEvent as data. Only changes of tasks that match the filters of GET /tasks when the change is sent are sent. Send Last-Event-ID (or last_event_id) to resume after an event; without it the stream starts at the current position.
+// @Tags         tasks
+// @Produce      text/event-stream
+// @Security     BearerAuth
+// @Param        ws             path   int      true  "Workspace ID"
+// @Param        Last-Event-ID  header string   false "Resume after this SSE id"
+// @Param        last_event_id  query  string   false "Resume after this SSE id, for clients that cannot set headers"
+// @Param        status         query  string   false "Filter by status" Enums(todo, in_progress, blocked, in_review, done, cancelled)
+// @Param        priority       query  []string false "Filter by priorities (comma separated)" collectionFormat(csv)
+// @Param        assignee       query  string   false "Filter by assignee"
+// @Param        tags           query  []string false "Filter by tags (comma separated)" collectionFormat(csv)
+// @Param        tags_match     query  string   false "Match any or all of the tags" Enums(any, all) default(any)
+// @Param        due_after      query  string   false "Due date lower bound (RFC3339, inclusive)"
+// @Param        due_before     query  string   false "Due date upper bound (RFC3339, exclusive)"
+// @Param        overdue        query  bool     false "Only open tasks whose due date has passed"
+// @Success      200 {object} dto.TaskStreamEvent
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/stream [get]
+func (h *StreamHandler) StreamTasks(c *gin.Context) {
+	var request dto.StreamTasksRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	query, err := taskFilter(request.TaskFilterRequest)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	ctx, scope := c.Request.Context(), workspaceScope(c)
+
+	var after uint
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = request.LastEventID
+	}
+	if lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+		after = uint(id)
+	} else if after, err = h.repo.LatestTaskEventSeq(ctx, scope); err != nil {
+		respondRepoError(c, err)
+		return
+	}
+
+	// 串流會一直開著，不受 server.write_timeout 限制
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // 避免 nginx 緩衝
+	c.Render(http.StatusOK, sse.Event{
+		Id:    strconv.FormatUint(uint64(after), 10),
+		Event: "ready",
+		Data:  dto.StreamReadyEvent{LastEventID: after},
+	})
+	c.Writer.Flush()
+
+	poll := time.NewTicker(h.cfg.PollInterval.Duration)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(h.cfg.Heartbeat.Duration)
+	defer heartbeat.Stop()
+	for {
+		page, err := h.repo.ListTaskChanges(ctx, scope, query, after)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "streaming task changes failed", "error", err)
+			}
+			return // 客戶端會帶 Last-Event-ID 重連
+		}
+		for i := range page.Changes {
+			change := &page.Changes[i]
+			c.Render(-1, sse.Event{
+				Id:    strconv.FormatUint(uint64(change.Event.Seq), 10),
+				Event: change.Name,
+				Data:  dto.TaskStreamEvent{TaskEventResponse: dto.NewTaskEventResponse(&change.Event), Task: dto.NewTaskResponse(&change.Task)},
+			})
+		}
+		if len(page.Changes) > 0 {
+			c.Writer.Flush()
+			heartbeat.Reset(h.cfg.Heartbeat.Duration)
+		}
+		if page.LastSeq != after {
+			// 可能還有下一批，不等下次輪詢
+			after = page.LastSeq
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.done:
+			return
+		case <-heartbeat.C:
+			_, _ = c.Writer.WriteString(": ping\n\n")
+			c.Writer.Flush()
+		case <-poll.C:
+		}
+	}
+}
@@ -2,23 +2,30 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"task-api/config"
 	"task-api/dto"
 	"task-api/model"
+	"task-api/pkg/auth"
+	"task-api/pkg/requestid"
+	"task-api/pkg/tenant"
 	"task-api/repository"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type TaskHandler struct {
-	repo repository.RepositoryInterface
+	repo        repository.RepositoryInterface
+	limits      config.LimitsConfig
+	concurrency config.ConcurrencyConfig
 }
 
-func NewTaskHandler(repo repository.RepositoryInterface) *TaskHandler {
-	return &TaskHandler{repo: repo}
+func NewTaskHandler(repo repository.RepositoryInterface, limits config.LimitsConfig, concurrency config.ConcurrencyConfig) *TaskHandler {
+	return &TaskHandler{repo: repo, limits: limits, concurrency: concurrency}
 }
 
 // CreateTask godoc
@@ -27,163 +34,755 @@ func NewTaskHandler(repo repository.RepositoryInterface) *TaskHandler {
 // @Tags         tasks
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
+// @Param        ws   path int true "Workspace ID"
 // @Param        task body dto.CreateTaskRequest true "Task to create"
 // @Success      201 {object} dto.TaskResponse
+// @Header       201 {string} ETag "Version of the new task"
 // @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
 // @Failure      500 {object} dto.ErrorResponse
-// @Router       /tasks [post]
+// @Router       /workspaces/{ws}/tasks [post]
 func (h *TaskHandler) CreateTask(c *gin.Context) {
 	var request dto.CreateTaskRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		respondBindError(c, err)
+		return
+	}
+
+	if !memberAssignee(auth.FromContext(c.Request.Context()), &request.Assignee) {
+		respondError(c, http.StatusForbidden, errCreateForOthers)
 		return
 	}
 
 	task := model.Task{
-		Name:     request.Name,
-		Status:   0, // 預設未完成
-		DueDate:  request.DueDate,
-		Assignee: request.Assignee,
-		Tags:     request.Tags,
+		Name:       request.Name,
+		Status:     model.StatusTodo,
+		Priority:   priorityOrDefault(request.Priority),
+		DueDate:    request.DueDate,
+		Assignee:   request.Assignee,
+		Tags:       request.Tags,
+		ParentID:   request.ParentID,
+		Recurrence: request.Recurrence,
+		Reminders:  request.Reminders,
 	}
 
-	createdTask, err := h.repo.CreateTask(&task)
+	createdTask, err := h.repo.CreateTask(c.Request.Context(), workspaceScope(c), &task)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: err.Error()})
+		respondRepoError(c, err)
 		return
 	}
 
-	response := dto.TaskResponse(*createdTask)
-	c.JSON(http.StatusCreated, response)
+	respondTask(c, http.StatusCreated, createdTask)
 }
 
-// GetTasks godoc
-// @Summary      Get task(s)
-// @Description  Get a single task by ID (if provided), or all tasks
+// GetTask godoc
+// @Summary      Get a task
+// @Description  Get a single task by ID, with the progress of its subtasks if it has any
 // @Tags         tasks
 // @Produce      json
-// @Param        id query int false "Task ID"
+// @Security     BearerAuth
+// @Param        ws path int true "Workspace ID"
+// @Param        id path int true "Task ID"
+// @Param        If-None-Match header string false "ETag from a previous response"
 // @Success      200 {object} dto.TaskResponse
-// @Success      200 {array} dto.TaskResponse
+// @Header       200 {string} ETag "Current version of the task"
+// @Success      304 "Not Modified"
 // @Failure      400 {object} dto.ErrorResponse
 // @Failure      404 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/{id} [get]
+func (h *TaskHandler) GetTask(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.repo.GetTaskByID(c.Request.Context(), workspaceScope(c), id)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+	if c.GetHeader("If-None-Match") == taskETag(task) {
+		c.Header("ETag", taskETag(task))
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	responses := []dto.TaskResponse{dto.NewTaskResponse(task)}
+	if !h.withProgress(c, responses) {
+		return
+	}
+	c.Header("ETag", taskETag(task))
+	c.JSON(http.StatusOK, responses[0])
+}
+
+// GetTasks godoc
+// @Summary      List tasks
+// @Description  Get a filtered, sorted page of tasks
+// @Tags         tasks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws             path  int      true  "Workspace ID"
+// @Param        status         query string   false "Filter by status; legacy 0 and 1 mean todo and done" Enums(todo, in_progress, blocked, in_review, done, cancelled)
+// @Param        priority       query []string false "Filter by priorities (comma separated)" collectionFormat(csv)
+// @Param        assignee       query string   false "Filter by assignee"
+// @Param        tags           query []string false "Filter by tags (comma separated)" collectionFormat(csv)
+// @Param        tags_match     query string   false "Match any or all of the tags" Enums(any, all) default(any)
+// @Param        due_after      query string   false "Due date lower bound (RFC3339, inclusive)"
+// @Param        due_before     query string   false "Due date upper bound (RFC3339, exclusive)"
+// @Param        created_after  query string   false "Created lower bound (RFC3339, inclusive)"
+// @Param        created_before query string   false "Created upper bound (RFC3339, exclusive)"
+// @Param        updated_after  query string   false "Updated lower bound (RFC3339, inclusive)"
+// @Param        updated_before query string   false "Updated upper bound (RFC3339, exclusive)"
+// @Param        sort           query string   false "Sort column (e.g. priority, rank, due_date), prefix with - for descending" default(id)
+// @Param        limit          query int      false "Page size, capped by limits.max_page_size" minimum(1) default(20)
+// @Param        cursor         query string   false "Cursor from a previous page's next_cursor"
+// @Param        overdue        query bool     false "Only open tasks whose due date has passed"
+// @Param        include_deleted query bool    false "Also list tasks in the trash"
+// @Success      200 {object} dto.TaskListResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
 // @Failure      500 {object} dto.ErrorResponse
-// @Router       /tasks [get]
-// @Router       /tasks/{id} [get]
+// @Router       /workspaces/{ws}/tasks [get]
 func (h *TaskHandler) GetTasks(c *gin.Context) {
-	idStr := c.Query("id")
-	if idStr != "" {
-		idUint, err := strconv.ParseUint(idStr, 10, 64)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid id format"})
-			return
+	h.listTasks(c, nil)
+}
+
+// GetTrash godoc
+// @Summary      List deleted tasks
+// @Description  Get a page of tasks in the trash; they are purged permanently after trash.retention. Accepts the filters of GET /tasks and sort=deleted_at.
+// @Tags         tasks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws     path  int    true  "Workspace ID"
+// @Param        sort   query string false "Sort column, prefix with - for descending" default(id)
+// @Param        limit  query int    false "Page size, capped by limits.max_page_size" minimum(1) default(20)
+// @Param        cursor query string false "Cursor from a previous page's next_cursor"
+// @Success      200 {object} dto.TaskListResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/trash [get]
+func (h *TaskHandler) GetTrash(c *gin.Context) {
+	h.listTasks(c, func(query *repository.TaskQuery) {
+		query.OnlyDeleted = true
+	})
+}
+
+// GetSubtasks godoc
+// @Summary      List subtasks
+// @Description  Get a page of the direct subtasks of a task. Accepts the filters of GET /tasks.
+// @Tags         tasks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws     path  int    true  "Workspace ID"
+// @Param        id     path  int    true  "Parent task ID"
+// @Param        sort   query string false "Sort column, prefix with - for descending" default(id)
+// @Param        limit  query int    false "Page size, capped by limits.max_page_size" minimum(1) default(20)
+// @Param        cursor query string false "Cursor from a previous page's next_cursor"
+// @Success      200 {object} dto.TaskListResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/{id}/subtasks [get]
+func (h *TaskHandler) GetSubtasks(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	if _, err := h.repo.GetTaskByID(c.Request.Context(), workspaceScope(c), id); err != nil {
+		respondRepoError(c, err)
+		return
+	}
+	h.listTasks(c, func(query *repository.TaskQuery) {
+		query.ParentID = &id
+	})
+}
+
+// GetTaskTree godoc
+// @Summary      Get a task with its subtasks
+// @Description  Get a task and its subtasks nested depth levels deep, each level in manual order (rank), with progress on every task that has subtasks
+// @Tags         tasks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws    path  int true  "Workspace ID"
+// @Param        id    path  int true  "Task ID"
+// @Param        depth query int false "Levels of subtasks to include, capped by limits.max_tree_depth" minimum(1)
+// @Success      200 {object} dto.TaskTreeResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/{id}/tree [get]
+func (h *TaskHandler) GetTaskTree(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var request dto.TaskTreeRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if request.Depth == 0 {
+		request.Depth = h.limits.MaxTreeDepth
+	}
+	if request.Depth > h.limits.MaxTreeDepth {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("depth must not exceed %d", h.limits.MaxTreeDepth))
+		return
+	}
+
+	root, err := h.repo.GetTaskTree(c.Request.Context(), workspaceScope(c), id, request.Depth)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+
+	// 先攤平整棵樹，一次算完所有節點的進度
+	var responses []dto.TaskResponse
+	var flatten func(node *repository.TaskNode)
+	flatten = func(node *repository.TaskNode) {
+		responses = append(responses, dto.NewTaskResponse(&node.Task))
+		for _, child := range node.Children {
+			flatten(child)
 		}
-		task, err := h.repo.GetTaskByID(uint(idUint))
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "task not found"})
-			} else {
-				c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: err.Error()})
-			}
-			return
+	}
+	flatten(root)
+	if !h.withProgress(c, responses) {
+		return
+	}
+
+	next := 0
+	var build func(node *repository.TaskNode) dto.TaskTreeResponse
+	build = func(node *repository.TaskNode) dto.TaskTreeResponse {
+		tree := dto.TaskTreeResponse{TaskResponse: responses[next], Children: []dto.TaskTreeResponse{}}
+		next++
+		for _, child := range node.Children {
+			tree.Children = append(tree.Children, build(child))
+		}
+		return tree
+	}
+	c.JSON(http.StatusOK, build(root))
+}
+
+// withProgress fills in the progress of the responses that have subtasks.
+func (h *TaskHandler) withProgress(c *gin.Context, responses []dto.TaskResponse) bool {
+	ids := make([]uint, len(responses))
+	for i := range responses {
+		ids[i] = responses[i].ID
+	}
+	progress, err := h.repo.TaskProgress(c.Request.Context(), workspaceScope(c), ids)
+	if err != nil {
+		respondRepoError(c, err)
+		return false
+	}
+	for i := range responses {
+		if p, ok := progress[responses[i].ID]; ok {
+			percent := p.Percent()
+			responses[i].Progress = &percent
 		}
-		c.JSON(http.StatusOK, dto.TaskResponse(*task))
+	}
+	return true
+}
+
+// listTasks answers a GET of a task list; narrow adjusts the query parsed
+// from the request, if set.
+func (h *TaskHandler) listTasks(c *gin.Context, narrow func(*repository.TaskQuery)) {
+	var request dto.ListTasksRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if request.Limit == 0 {
+		request.Limit = h.limits.DefaultPageSize
+	}
+	if request.Limit > h.limits.MaxPageSize {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("limit must not exceed %d", h.limits.MaxPageSize))
 		return
 	}
 
-	tasks, err := h.repo.GetAllTasks()
+	query, err := toTaskQuery(request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if narrow != nil {
+		narrow(&query)
+	}
+	page, err := h.repo.ListTasks(c.Request.Context(), workspaceScope(c), query)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidSort) || errors.Is(err, repository.ErrInvalidCursor) {
+			respondError(c, http.StatusBadRequest, err.Error())
+		} else {
+			respondError(c, http.StatusInternalServerError, errInternal)
+		}
 		return
 	}
 
-	var responses []dto.TaskResponse
-	for _, task := range tasks {
-		responses = append(responses, dto.TaskResponse(task))
+	responses := make([]dto.TaskResponse, 0, len(page.Tasks))
+	for i := range page.Tasks {
+		responses = append(responses, dto.NewTaskResponse(&page.Tasks[i]))
+	}
+	if !h.withProgress(c, responses) {
+		return
 	}
 
-	c.JSON(http.StatusOK, responses)
+	c.JSON(http.StatusOK, dto.TaskListResponse{Data: responses, NextCursor: page.NextCursor})
+}
+
+func toTaskQuery(request dto.ListTasksRequest) (repository.TaskQuery, error) {
+	query, err := taskFilter(request.TaskFilterRequest)
+	query.Limit = request.Limit
+	query.Cursor = request.Cursor
+	query.IncludeDeleted = request.IncludeDeleted
+	query.Sort = strings.TrimPrefix(request.Sort, "-")
+	query.Desc = strings.HasPrefix(request.Sort, "-")
+	return query, err
+}
+
+// taskFilter converts the filters shared by GET /tasks and /tasks/stream.
+func taskFilter(request dto.TaskFilterRequest) (repository.TaskQuery, error) {
+	query := repository.TaskQuery{
+		Assignee:      request.Assignee,
+		TagMatch:      repository.TagMatch(request.TagsMatch),
+		DueAfter:      request.DueAfter,
+		DueBefore:     request.DueBefore,
+		CreatedAfter:  request.CreatedAfter,
+		CreatedBefore: request.CreatedBefore,
+		UpdatedAfter:  request.UpdatedAfter,
+		UpdatedBefore: request.UpdatedBefore,
+		Overdue:       request.Overdue,
+	}
+	if request.Status != "" {
+		// binding 已驗證過，不會失敗
+		query.Status, _ = model.ParseStatus(request.Status)
+	}
+	query.Tags = splitList(request.Tags)
+	for _, p := range splitList(request.Priority) {
+		priority, err := model.ParsePriority(p)
+		if err != nil {
+			return query, err
+		}
+		query.Priorities = append(query.Priorities, priority)
+	}
+	return query, nil
+}
+
+// splitList 同時支援 ?tags=a,b 與 ?tags=a&tags=b
+func splitList(values []string) []string {
+	var out []string
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+// priorityOrDefault gives tasks created or replaced without a priority DefaultPriority.
+func priorityOrDefault(priority *model.Priority) model.Priority {
+	if priority == nil {
+		return model.DefaultPriority
+	}
+	return *priority
 }
 
 // UpdateTask godoc
-// @Summary      Update a task
-// @Description  Update task fields by ID
+// @Summary      Replace a task
+// @Description  Replace all editable fields of a task; omitted optional fields are cleared. Use PATCH for partial updates.
 // @Tags         tasks
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
+// @Param        ws path int true "Workspace ID"
 // @Param        id path int true "Task ID"
-// @Param        task body dto.UpdateTaskRequest true "Updated task data"
+// @Param        If-Match header string false "ETag the update is conditioned on (required when concurrency.require_if_match is set)"
+// @Param        task body dto.ReplaceTaskRequest true "New task data"
 // @Success      200 {object} dto.TaskResponse
+// @Header       200 {string} ETag "New version of the task"
 // @Failure      400 {object} dto.ErrorResponse
 // @Failure      404 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      412 {object} dto.ErrorResponse
+// @Failure      428 {object} dto.ErrorResponse
 // @Failure      500 {object} dto.ErrorResponse
-// @Router       /tasks/{id} [put]
+// @Router       /workspaces/{ws}/tasks/{id} [put]
 func (h *TaskHandler) UpdateTask(c *gin.Context) {
-	idStr := c.Param("id")
-	idUint, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid id format"})
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	version, ok := h.ifMatchVersion(c)
+	if !ok {
 		return
 	}
 
-	var request dto.UpdateTaskRequest
+	var request dto.ReplaceTaskRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
-	fields := map[string]interface{}{}
-	if request.Name != nil {
-		fields["name"] = *request.Name
+	scope, ok := updateScope(c, &request)
+	if !ok {
+		return
 	}
-	if request.Status != nil {
-		fields["status"] = *request.Status
+
+	task, err := h.repo.UpdateTask(c.Request.Context(), scope, replaceFields(request), id, version)
+	if err != nil {
+		respondRepoError(c, err)
+		return
 	}
-	if request.DueDate != nil {
-		fields["due_date"] = *request.DueDate
+	respondTask(c, http.StatusOK, task)
+}
+
+// DeleteTask godoc
+// @Summary      Delete a task
+// @Description  Move a task to the trash; it can be restored until it is purged after trash.retention. Its subtasks are handled according to subtasks.on_delete.
+// @Tags         tasks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws path int true "Workspace ID"
+// @Param        id path int true "Task ID"
+// @Param        If-Match header string false "ETag the delete is conditioned on (required when concurrency.require_if_match is set)"
+// @Success      204 "No Content"
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      412 {object} dto.ErrorResponse
+// @Failure      428 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/{id} [delete]
+func (h *TaskHandler) DeleteTask(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
 	}
-	if request.Assignee != nil {
-		fields["assignee"] = *request.Assignee
+
+	version, ok := h.ifMatchVersion(c)
+	if !ok {
+		return
 	}
-	if request.Tags != nil {
-		fields["tags"] = request.Tags
+
+	if err := h.repo.DeleteTask(c.Request.Context(), workspaceScope(c), id, version); err != nil {
+		respondRepoError(c, err)
+		return
 	}
+	c.Status(http.StatusNoContent)
+}
 
-	if err := h.repo.UpdateTask(fields, uint(idUint)); err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: err.Error()})
+// RestoreTask godoc
+// @Summary      Restore a deleted task
+// @Description  Move a task out of the trash
+// @Tags         tasks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws path int true "Workspace ID"
+// @Param        id path int true "Task ID"
+// @Success      200 {object} dto.TaskResponse
+// @Header       200 {string} ETag "New version of the task"
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/{id}/restore [post]
+func (h *TaskHandler) RestoreTask(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	task, err := h.repo.RestoreTask(c.Request.Context(), workspaceScope(c), id)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+	respondTask(c, http.StatusOK, task)
 }
 
-// DeleteTask godoc
-// @Summary      Delete a task
-// @Description  Delete a task by ID
+// ReopenTask godoc
+// @Summary      Reopen a closed task
+// @Description  Move a done or cancelled task back to todo and clear completed_at. The workflow never allows leaving these statuses through PUT or PATCH.
 // @Tags         tasks
 // @Produce      json
+// @Security     BearerAuth
+// @Param        ws path int true "Workspace ID"
 // @Param        id path int true "Task ID"
-// @Success      204 "No Content"
+// @Param        If-Match header string false "ETag the reopen is conditioned on (required when concurrency.require_if_match is set)"
+// @Success      200 {object} dto.TaskResponse
+// @Header       200 {string} ETag "New version of the task"
 // @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
 // @Failure      404 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      412 {object} dto.ErrorResponse
+// @Failure      428 {object} dto.ErrorResponse
 // @Failure      500 {object} dto.ErrorResponse
-// @Router       /tasks/{id} [delete]
-func (h *TaskHandler) DeleteTask(c *gin.Context) {
-	idStr := c.Param("id")
-	idUint, err := strconv.ParseUint(idStr, 10, 64)
+// @Router       /workspaces/{ws}/tasks/{id}/reopen [post]
+func (h *TaskHandler) ReopenTask(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	version, ok := h.ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.repo.ReopenTask(c.Request.Context(), ownTasks(c), id, version)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid id format"})
+		respondRepoError(c, err)
+		return
+	}
+	respondTask(c, http.StatusOK, task)
+}
+
+// MoveTask godoc
+// @Summary      Move a task in the manual order
+// @Description  Give a task a rank directly after after_id or directly before before_id, for drag-and-drop ordering (sort=rank). Only the moved task changes.
+// @Tags         tasks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws   path int                 true "Workspace ID"
+// @Param        id   path int                 true "Task ID"
+// @Param        If-Match header string false "ETag the move is conditioned on (required when concurrency.require_if_match is set)"
+// @Param        move body dto.MoveTaskRequest true "Neighbour to place the task next to"
+// @Success      200 {object} dto.TaskResponse
+// @Header       200 {string} ETag "New version of the task"
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      412 {object} dto.ErrorResponse
+// @Failure      428 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/{id}/move [post]
+func (h *TaskHandler) MoveTask(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	version, ok := h.ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	var request dto.MoveTaskRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	move := repository.Move{AfterID: request.AfterID, BeforeID: request.BeforeID}
+	task, err := h.repo.MoveTask(c.Request.Context(), ownTasks(c), id, move, version)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+	respondTask(c, http.StatusOK, task)
+}
+
+// GetTaskHistory godoc
+// @Summary      Get a task's history
+// @Description  Get the audit events of a task, oldest first, with the actor and field-level changes. Tasks in the trash keep their history.
+// @Tags         tasks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws     path  int    true  "Workspace ID"
+// @Param        id     path  int    true  "Task ID"
+// @Param        limit  query int    false "Page size, capped by limits.max_page_size" minimum(1) default(20)
+// @Param        cursor query string false "Cursor from a previous page's next_cursor"
+// @Success      200 {object} dto.TaskEventListResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/tasks/{id}/history [get]
+func (h *TaskHandler) GetTaskHistory(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var request dto.ListTaskEventsRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if request.Limit == 0 {
+		request.Limit = h.limits.DefaultPageSize
+	}
+	if request.Limit > h.limits.MaxPageSize {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("limit must not exceed %d", h.limits.MaxPageSize))
 		return
 	}
 
-	deleted, err := h.repo.DeleteTask(uint(idUint))
+	page, err := h.repo.ListTaskEvents(c.Request.Context(), workspaceScope(c), id, request.Cursor, request.Limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: err.Error()})
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			respondError(c, http.StatusBadRequest, err.Error())
+		} else {
+			respondRepoError(c, err)
+		}
 		return
 	}
-	if !deleted {
-		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "task not found"})
+
+	responses := make([]dto.TaskEventResponse, 0, len(page.Events))
+	for i := range page.Events {
+		responses = append(responses, dto.NewTaskEventResponse(&page.Events[i]))
+	}
+	c.JSON(http.StatusOK, dto.TaskEventListResponse{Data: responses, NextCursor: page.NextCursor})
+}
+
+// workspaceScope confines repository calls to the workspace resolved by
+// WorkspaceHandler.Resolve.
+func workspaceScope(c *gin.Context) repository.Scope {
+	return repository.Scope{WorkspaceID: tenant.FromContext(c.Request.Context())}
+}
+
+const (
+	errCreateForOthers = "members can only create tasks assigned to themselves"
+	errReassign        = "members cannot reassign tasks to someone else"
+)
+
+// memberAssignee defaults assignee to the caller when they may only work on
+// their own tasks, and reports whether the resulting assignee is allowed.
+func memberAssignee(principal *auth.Principal, assignee *string) bool {
+	if principal.Can(auth.PermTaskAny) {
+		return true
+	}
+	// member 只能處理指派給自己的 task，未填 assignee 時預設為自己
+	if *assignee == "" {
+		*assignee = principal.Name
+	}
+	return *assignee == principal.Name
+}
+
+// updateScope is the scope of a PUT or PATCH: members may only write their
+// own tasks and keep them assigned to themselves.
+func updateScope(c *gin.Context, request *dto.ReplaceTaskRequest) (repository.Scope, bool) {
+	scope, ok := ownerScope(auth.FromContext(c.Request.Context()), workspaceScope(c), request)
+	if !ok {
+		respondError(c, http.StatusForbidden, errReassign)
+	}
+	return scope, ok
+}
+
+// ownTasks is the workspace scope, narrowed to the caller's own tasks
+// for members.
+func ownTasks(c *gin.Context) repository.Scope {
+	scope := workspaceScope(c)
+	if principal := auth.FromContext(c.Request.Context()); !principal.Can(auth.PermTaskAny) {
+		scope.Assignee = principal.Name
+	}
+	return scope
+}
+
+func ownerScope(principal *auth.Principal, scope repository.Scope, request *dto.ReplaceTaskRequest) (repository.Scope, bool) {
+	if !memberAssignee(principal, &request.Assignee) {
+		return scope, false
+	}
+	if !principal.Can(auth.PermTaskAny) {
+		scope.Assignee = principal.Name
+	}
+	return scope, true
+}
+
+// replaceFields maps a full task representation to the columns it overwrites.
+func replaceFields(request dto.ReplaceTaskRequest) map[string]interface{} {
+	status := request.Status
+	if status == "" {
+		status = model.StatusTodo
+	}
+	fields := map[string]interface{}{
+		"name":       request.Name,
+		"status":     status,
+		"priority":   priorityOrDefault(request.Priority),
+		"due_date":   nil,
+		"assignee":   request.Assignee,
+		"tags":       model.Tags(request.Tags),
+		"parent_id":  request.ParentID,
+		"recurrence": request.Recurrence,
+		"reminders":  model.Reminders(request.Reminders),
+	}
+	if request.DueDate != nil {
+		fields["due_date"] = *request.DueDate
+	}
+	return fields
+}
+
+// parseID 解析路徑上的 :id，格式錯誤時直接回應 400
+func parseID(c *gin.Context) (uint, bool) {
+	idUint, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid id format")
+		return 0, false
+	}
+	return uint(idUint), true
+}
+
+// respondError writes a dto.ErrorResponse carrying the request ID.
+// errInternal is the message of every 500 response; the cause is logged, never
+// sent to the client.
+const errInternal = "internal server error"
+
+func respondError(c *gin.Context, status int, message string) {
+	c.JSON(status, dto.ErrorResponse{Error: message, RequestID: requestid.FromContext(c.Request.Context())})
+}
+
+func respondBindError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondError(c, http.StatusRequestEntityTooLarge, "request body too large")
 		return
 	}
-	c.Status(http.StatusNoContent)
+	respondError(c, http.StatusBadRequest, err.Error())
+}
+
+func respondRepoError(c *gin.Context, err error) {
+	status, message := repoErrorStatus(err)
+	respondError(c, status, message)
+}
+
+// repoErrorStatus maps a repository error to an HTTP status and message.
+func repoErrorStatus(err error) (int, string) {
+	if errors.Is(err, repository.ErrVersionMismatch) {
+		return http.StatusPreconditionFailed, err.Error()
+	}
+	if errors.Is(err, repository.ErrNotOwner) {
+		return http.StatusForbidden, err.Error()
+	}
+	if errors.Is(err, repository.ErrInvalidMove) || errors.Is(err, repository.ErrInvalidParent) || errors.Is(err, repository.ErrInvalidDependency) ||
+		errors.Is(err, repository.ErrInvalidRecurrence) || errors.Is(err, repository.ErrInvalidReminder) {
+		return http.StatusBadRequest, err.Error()
+	}
+	if errors.Is(err, repository.ErrNotDeleted) || errors.Is(err, repository.ErrInvalidTransition) || errors.Is(err, repository.ErrNotClosed) ||
+		errors.Is(err, repository.ErrHasSubtasks) || errors.Is(err, repository.ErrOpenSubtasks) ||
+		errors.Is(err, repository.ErrDependencyExists) || errors.Is(err, repository.ErrDependencyCycle) || errors.Is(err, repository.ErrOpenBlockers) {
+		return http.StatusConflict, err.Error()
+	}
+	for _, notFound := range []error{repository.ErrNotFound, repository.ErrAPIKeyNotFound, repository.ErrWorkspaceNotFound, repository.ErrDependencyNotFound, repository.ErrWebhookNotFound} {
+		if errors.Is(err, notFound) {
+			return http.StatusNotFound, notFound.Error()
+		}
+	}
+	return http.StatusInternalServerError, errInternal
 }
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/model"
+	"task-api/pkg/logger"
+	"task-api/pkg/tenant"
+	"task-api/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	repo   repository.WebhookRepositoryInterface
+	limits config.LimitsConfig
+}
+
+func NewWebhookHandler(repo repository.WebhookRepositoryInterface, limits config.LimitsConfig) *WebhookHandler {
+	return &WebhookHandler{repo: repo, limits: limits}
+}
+
+// CreateWebhook godoc
+// @Summary      Create a webhook
+// @Description  Subscribe a URL to the task events of the workspace. Every delivery is a JSON POST of dto.WebhookPayload with the headers X-Webhook-Event, X-Webhook-Delivery and X-Webhook-Signature ("sha256=" and the hex HMAC-SHA256 of the body under the secret). The secret is only returned in this response.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws      path int                      true "Workspace ID"
+// @Param        webhook body dto.CreateWebhookRequest true "Webhook to create"
+// @Success      201 {object} dto.CreatedWebhookResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var request dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			logger.FromContext(c.Request.Context()).Error("generating webhook secret failed", "error", err)
+			respondError(c, http.StatusInternalServerError, errInternal)
+			return
+		}
+		secret = hex.EncodeToString(buf)
+	}
+	webhook, err := h.repo.CreateWebhook(c.Request.Context(), &model.Webhook{
+		WorkspaceID: tenant.FromContext(c.Request.Context()),
+		URL:         request.URL,
+		Secret:      secret,
+		Events:      strings.Join(distinctEvents(request.Events), ","),
+	})
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, dto.CreatedWebhookResponse{WebhookResponse: dto.NewWebhookResponse(webhook), Secret: secret})
+}
+
+// ListWebhooks godoc
+// @Summary      List webhooks
+// @Description  List the webhooks of the workspace. Secrets are never returned.
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws path int true "Workspace ID"
+// @Success      200 {array}  dto.WebhookResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.repo.ListWebhooks(c.Request.Context(), tenant.FromContext(c.Request.Context()))
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+	response := make([]dto.WebhookResponse, 0, len(webhooks))
+	for i := range webhooks {
+		response = append(response, dto.NewWebhookResponse(&webhooks[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWebhook godoc
+// @Summary      Get a webhook
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws path int true "Workspace ID"
+// @Param        id path int true "Webhook ID"
+// @Success      200 {object} dto.WebhookResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	webhook, err := h.repo.GetWebhook(c.Request.Context(), tenant.FromContext(c.Request.Context()), id)
+	if err != nil {
+		respondRepoError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewWebhookResponse(webhook))
+}
+
+// DeleteWebhook godoc
+// @Summary      Delete a webhook
+// @Description  Delete a webhook and its delivery log; pending deliveries are dropped.
+// @Tags         webhooks
+// @Security     BearerAuth
+// @Param        ws path int true "Workspace ID"
+// @Param        id path int true "Webhook ID"
+// @Success      204
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	if err := h.repo.DeleteWebhook(c.Request.Context(), tenant.FromContext(c.Request.Context()), id); err != nil {
+		respondRepoError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries godoc
+// @Summary      List the deliveries of a webhook
+// @Description  Get the delivery log of a webhook, newest first, with the attempts made, the last response or error and the payload.
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws     path  int    true  "Workspace ID"
+// @Param        id     path  int    true  "Webhook ID"
+// @Param        status query string false "Filter by status" Enums(pending, delivered, failed)
+// @Param        limit  query int    false "Page size, capped by limits.max_page_size" minimum(1) default(20)
+// @Param        cursor query string false "Cursor from a previous page's next_cursor"
+// @Success      200 {object} dto.WebhookDeliveryListResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws}/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var request dto.ListWebhookDeliveriesRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if request.Limit == 0 {
+		request.Limit = h.limits.DefaultPageSize
+	}
+	if request.Limit > h.limits.MaxPageSize {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("limit must not exceed %d", h.limits.MaxPageSize))
+		return
+	}
+
+	page, err := h.repo.ListWebhookDeliveries(c.Request.Context(), tenant.FromContext(c.Request.Context()), id, request.Status, request.Cursor, request.Limit)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			respondError(c, http.StatusBadRequest, err.Error())
+		} else {
+			respondRepoError(c, err)
+		}
+		return
+	}
+	responses := make([]dto.WebhookDeliveryResponse, 0, len(page.Deliveries))
+	for i := range page.Deliveries {
+		responses = append(responses, dto.NewWebhookDeliveryResponse(&page.Deliveries[i]))
+	}
+	c.JSON(http.StatusOK, dto.WebhookDeliveryListResponse{Data: responses, NextCursor: page.NextCursor})
+}
+
+// distinctEvents drops repeated events, keeping the order they were given in.
+func distinctEvents(events []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, event := range events {
+		if !seen[event] {
+			seen[event] = true
+			out = append(out, event)
+		}
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"task-api/dto"
+	"task-api/model"
+	"task-api/pkg/auth"
+	"task-api/pkg/tenant"
+	"task-api/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WorkspaceHandler struct {
+	repo repository.WorkspaceRepositoryInterface
+}
+
+func NewWorkspaceHandler(repo repository.WorkspaceRepositoryInterface) *WorkspaceHandler {
+	return &WorkspaceHandler{repo: repo}
+}
+
+// Resolve is middleware for /workspaces/:ws routes. It loads the workspace
+// and stores its id in the request context for the task handlers. Unknown
+// workspaces and ones the caller may not access both answer 404, so their
+// existence is not revealed.
+func (h *WorkspaceHandler) Resolve(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("ws"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid workspace id format")
+		c.Abort()
+		return
+	}
+
+	ctx := c.Request.Context()
+	if !auth.FromContext(ctx).CanAccessWorkspace(uint(id)) {
+		respondError(c, http.StatusNotFound, repository.ErrWorkspaceNotFound.Error())
+		c.Abort()
+		return
+	}
+	ws, err := h.repo.GetWorkspace(ctx, uint(id))
+	if err != nil {
+		respondRepoError(c, err)
+		c.Abort()
+		return
+	}
+
+	c.Set("workspace", ws)
+	c.Request = c.Request.WithContext(tenant.NewContext(ctx, ws.ID))
+	c.Next()
+}
+
+// CreateWorkspace godoc
+// @Summary      Create a workspace
+// @Description  Create a new tenant workspace
+// @Tags         workspaces
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        workspace body dto.CreateWorkspaceRequest true "Workspace to create"
+// @Success      201 {object} dto.WorkspaceResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces [post]
+func (h *WorkspaceHandler) CreateWorkspace(c *gin.Context) {
+	var request dto.CreateWorkspaceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	ws, err := h.repo.CreateWorkspace(c.Request.Context(), &model.Workspace{Name: request.Name})
+	if errors.Is(err, repository.ErrWorkspaceExists) {
+		respondError(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errInternal)
+		return
+	}
+	c.JSON(http.StatusCreated, dto.NewWorkspaceResponse(ws))
+}
+
+// ListWorkspaces godoc
+// @Summary      List workspaces
+// @Description  List the workspaces the caller can access
+// @Tags         workspaces
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {array}  dto.WorkspaceResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /workspaces [get]
+func (h *WorkspaceHandler) ListWorkspaces(c *gin.Context) {
+	var ids []uint // nil: all workspaces
+	if principal := auth.FromContext(c.Request.Context()); principal != nil && !principal.AllWorkspaces {
+		ids = append([]uint{}, principal.Workspaces...)
+	}
+
+	list, err := h.repo.ListWorkspaces(c.Request.Context(), ids)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errInternal)
+		return
+	}
+	response := make([]dto.WorkspaceResponse, 0, len(list))
+	for i := range list {
+		response = append(response, dto.NewWorkspaceResponse(&list[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWorkspace godoc
+// @Summary      Get a workspace
+// @Tags         workspaces
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ws path int true "Workspace ID"
+// @Success      200 {object} dto.WorkspaceResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /workspaces/{ws} [get]
+func (h *WorkspaceHandler) GetWorkspace(c *gin.Context) {
+	ws, ok := c.Get("workspace")
+	if !ok {
+		respondError(c, http.StatusInternalServerError, "workspace not resolved")
+		return
+	}
+	c.JSON(http.StatusOK, dto.NewWorkspaceResponse(ws.(*model.Workspace)))
+}
@@ -1,14 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"task-api/config"
 	"task-api/handler"
+	"task-api/pkg/auth"
+	"task-api/pkg/health"
+	"task-api/pkg/logger"
+	"task-api/pkg/metrics"
+	"task-api/pkg/migrate"
 	"task-api/pkg/orm"
+	"task-api/pkg/realtime"
+	"task-api/pkg/reminder"
+	"task-api/pkg/server"
+	"task-api/pkg/trash"
+	"task-api/pkg/webhook"
+	"task-api/pkg/workflow"
 	"task-api/repository"
 	"task-api/router"
 
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
-
 	_ "task-api/docs" // 🔥 非常重要！引用剛剛產生的 Swagger 文件
 )
 
@@ -17,13 +35,126 @@ import (
 // @description A simple RESTful API for managing tasks.
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description "Bearer <api key or JWT>"
+//
+// Usage:
+//
+//	task-api [flags]                          start the HTTP server
+//	task-api [flags] migrate up               apply pending migrations
+//	task-api [flags] migrate down [steps]     roll back the last migration(s)
+//	task-api [flags] migrate status           list migrations and whether they ran
+//	task-api [flags] apikey create <name>     issue an API key and print it once
+//	task-api [flags] apikey list | revoke <id>
+//
+// Run `task-api -h` for the flags; see config.Load for precedence.
 func main() {
-	db := orm.InitDB()
-	repo := repository.NewTaskRepository(db)
-	handler := handler.NewTaskHandler(repo)
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger.New(cfg.Log.Level, os.Stdout))
+
+	db, err := orm.InitDB(cfg.Database, cfg.Log.Level)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := migrate.Run(db, args[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	// 開發時預設啟動即套用 migration，正式環境可關閉改用 `task-api migrate up`
+	if cfg.Database.AutoMigrate {
+		if _, err := migrate.New(db, migrate.All()).Up(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// apikey 在 migration 之後執行，新資料庫也能直接建立第一把 key
+	keys := repository.NewAPIKeyRepository(db)
+	if len(args) > 0 && args[0] == "apikey" {
+		if err := auth.Run(context.Background(), keys, args[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var authenticator *auth.Authenticator
+	if cfg.Auth.Enabled {
+		var jwks *auth.JWKS
+		if cfg.Auth.JWKSFile != "" {
+			if jwks, err = auth.LoadJWKS(cfg.Auth.JWKSFile); err != nil {
+				log.Fatal(err)
+			}
+		}
+		authenticator = auth.New(keys, jwks, cfg.Auth.Issuer, cfg.Auth.Audience)
+	} else {
+		slog.Warn("authentication is disabled; every route is public")
+	}
+
+	transitions, err := workflow.Parse(cfg.Workflow.Transitions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	repo := repository.NewTaskRepository(db).WithWorkflow(transitions).WithSubtaskPolicy(repository.SubtaskPolicy{
+		OnDelete:   repository.DeletePolicy(cfg.Subtasks.OnDelete),
+		OnComplete: repository.CompletePolicy(cfg.Subtasks.OnComplete),
+	}).WithWebhooks(webhook.Encode)
+	workspaces := repository.NewWorkspaceRepository(db)
+	webhooks := repository.NewWebhookRepository(db)
+	readiness := &health.Readiness{}
+	stream := handler.NewStreamHandler(repo, cfg.Stream)
+	// repository 提交後把變更推給 WebSocket 連線
+	hub := realtime.NewHub(cfg.Realtime)
+	repo.WithPublisher(hub)
+	r := router.SetupRouter(router.Handlers{
+		Task:      handler.NewTaskHandler(repo, cfg.Limits, cfg.Concurrency),
+		Workspace: handler.NewWorkspaceHandler(workspaces),
+		APIKey:    handler.NewAPIKeyHandler(keys, workspaces),
+		Webhook:   handler.NewWebhookHandler(webhooks, cfg.Limits),
+		Stream:    stream,
+		Realtime:  handler.NewRealtimeHandler(hub, repo, workspaces, cfg.CORS.AllowedOrigins),
+		Health:    handler.NewHealthHandler(readiness, sqlDB, migrate.New(db, migrate.All())),
+		Metrics:   metrics.New(sqlDB, repo),
+		Auth:      authenticator,
+	}, cfg)
+
+	// SIGTERM（容器調度）或 Ctrl+C 時優雅關閉：先標記 not ready，再等待進行中的請求
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	r := router.SetupRouter(handler)
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// 背景清除超過保留期限的已刪除 task
+	purger := trash.NewPurger(repo, cfg.Trash)
+	purger.Start(ctx)
+	// 背景寄送到期提醒並標記逾期的 task
+	scheduler := reminder.NewScheduler(repo, reminder.NewNotifier(cfg.Reminders), cfg.Reminders)
+	scheduler.Start(ctx)
+	// 背景送出佇列中的 webhook，失敗時依指數退避重試
+	dispatcher := webhook.NewDispatcher(webhooks, cfg.Webhooks)
+	dispatcher.Start(ctx)
 
-	r.Run(":8080") // 啟動 server
+	srv := server.New(cfg.Server, r, readiness)
+	srv.OnDrain(stream.Close)
+	srv.OnDrain(hub.Close)
+	srv.OnShutdown(purger.Wait)
+	srv.OnShutdown(scheduler.Wait)
+	srv.OnShutdown(dispatcher.Wait)
+	srv.OnShutdown(sqlDB.Close)
+	if err := srv.Run(ctx); err != nil { // 啟動 server
+		log.Fatal(err)
+	}
+	slog.Info("server stopped")
 }
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"task-api/dto"
+	"task-api/pkg/actor"
+	"task-api/pkg/auth"
+	"task-api/pkg/logger"
+	"task-api/pkg/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrincipalKey is the gin context key holding the authenticated *auth.Principal.
+const PrincipalKey = "principal"
+
+// Authenticate requires an API key or JWT, sent as "Authorization: Bearer
+// <token>" or, for API keys, "X-API-Key: <key>". The principal is stored in
+// the gin context and the request context.
+func Authenticate(a *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		principal, err := a.Authenticate(ctx, credentials(c.Request))
+		if err != nil {
+			status, message := http.StatusUnauthorized, err.Error()
+			if errors.Is(err, auth.ErrUnauthenticated) {
+				c.Header("WWW-Authenticate", `Bearer realm="task-api"`)
+			} else {
+				logger.FromContext(ctx).Error("authentication failed", "error", err)
+				status, message = http.StatusInternalServerError, "internal server error"
+			}
+			c.AbortWithStatusJSON(status, dto.ErrorResponse{Error: message, RequestID: requestid.FromContext(ctx)})
+			return
+		}
+
+		c.Set(PrincipalKey, principal)
+		ctx = auth.NewContext(ctx, principal)
+		ctx = actor.NewContext(ctx, principal.Subject)
+		ctx = logger.WithContext(ctx, logger.FromContext(ctx).With("principal", principal.Subject))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Principal returns the caller authenticated by Authenticate, or nil.
+func Principal(c *gin.Context) *auth.Principal {
+	p, _ := c.Get(PrincipalKey)
+	principal, _ := p.(*auth.Principal)
+	return principal
+}
+
+func credentials(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// Authorize rejects callers whose role lacks perm with 403. It must run
+// after Authenticate; without a principal (auth disabled) everything passes.
+func Authorize(perm auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := Principal(c)
+		if !principal.Can(perm) {
+			err := &auth.ForbiddenError{Role: principal.Role, Permission: perm}
+			c.AbortWithStatusJSON(http.StatusForbidden, dto.ErrorResponse{Error: err.Error(), RequestID: requestid.FromContext(c.Request.Context())})
+			return
+		}
+		c.Next()
+	}
+}
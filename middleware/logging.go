@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"task-api/dto"
+	"task-api/pkg/logger"
+	"task-api/pkg/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog attaches a request-scoped logger (carrying the request ID) to
+// the request context and writes one JSON record per request. It must run
+// after RequestID.
+func AccessLog(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		reqLogger := base.With("request_id", requestid.FromContext(c.Request.Context()))
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), reqLogger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip", c.ClientIP(),
+			"bytes", c.Writer.Size(),
+		}
+		if ws := c.Param("ws"); ws != "" {
+			attrs = append(attrs, "workspace_id", ws)
+		}
+		if id := c.Param("id"); id != "" && strings.Contains(c.FullPath(), "/tasks/") {
+			attrs = append(attrs, "task_id", id)
+		}
+		if principal := Principal(c); principal != nil {
+			attrs = append(attrs, "principal", principal.Subject)
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		reqLogger.Log(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery turns panics into a logged 500 with a dto.ErrorResponse.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				ctx := c.Request.Context()
+				logger.FromContext(ctx).Error("panic recovered", "panic", rec, "stack", string(debug.Stack()))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, dto.ErrorResponse{
+					Error:     "internal server error",
+					RequestID: requestid.FromContext(ctx),
+				})
+			}
+		}()
+		c.Next()
+	}
+}
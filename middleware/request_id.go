@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"task-api/pkg/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID propagates a valid incoming X-Request-ID or generates a new one,
+// echoes it on the response and stores it in the request context.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.Generate()
+		}
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// APIKey is a static credential. Only the SHA-256 hash of the key is stored;
+// Prefix keeps enough of it to tell keys apart in listings.
+type APIKey struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Name        string     `gorm:"size:100;not null" json:"name"`
+	Prefix      string     `gorm:"size:16;not null" json:"prefix"`
+	WorkspaceID *uint      `json:"workspace_id,omitempty"`                      // nil: valid for every workspace
+	Role        string     `gorm:"size:16;not null;default:member" json:"role"` // an auth.Role
+	KeyHash     string     `gorm:"size:64;not null;uniqueIndex:idx_api_keys_key_hash" json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Priority ranks how urgent a task is, from P0 (most urgent) to P4. It is
+// stored as its number so that sorting ascending lists P0 first, and
+// written as "P0".."P4" in JSON.
+type Priority int
+
+const (
+	PriorityP0 Priority = iota
+	PriorityP1
+	PriorityP2
+	PriorityP3
+	PriorityP4
+)
+
+// DefaultPriority is given to tasks created without a priority.
+const DefaultPriority = PriorityP2
+
+func (p Priority) Valid() bool {
+	return p >= PriorityP0 && p <= PriorityP4
+}
+
+func (p Priority) String() string {
+	return "P" + strconv.Itoa(int(p))
+}
+
+// ParsePriority accepts "P0".."P4", case-insensitively.
+func ParsePriority(s string) (Priority, error) {
+	if len(s) == 2 && (s[0] == 'P' || s[0] == 'p') {
+		if n, err := strconv.Atoi(s[1:]); err == nil && Priority(n).Valid() {
+			return Priority(n), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid priority %q: must be one of P0, P1, P2, P3, P4", s)
+}
+
+func (p Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid priority %s: must be one of P0, P1, P2, P3, P4", strings.TrimSpace(string(data)))
+	}
+	parsed, err := ParsePriority(s)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Kinds of TaskReminder.
+const (
+	ReminderBefore  = "reminder" // Offset before the due date
+	ReminderOverdue = "overdue"  // the due date has passed
+)
+
+// TaskReminder records a notification the reminder scheduler claimed for a
+// task. The unique key makes the claim, so each notification is sent at most
+// once per due date, across restarts and instances.
+type TaskReminder struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint       `gorm:"not null;index" json:"workspace_id"`
+	TaskID      uint       `gorm:"not null;uniqueIndex:idx_task_reminders_key,priority:1" json:"task_id"`
+	DueDate     time.Time  `gorm:"not null;uniqueIndex:idx_task_reminders_key,priority:2" json:"due_date"`
+	Kind        string     `gorm:"size:16;not null;uniqueIndex:idx_task_reminders_key,priority:3" json:"kind"`
+	Offset      string     `gorm:"size:32;not null;default:'';uniqueIndex:idx_task_reminders_key,priority:4" json:"offset,omitempty"`
+	SentAt      *time.Time `json:"sent_at,omitempty"` // unset while the notification is being sent
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Reminders are the offsets before its due date at which a task is
+// reminded of, as Go durations such as "24h" or "30m". Stored as JSON like
+// Tags; an empty list is stored as NULL.
+type Reminders []string
+
+func (Reminders) GormDataType() string {
+	return "json"
+}
+
+func (Reminders) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "jsonb"
+	}
+	return "json"
+}
+
+func (r Reminders) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal([]string(r))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (r *Reminders) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("unsupported reminders value %T", value)
+	}
+	return json.Unmarshal(raw, (*[]string)(r))
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Status is a task's workflow state.
+type Status string
+
+const (
+	StatusTodo       Status = "todo"
+	StatusInProgress Status = "in_progress"
+	StatusBlocked    Status = "blocked"
+	StatusInReview   Status = "in_review"
+	StatusDone       Status = "done"
+	StatusCancelled  Status = "cancelled"
+)
+
+// Statuses lists every status in workflow order.
+var Statuses = []Status{StatusTodo, StatusInProgress, StatusBlocked, StatusInReview, StatusDone, StatusCancelled}
+
+func (s Status) Valid() bool {
+	for _, status := range Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Closed reports whether the task is finished; closed tasks only leave
+// their status by being reopened.
+func (s Status) Closed() bool {
+	return s == StatusDone || s == StatusCancelled
+}
+
+// ParseStatus accepts a status name or, for clients of the old API, 0
+// (incomplete, now todo) and 1 (complete, now done).
+func ParseStatus(s string) (Status, error) {
+	switch s {
+	case "0":
+		return StatusTodo, nil
+	case "1":
+		return StatusDone, nil
+	}
+	if status := Status(s); status.Valid() {
+		return status, nil
+	}
+	names := make([]string, len(Statuses))
+	for i, status := range Statuses {
+		names[i] = string(status)
+	}
+	return "", fmt.Errorf("invalid status %q: must be one of %s", s, strings.Join(names, ", "))
+}
+
+// UnmarshalJSON accepts a status name or the legacy numbers 0 and 1.
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var legacy json.Number
+		if json.Unmarshal(data, &legacy) != nil {
+			return fmt.Errorf("invalid status %s", data)
+		}
+		name = legacy.String()
+	}
+	if name == "" {
+		*s = ""
+		return nil
+	}
+	status, err := ParseStatus(name)
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
@@ -2,15 +2,31 @@ package model
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Task struct {
-	ID        uint       `gorm:"primaryKey" json:"id"`
-	Name      string     `gorm:"size:255;not null" json:"name"`
-	Status    int        `gorm:"type:int;default:0" json:"status"` // 0 = 未完成，1 = 已完成
-	DueDate   *time.Time `json:"due_date,omitempty"`
-	Assignee  string     `json:"assignee"`
-	Tags      []string   `gorm:"type:json;serializer:json" json:"tags,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint           `gorm:"not null;index" json:"workspace_id"`
+	ParentID    *uint          `gorm:"index" json:"parent_id,omitempty"` // set on subtasks
+	Name        string         `gorm:"size:255;not null" json:"name"`
+	Status      Status         `gorm:"size:16;not null;default:todo" json:"status"`
+	Priority    Priority       `gorm:"not null" json:"priority"`
+	Rank        string         `gorm:"column:position;size:64;not null;default:''" json:"rank"` // fractional index for manual ordering, see pkg/rank
+	DueDate     *time.Time     `json:"due_date,omitempty"`
+	Assignee    string         `json:"assignee"`
+	Tags        Tags           `json:"tags,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Version     uint           `gorm:"not null;default:1" json:"version"`                        // bumped on every update, exposed as the ETag
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at"`                                  // set while the task is in the trash
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`                                   // set while the status is done
+	Recurrence  string         `gorm:"size:255;not null;default:''" json:"recurrence,omitempty"` // RRULE, see pkg/recurrence
+	SeriesID    *uint          `gorm:"index" json:"series_id,omitempty"`                         // first occurrence of the recurring series
+	Reminders   Reminders      `json:"reminders,omitempty"`                                      // offsets before the due date, see pkg/reminder
+	OverdueAt   *time.Time     `json:"overdue_at,omitempty"`                                     // set by the scheduler once the due date passed
+	// NextReminderAt is when the reminder scheduler next has to look at the
+	// task; nil when there is nothing left to notify.
+	NextReminderAt *time.Time `gorm:"index" json:"-"`
 }
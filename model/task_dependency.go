@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// TaskDependency records that BlockerID blocks BlockedID: the blocked task
+// cannot be done while the blocker is open. Both tasks are in WorkspaceID
+// and the dependencies of a workspace never form a cycle.
+type TaskDependency struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint      `gorm:"not null;index" json:"workspace_id"`
+	BlockerID   uint      `gorm:"not null;uniqueIndex:idx_task_dependencies_edge,priority:1" json:"blocker_id"`
+	BlockedID   uint      `gorm:"not null;uniqueIndex:idx_task_dependencies_edge,priority:2;index" json:"blocked_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
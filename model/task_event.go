@@ -0,0 +1,93 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Task event types.
+const (
+	TaskCreated  = "created"
+	TaskUpdated  = "updated"
+	TaskDeleted  = "deleted"
+	TaskRestored = "restored"
+	TaskReopened = "reopened"
+	TaskOverdue  = "overdue"
+)
+
+// TaskEvent is one entry in a task's audit history. It is written in the
+// same transaction as the change it records and outlives the task.
+//
+// Seq numbers the events of a workspace in commit order, unlike ID, which is
+// allocated before commit; see TaskEventSequence.
+type TaskEvent struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint      `gorm:"not null;index;index:idx_task_events_workspace_seq,priority:1" json:"workspace_id"`
+	Seq         uint      `gorm:"not null;default:0;index:idx_task_events_workspace_seq,priority:2" json:"-"`
+	TaskID      uint      `gorm:"not null;index" json:"task_id"`
+	Type        string    `gorm:"size:16;not null" json:"type"`
+	Actor       string    `gorm:"size:255;not null;default:''" json:"actor"` // principal subject, empty without auth
+	Version     uint      `gorm:"not null" json:"version"`                   // task version after the change
+	Changes     Changes   `json:"changes,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TaskEventSequence is the last Seq handed out in a workspace. A transaction
+// bumps it right before it commits, and the row lock keeps the other writers
+// of the workspace from bumping it until the commit.
+type TaskEventSequence struct {
+	WorkspaceID uint `gorm:"primaryKey;autoIncrement:false"`
+	Seq         uint `gorm:"not null;default:0"`
+}
+
+// Change is the before and after value of one field.
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Changes maps column names to their change; stored as JSON like Tags.
+type Changes map[string]Change
+
+func (Changes) GormDataType() string {
+	return "json"
+}
+
+func (Changes) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "jsonb"
+	}
+	return "json"
+}
+
+func (c Changes) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(map[string]Change(c))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (c *Changes) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("unsupported changes value %T", value)
+	}
+	return json.Unmarshal(raw, (*map[string]Change)(c))
+}
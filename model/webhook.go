@@ -0,0 +1,74 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Webhook events, derived from the task events that trigger them.
+const (
+	WebhookTaskCreated   = "task.created"
+	WebhookTaskUpdated   = "task.updated"
+	WebhookTaskDeleted   = "task.deleted"
+	WebhookTaskCompleted = "task.completed"
+)
+
+// Webhook delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed" // gave up after the last attempt
+)
+
+// Webhook subscribes URL to the task events of a workspace. Payloads are
+// signed with Secret.
+type Webhook struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WorkspaceID uint      `gorm:"not null;index" json:"workspace_id"`
+	URL         string    `gorm:"size:2048;not null" json:"url"`
+	Secret      string    `gorm:"size:255;not null" json:"-"`
+	Events      string    `gorm:"size:255;not null;default:''" json:"events"` // comma separated, empty for every event
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// EventList is Events as a list; nil means every event.
+func (w *Webhook) EventList() []string {
+	if w.Events == "" {
+		return nil
+	}
+	return strings.Split(w.Events, ",")
+}
+
+// Wants reports whether event is one the webhook subscribed to.
+func (w *Webhook) Wants(event string) bool {
+	if w.Events == "" {
+		return true
+	}
+	for _, e := range w.EventList() {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for, or delivered to, a webhook. It
+// is written in the same transaction as the task event it announces, and
+// Payload is the exact body that is signed and sent on every attempt.
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	WorkspaceID    uint       `gorm:"not null;index" json:"workspace_id"`
+	WebhookID      uint       `gorm:"not null;index" json:"webhook_id"`
+	TaskID         uint       `gorm:"not null" json:"task_id"`
+	Event          string     `gorm:"size:32;not null" json:"event"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"`
+	Status         string     `gorm:"size:16;not null;default:pending;index:idx_webhook_deliveries_due,priority:1" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"not null;index:idx_webhook_deliveries_due,priority:2" json:"next_attempt_at"`
+	ResponseStatus int        `gorm:"not null;default:0" json:"response_status"` // of the last attempt, 0 if there was no response
+	LastError      string     `gorm:"size:1024;not null;default:''" json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
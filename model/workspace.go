@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// Workspace is a tenant. Every task belongs to exactly one workspace and is
+// never visible from another.
+type Workspace struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:100;not null;uniqueIndex:idx_workspaces_name" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+// Package actor carries who is making a request, for the audit trail. It is
+// separate from pkg/auth so the repository can read it without import cycles.
+package actor
+
+import "context"
+
+type ctxKey struct{}
+
+func NewContext(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, actor)
+}
+
+// FromContext returns the acting principal's subject, or "" when the request
+// is unauthenticated.
+func FromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(ctxKey{}).(string)
+	return actor
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+
+	"task-api/model"
+	"task-api/repository"
+)
+
+// KeyPrefix marks API keys so they can be told apart from JWTs.
+const KeyPrefix = "tk_"
+
+// GenerateKey returns a new random API key and the prefix shown in listings.
+func GenerateKey() (key, prefix string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key = KeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:len(KeyPrefix)+8], nil
+}
+
+// HashKey returns the hex SHA-256 of key. Keys carry 256 bits of entropy, so
+// a fast unsalted hash is enough and allows lookup by hash.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func keySubject(id uint) string {
+	return "apikey:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// IssueKey creates and stores a new API key. The plaintext key is returned
+// once and cannot be recovered afterwards.
+func IssueKey(ctx context.Context, keys repository.APIKeyRepositoryInterface, name string, role Role, workspaceID *uint) (*model.APIKey, string, error) {
+	key, prefix, err := GenerateKey()
+	if err != nil {
+		return nil, "", err
+	}
+	created, err := keys.CreateAPIKey(ctx, &model.APIKey{Name: name, Role: string(role), WorkspaceID: workspaceID, Prefix: prefix, KeyHash: HashKey(key)})
+	if err != nil {
+		return nil, "", err
+	}
+	return created, key, nil
+}
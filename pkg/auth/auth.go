@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"task-api/model"
+	"task-api/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnauthenticated = errors.New("missing or invalid credentials")
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string // "apikey:<id>" or the JWT sub claim
+	Name    string // matched against model.Task.Assignee for ownership
+	Method  string // MethodAPIKey or MethodJWT
+	Role    Role
+	// AllWorkspaces grants access to every workspace; otherwise only the
+	// ones listed in Workspaces.
+	AllWorkspaces bool
+	Workspaces    []uint
+}
+
+// CanAccessWorkspace reports whether the principal may use workspace id.
+// A nil principal means authentication is disabled.
+func (p *Principal) CanAccessWorkspace(id uint) bool {
+	if p == nil || p.AllWorkspaces {
+		return true
+	}
+	for _, ws := range p.Workspaces {
+		if ws == id {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+type ctxKey struct{}
+
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext returns the principal stored by the auth middleware, or nil.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(ctxKey{}).(*Principal)
+	return p
+}
+
+// KeyStore looks up hashed API keys; repository.APIKeyRepository implements it.
+type KeyStore interface {
+	FindAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error)
+}
+
+// Authenticator validates API keys against the KeyStore and JWTs against a
+// JWKS. Either may be nil to disable that method.
+type Authenticator struct {
+	keys    KeyStore
+	jwks    *JWKS
+	options []jwt.ParserOption
+}
+
+// New returns an Authenticator. issuer and audience are enforced on JWTs
+// when non-empty.
+func New(keys KeyStore, jwks *JWKS, issuer, audience string) *Authenticator {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "RS256"}),
+		jwt.WithExpirationRequired(),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+	return &Authenticator{keys: keys, jwks: jwks, options: options}
+}
+
+// Authenticate resolves a bearer token: tokens with the API key prefix are
+// looked up in the KeyStore, anything else is parsed as a JWT. Every failure
+// is reported as ErrUnauthenticated so callers can't probe which part failed;
+// store errors are returned as-is.
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	if token == "" {
+		return nil, ErrUnauthenticated
+	}
+	if strings.HasPrefix(token, KeyPrefix) {
+		return a.authenticateKey(ctx, token)
+	}
+	return a.authenticateJWT(token)
+}
+
+func (a *Authenticator) authenticateKey(ctx context.Context, token string) (*Principal, error) {
+	if a.keys == nil {
+		return nil, ErrUnauthenticated
+	}
+	key, err := a.keys.FindAPIKeyByHash(ctx, HashKey(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, ErrUnauthenticated
+		}
+		return nil, err
+	}
+	p := &Principal{Subject: keySubject(key.ID), Name: key.Name, Method: MethodAPIKey, Role: Role(key.Role)}
+	if key.WorkspaceID == nil {
+		p.AllWorkspaces = true
+	} else {
+		p.Workspaces = []uint{*key.WorkspaceID}
+	}
+	return p, nil
+}
+
+func (a *Authenticator) authenticateJWT(token string) (*Principal, error) {
+	if a.jwks == nil {
+		return nil, ErrUnauthenticated
+	}
+	var claims Claims
+	if _, err := jwt.ParseWithClaims(token, &claims, a.jwks.Keyfunc, a.options...); err != nil {
+		return nil, ErrUnauthenticated
+	}
+	if claims.Subject == "" {
+		return nil, ErrUnauthenticated
+	}
+	name := claims.Name
+	if name == "" {
+		name = claims.Subject
+	}
+	// Tokens without a recognised role get the least privilege.
+	role := Role(claims.Role)
+	if !role.Valid() {
+		role = RoleViewer
+	}
+	return &Principal{
+		Subject:       claims.Subject,
+		Name:          name,
+		Method:        MethodJWT,
+		Role:          role,
+		AllWorkspaces: claims.Workspaces.All,
+		Workspaces:    claims.Workspaces.IDs,
+	}, nil
+}
+
+// Claims are the JWT claims the API understands.
+type Claims struct {
+	Name string `json:"name,omitempty"`
+	Role string `json:"role,omitempty"` // viewer, member or admin
+	// Workspaces lists the workspace ids the token is valid for; "*" means all.
+	Workspaces WorkspaceClaim `json:"workspaces,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// WorkspaceClaim decodes a "workspaces" claim: an array of ids, which may
+// contain "*" for every workspace. A missing claim grants no workspace.
+type WorkspaceClaim struct {
+	All bool
+	IDs []uint
+}
+
+func (w *WorkspaceClaim) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("workspaces claim: %w", err)
+	}
+	for _, item := range items {
+		var id uint
+		if err := json.Unmarshal(item, &id); err == nil {
+			w.IDs = append(w.IDs, id)
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(item, &s); err != nil || s != "*" {
+			return fmt.Errorf("workspaces claim: invalid entry %s", item)
+		}
+		w.All = true
+	}
+	return nil
+}
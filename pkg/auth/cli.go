@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"task-api/repository"
+)
+
+var ErrUsage = errors.New("usage: apikey create <name> [viewer|member|admin] [workspace id] | list | revoke <id>")
+
+// Run executes the `apikey` subcommand, which bootstraps keys before any
+// caller can authenticate against the HTTP management endpoints. Keys
+// created here are admins valid for every workspace unless a role and
+// workspace are given.
+func Run(ctx context.Context, keys repository.APIKeyRepositoryInterface, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	switch args[0] {
+	case "create":
+		if len(args) < 2 || len(args) > 4 || args[1] == "" {
+			return ErrUsage
+		}
+		role := RoleAdmin
+		if len(args) >= 3 {
+			role = Role(args[2])
+		}
+		if !role.Valid() {
+			return ErrUsage
+		}
+		var workspaceID *uint
+		if len(args) == 4 {
+			id, err := strconv.ParseUint(args[3], 10, 64)
+			if err != nil {
+				return ErrUsage
+			}
+			ws := uint(id)
+			workspaceID = &ws
+		}
+		created, key, err := IssueKey(ctx, keys, args[1], role, workspaceID)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "created %s api key %d (%s); it will not be shown again:\n%s\n", created.Role, created.ID, created.Name, key)
+		return nil
+	case "list":
+		list, err := keys.ListAPIKeys(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tROLE\tWORKSPACE\tPREFIX\tCREATED AT\tREVOKED AT")
+		for _, k := range list {
+			revoked := "-"
+			if k.RevokedAt != nil {
+				revoked = k.RevokedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			workspace := "*"
+			if k.WorkspaceID != nil {
+				workspace = strconv.FormatUint(uint64(*k.WorkspaceID), 10)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Name, k.Role, workspace, k.Prefix, k.CreatedAt.Format("2006-01-02 15:04:05 MST"), revoked)
+		}
+		return w.Flush()
+	case "revoke":
+		if len(args) != 2 {
+			return ErrUsage
+		}
+		id, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return ErrUsage
+		}
+		if err := keys.RevokeAPIKey(ctx, uint(id)); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "revoked api key %d\n", id)
+		return nil
+	default:
+		return ErrUsage
+	}
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWKS is a set of verification keys loaded from a local JSON Web Key Set
+// file. Symmetric ("oct") keys verify HS256 and RSA keys verify RS256.
+type JWKS struct {
+	keys map[string]jwk // by kid
+}
+
+type jwk struct {
+	alg string
+	key interface{} // []byte or *rsa.PublicKey
+}
+
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// LoadJWKS reads a JWKS file such as {"keys":[{"kty":"oct","kid":"k1","k":"..."}]}.
+func LoadJWKS(path string) (*JWKS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+func ParseJWKS(data []byte) (*JWKS, error) {
+	var set struct {
+		Keys []rawJWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	if len(set.Keys) == 0 {
+		return nil, errors.New("jwks: no keys")
+	}
+
+	jwks := &JWKS{keys: make(map[string]jwk, len(set.Keys))}
+	for i, raw := range set.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(raw)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: key %d (%q): %w", i, raw.Kid, err)
+		}
+		if _, dup := jwks.keys[raw.Kid]; dup {
+			return nil, fmt.Errorf("jwks: duplicate kid %q", raw.Kid)
+		}
+		jwks.keys[raw.Kid] = key
+	}
+	return jwks, nil
+}
+
+func parseJWK(raw rawJWK) (jwk, error) {
+	switch raw.Kty {
+	case "oct":
+		if raw.Alg != "" && raw.Alg != "HS256" {
+			return jwk{}, fmt.Errorf("unsupported alg %q for oct key", raw.Alg)
+		}
+		secret, err := base64.RawURLEncoding.DecodeString(raw.K)
+		if err != nil || len(secret) == 0 {
+			return jwk{}, errors.New("invalid k")
+		}
+		return jwk{alg: "HS256", key: secret}, nil
+	case "RSA":
+		if raw.Alg != "" && raw.Alg != "RS256" {
+			return jwk{}, fmt.Errorf("unsupported alg %q for RSA key", raw.Alg)
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(raw.N)
+		e, errE := base64.RawURLEncoding.DecodeString(raw.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return jwk{}, errors.New("invalid n or e")
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		return jwk{alg: "RS256", key: pub}, nil
+	default:
+		return jwk{}, fmt.Errorf("unsupported kty %q", raw.Kty)
+	}
+}
+
+// Keyfunc selects the verification key by the token's kid header. The key's
+// algorithm must match the token's so an RSA public key can never be used as
+// an HMAC secret.
+func (s *JWKS) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok && kid == "" && len(s.keys) == 1 {
+		for _, only := range s.keys {
+			key, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.alg {
+		return nil, fmt.Errorf("alg %s does not match key", token.Method.Alg())
+	}
+	return key.key, nil
+}
@@ -0,0 +1,76 @@
+package auth
+
+import "fmt"
+
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleMember Role = "member"
+	RoleAdmin  Role = "admin"
+)
+
+type Permission string
+
+const (
+	PermTaskRead   Permission = "tasks:read"
+	PermTaskCreate Permission = "tasks:create"
+	PermTaskUpdate Permission = "tasks:update"
+	PermTaskDelete Permission = "tasks:delete"
+	// PermTaskAny lifts the "only tasks assigned to you" restriction on
+	// create and update.
+	PermTaskAny          Permission = "tasks:any"
+	PermWebhooksManage   Permission = "webhooks:manage"
+	PermAPIKeysManage    Permission = "api_keys:manage"
+	PermWorkspacesManage Permission = "workspaces:manage"
+)
+
+// platformPermissions span tenants, so they additionally require a
+// principal that is valid for every workspace.
+var platformPermissions = map[Permission]bool{
+	PermAPIKeysManage:    true,
+	PermWorkspacesManage: true,
+}
+
+// policy is the single source of truth for what each role may do.
+var policy = map[Role][]Permission{
+	RoleViewer: {PermTaskRead},
+	RoleMember: {PermTaskRead, PermTaskCreate, PermTaskUpdate},
+	RoleAdmin:  {PermTaskRead, PermTaskCreate, PermTaskUpdate, PermTaskDelete, PermTaskAny, PermWebhooksManage, PermAPIKeysManage, PermWorkspacesManage},
+}
+
+func (r Role) Valid() bool {
+	_, ok := policy[r]
+	return ok
+}
+
+func (r Role) Can(perm Permission) bool {
+	for _, p := range policy[r] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether the principal holds perm. A nil principal means
+// authentication is disabled, in which case everything is allowed.
+func (p *Principal) Can(perm Permission) bool {
+	if p == nil {
+		return true
+	}
+	return p.Role.Can(perm) && (p.AllWorkspaces || !platformPermissions[perm])
+}
+
+// ForbiddenError explains which permission the caller lacks.
+type ForbiddenError struct {
+	Role       Role
+	Permission Permission
+}
+
+func (e *ForbiddenError) Error() string {
+	if e.Role.Can(e.Permission) {
+		return fmt.Sprintf("permission %q requires credentials valid for all workspaces", e.Permission)
+	}
+	return fmt.Sprintf("role %q lacks permission %q", e.Role, e.Permission)
+}
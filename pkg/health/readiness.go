@@ -0,0 +1,17 @@
+package health
+
+import "sync/atomic"
+
+// Readiness tracks whether the process should receive new traffic. It starts
+// ready and flips to draining once shutdown begins; it never flips back.
+type Readiness struct {
+	draining atomic.Bool
+}
+
+func (r *Readiness) SetDraining() {
+	r.draining.Store(true)
+}
+
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
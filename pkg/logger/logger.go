@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// New returns a JSON logger writing records at or above level to w.
+func New(level string, w io.Writer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: ParseLevel(level)}))
+}
+
+func ParseLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+type ctxKey struct{}
+
+// WithContext stores a request-scoped logger in ctx.
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the request-scoped logger, or slog.Default() outside a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+			return l
+		}
+	}
+	return slog.Default()
+}
+
+// GormWriter adapts slog to gorm's logger so SQL warnings and slow queries
+// are emitted as JSON like everything else.
+type GormWriter struct {
+	Logger *slog.Logger
+}
+
+func (w GormWriter) Printf(format string, args ...interface{}) {
+	w.Logger.Warn(fmt.Sprintf(format, args...), "component", "gorm")
+}
+
+var _ gormlogger.Writer = GormWriter{}
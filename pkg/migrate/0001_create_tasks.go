@@ -0,0 +1,42 @@
+package migrate
+
+import (
+	"time"
+
+	"task-api/model"
+
+	"gorm.io/gorm"
+)
+
+// task0001 is the tasks table as it was created by AutoMigrate before
+// versioned migrations existed. Snapshots like this keep old migrations
+// stable when model.Task changes.
+type task0001 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:255;not null"`
+	Status    int    `gorm:"type:int;default:0"`
+	DueDate   *time.Time
+	Assignee  string
+	Tags      model.Tags
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (task0001) TableName() string {
+	return "tasks"
+}
+
+var createTasks = Migration{
+	Version: 1,
+	Name:    "create_tasks",
+	Up: func(tx *gorm.DB) error {
+		// Databases created by the old AutoMigrate already have the table.
+		if tx.Migrator().HasTable(&task0001{}) {
+			return nil
+		}
+		return tx.Migrator().CreateTable(&task0001{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&task0001{})
+	},
+}
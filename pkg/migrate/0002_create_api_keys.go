@@ -0,0 +1,31 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type apiKey0002 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:100;not null"`
+	Prefix    string `gorm:"size:16;not null"`
+	KeyHash   string `gorm:"size:64;not null;uniqueIndex:idx_api_keys_key_hash"`
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+func (apiKey0002) TableName() string {
+	return "api_keys"
+}
+
+var createAPIKeys = Migration{
+	Version: 2,
+	Name:    "create_api_keys",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&apiKey0002{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&apiKey0002{})
+	},
+}
@@ -0,0 +1,26 @@
+package migrate
+
+import "gorm.io/gorm"
+
+type apiKey0003 struct {
+	Role string `gorm:"size:16;not null;default:member"`
+}
+
+func (apiKey0003) TableName() string {
+	return "api_keys"
+}
+
+var addAPIKeyRoles = Migration{
+	Version: 3,
+	Name:    "add_api_key_roles",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&apiKey0003{}, "Role"); err != nil {
+			return err
+		}
+		// Keys issued before roles existed had full access; keep it that way.
+		return tx.Exec("UPDATE api_keys SET role = 'admin'").Error
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropColumn(&apiKey0003{}, "Role")
+	},
+}
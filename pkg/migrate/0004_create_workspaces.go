@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type workspace0004 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:100;not null;uniqueIndex:idx_workspaces_name"`
+	CreatedAt time.Time
+}
+
+func (workspace0004) TableName() string {
+	return "workspaces"
+}
+
+type task0004 struct {
+	// Existing rows land in the default workspace created below.
+	WorkspaceID uint `gorm:"not null;default:1;index"`
+}
+
+func (task0004) TableName() string {
+	return "tasks"
+}
+
+type apiKey0004 struct {
+	WorkspaceID *uint
+}
+
+func (apiKey0004) TableName() string {
+	return "api_keys"
+}
+
+var createWorkspaces = Migration{
+	Version: 4,
+	Name:    "create_workspaces",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().CreateTable(&workspace0004{}); err != nil {
+			return err
+		}
+		// The first row of a fresh table gets id 1, matching the column default below.
+		if err := tx.Create(&workspace0004{Name: "default", CreatedAt: time.Now().UTC()}).Error; err != nil {
+			return err
+		}
+		if err := tx.Migrator().AddColumn(&task0004{}, "WorkspaceID"); err != nil {
+			return err
+		}
+		if err := tx.Migrator().CreateIndex(&task0004{}, "WorkspaceID"); err != nil {
+			return err
+		}
+		return tx.Migrator().AddColumn(&apiKey0004{}, "WorkspaceID")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropColumn(&apiKey0004{}, "WorkspaceID"); err != nil {
+			return err
+		}
+		if err := tx.Migrator().DropIndex(&task0004{}, "WorkspaceID"); err != nil {
+			return err
+		}
+		if err := tx.Migrator().DropColumn(&task0004{}, "WorkspaceID"); err != nil {
+			return err
+		}
+		return tx.Migrator().DropTable(&workspace0004{})
+	},
+}
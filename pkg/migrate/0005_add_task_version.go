@@ -0,0 +1,24 @@
+package migrate
+
+import "gorm.io/gorm"
+
+type task0005 struct {
+	Version uint `gorm:"not null;default:1"`
+}
+
+func (task0005) TableName() string {
+	return "tasks"
+}
+
+var addTaskVersion = Migration{
+	Version: 5,
+	Name:    "add_task_version",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().AddColumn(&task0005{}, "Version")
+	},
+	Down: func(tx *gorm.DB) error {
+		// gorm's sqlite DropColumn rebuilds the table and loses idx_tasks_workspace_id,
+		// which 0004's Down then fails to drop; both dialects support DROP COLUMN.
+		return tx.Exec("ALTER TABLE tasks DROP COLUMN version").Error
+	},
+}
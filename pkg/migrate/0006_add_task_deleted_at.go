@@ -0,0 +1,29 @@
+package migrate
+
+import "gorm.io/gorm"
+
+type task0006 struct {
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (task0006) TableName() string {
+	return "tasks"
+}
+
+var addTaskDeletedAt = Migration{
+	Version: 6,
+	Name:    "add_task_deleted_at",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&task0006{}, "DeletedAt"); err != nil {
+			return err
+		}
+		return tx.Migrator().CreateIndex(&task0006{}, "DeletedAt")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&task0006{}, "DeletedAt"); err != nil {
+			return err
+		}
+		// see 0005: gorm's sqlite DropColumn would lose the other indexes
+		return tx.Exec("ALTER TABLE tasks DROP COLUMN deleted_at").Error
+	},
+}
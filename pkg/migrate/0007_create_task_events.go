@@ -0,0 +1,35 @@
+package migrate
+
+import (
+	"time"
+
+	"task-api/model"
+
+	"gorm.io/gorm"
+)
+
+type taskEvent0007 struct {
+	ID          uint   `gorm:"primaryKey"`
+	WorkspaceID uint   `gorm:"not null;index"`
+	TaskID      uint   `gorm:"not null;index"`
+	Type        string `gorm:"size:16;not null"`
+	Actor       string `gorm:"size:255;not null;default:''"`
+	Version     uint   `gorm:"not null"`
+	Changes     model.Changes
+	CreatedAt   time.Time
+}
+
+func (taskEvent0007) TableName() string {
+	return "task_events"
+}
+
+var createTaskEvents = Migration{
+	Version: 7,
+	Name:    "create_task_events",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&taskEvent0007{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&taskEvent0007{})
+	},
+}
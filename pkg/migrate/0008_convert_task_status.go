@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type task0008 struct {
+	StatusName  string `gorm:"size:16;not null;default:todo"`
+	CompletedAt *time.Time
+}
+
+func (task0008) TableName() string {
+	return "tasks"
+}
+
+// convertTaskStatus replaces the 0/1 status with workflow state names:
+// 0 becomes todo and 1 done, with completed_at taken from the last update.
+var convertTaskStatus = Migration{
+	Version: 8,
+	Name:    "convert_task_status",
+	Up: func(tx *gorm.DB) error {
+		for _, field := range []string{"StatusName", "CompletedAt"} {
+			if err := tx.Migrator().AddColumn(&task0008{}, field); err != nil {
+				return err
+			}
+		}
+		return execAll(tx, []string{
+			"UPDATE tasks SET status_name = CASE WHEN status = 1 THEN 'done' ELSE 'todo' END",
+			"UPDATE tasks SET completed_at = updated_at WHERE status = 1",
+			// see 0005: gorm's sqlite DropColumn would lose the other indexes
+			"ALTER TABLE tasks DROP COLUMN status",
+			"ALTER TABLE tasks RENAME COLUMN status_name TO status",
+		})
+	},
+	Down: func(tx *gorm.DB) error {
+		return execAll(tx, []string{
+			"ALTER TABLE tasks RENAME COLUMN status TO status_name",
+			"ALTER TABLE tasks ADD COLUMN status int DEFAULT 0",
+			"UPDATE tasks SET status = CASE WHEN status_name IN ('done', 'cancelled') THEN 1 ELSE 0 END",
+			"ALTER TABLE tasks DROP COLUMN status_name",
+			"ALTER TABLE tasks DROP COLUMN completed_at",
+		})
+	},
+}
+
+// execAll runs raw statements in order, stopping at the first error.
+func execAll(tx *gorm.DB, statements []string) error {
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package migrate
+
+import (
+	"task-api/pkg/rank"
+
+	"gorm.io/gorm"
+)
+
+type task0009 struct{}
+
+func (task0009) TableName() string {
+	return "tasks"
+}
+
+// rankColumn compares ranks byte by byte, as pkg/rank requires, instead of
+// by the database's (possibly case-insensitive) default collation.
+func rankColumn(dialect string) string {
+	switch dialect {
+	case "postgres":
+		return `varchar(64) COLLATE "C" NOT NULL DEFAULT ''`
+	case "mysql":
+		return "varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL DEFAULT ''"
+	default:
+		return "varchar(64) NOT NULL DEFAULT ''"
+	}
+}
+
+var addTaskPriorityRank = Migration{
+	Version: 9,
+	Name:    "add_task_priority_rank",
+	Up: func(tx *gorm.DB) error {
+		// 欄位名稱用 position：rank 在 MySQL 8 是保留字
+		err := execAll(tx, []string{
+			"ALTER TABLE tasks ADD COLUMN priority int NOT NULL DEFAULT 2",
+			"ALTER TABLE tasks ADD COLUMN position " + rankColumn(tx.Dialector.Name()),
+			"CREATE INDEX idx_tasks_workspace_position ON tasks (workspace_id, position)",
+		})
+		if err != nil {
+			return err
+		}
+
+		// 既有 task 依建立順序在各自的 workspace 中排好
+		var rows []struct {
+			ID          uint
+			WorkspaceID uint
+		}
+		if err := tx.Table("tasks").Select("id, workspace_id").Order("workspace_id, id").Scan(&rows).Error; err != nil {
+			return err
+		}
+		var workspace uint
+		last := ""
+		for _, row := range rows {
+			if row.WorkspaceID != workspace {
+				workspace, last = row.WorkspaceID, ""
+			}
+			key, err := rank.Between(last, "")
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE tasks SET position = ? WHERE id = ?", key, row.ID).Error; err != nil {
+				return err
+			}
+			last = key
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&task0009{}, "idx_tasks_workspace_position"); err != nil {
+			return err
+		}
+		return execAll(tx, []string{
+			"ALTER TABLE tasks DROP COLUMN position",
+			"ALTER TABLE tasks DROP COLUMN priority",
+		})
+	},
+}
@@ -0,0 +1,29 @@
+package migrate
+
+import "gorm.io/gorm"
+
+type task0010 struct {
+	ParentID *uint `gorm:"index"`
+}
+
+func (task0010) TableName() string {
+	return "tasks"
+}
+
+var addTaskParentID = Migration{
+	Version: 10,
+	Name:    "add_task_parent_id",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&task0010{}, "ParentID"); err != nil {
+			return err
+		}
+		return tx.Migrator().CreateIndex(&task0010{}, "ParentID")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&task0010{}, "ParentID"); err != nil {
+			return err
+		}
+		// see 0005: gorm's sqlite DropColumn would lose the other indexes
+		return tx.Exec("ALTER TABLE tasks DROP COLUMN parent_id").Error
+	},
+}
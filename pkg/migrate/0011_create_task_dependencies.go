@@ -0,0 +1,30 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type taskDependency0011 struct {
+	ID          uint `gorm:"primaryKey"`
+	WorkspaceID uint `gorm:"not null;index"`
+	BlockerID   uint `gorm:"not null;uniqueIndex:idx_task_dependencies_edge,priority:1"`
+	BlockedID   uint `gorm:"not null;uniqueIndex:idx_task_dependencies_edge,priority:2;index"`
+	CreatedAt   time.Time
+}
+
+func (taskDependency0011) TableName() string {
+	return "task_dependencies"
+}
+
+var createTaskDependencies = Migration{
+	Version: 11,
+	Name:    "create_task_dependencies",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&taskDependency0011{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&taskDependency0011{})
+	},
+}
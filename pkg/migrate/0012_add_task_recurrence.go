@@ -0,0 +1,35 @@
+package migrate
+
+import "gorm.io/gorm"
+
+type task0012 struct {
+	Recurrence string `gorm:"size:255;not null;default:''"`
+	SeriesID   *uint  `gorm:"index"`
+}
+
+func (task0012) TableName() string {
+	return "tasks"
+}
+
+var addTaskRecurrence = Migration{
+	Version: 12,
+	Name:    "add_task_recurrence",
+	Up: func(tx *gorm.DB) error {
+		for _, field := range []string{"Recurrence", "SeriesID"} {
+			if err := tx.Migrator().AddColumn(&task0012{}, field); err != nil {
+				return err
+			}
+		}
+		return tx.Migrator().CreateIndex(&task0012{}, "SeriesID")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&task0012{}, "SeriesID"); err != nil {
+			return err
+		}
+		// see 0005: gorm's sqlite DropColumn would lose the other indexes
+		return execAll(tx, []string{
+			"ALTER TABLE tasks DROP COLUMN series_id",
+			"ALTER TABLE tasks DROP COLUMN recurrence",
+		})
+	},
+}
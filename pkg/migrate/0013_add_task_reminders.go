@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"time"
+
+	"task-api/model"
+
+	"gorm.io/gorm"
+)
+
+type task0013 struct {
+	Reminders model.Reminders
+	OverdueAt *time.Time
+}
+
+func (task0013) TableName() string {
+	return "tasks"
+}
+
+type taskReminder0013 struct {
+	ID          uint      `gorm:"primaryKey"`
+	WorkspaceID uint      `gorm:"not null;index"`
+	TaskID      uint      `gorm:"not null;uniqueIndex:idx_task_reminders_key,priority:1"`
+	DueDate     time.Time `gorm:"not null;uniqueIndex:idx_task_reminders_key,priority:2"`
+	Kind        string    `gorm:"size:16;not null;uniqueIndex:idx_task_reminders_key,priority:3"`
+	Offset      string    `gorm:"size:32;not null;default:'';uniqueIndex:idx_task_reminders_key,priority:4"`
+	SentAt      *time.Time
+	CreatedAt   time.Time
+}
+
+func (taskReminder0013) TableName() string {
+	return "task_reminders"
+}
+
+var addTaskReminders = Migration{
+	Version: 13,
+	Name:    "add_task_reminders",
+	Up: func(tx *gorm.DB) error {
+		for _, field := range []string{"Reminders", "OverdueAt"} {
+			if err := tx.Migrator().AddColumn(&task0013{}, field); err != nil {
+				return err
+			}
+		}
+		return tx.Migrator().CreateTable(&taskReminder0013{})
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropTable(&taskReminder0013{}); err != nil {
+			return err
+		}
+		// see 0005: gorm's sqlite DropColumn would lose the other indexes
+		return execAll(tx, []string{
+			"ALTER TABLE tasks DROP COLUMN overdue_at",
+			"ALTER TABLE tasks DROP COLUMN reminders",
+		})
+	},
+}
@@ -0,0 +1,53 @@
+package migrate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type webhook0014 struct {
+	ID          uint   `gorm:"primaryKey"`
+	WorkspaceID uint   `gorm:"not null;index"`
+	URL         string `gorm:"size:2048;not null"`
+	Secret      string `gorm:"size:255;not null"`
+	Events      string `gorm:"size:255;not null;default:''"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (webhook0014) TableName() string {
+	return "webhooks"
+}
+
+type webhookDelivery0014 struct {
+	ID             uint      `gorm:"primaryKey"`
+	WorkspaceID    uint      `gorm:"not null;index"`
+	WebhookID      uint      `gorm:"not null;index"`
+	TaskID         uint      `gorm:"not null"`
+	Event          string    `gorm:"size:32;not null"`
+	Payload        string    `gorm:"type:text;not null"`
+	Status         string    `gorm:"size:16;not null;default:pending;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int       `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time `gorm:"not null;index:idx_webhook_deliveries_due,priority:2"`
+	ResponseStatus int       `gorm:"not null;default:0"`
+	LastError      string    `gorm:"size:1024;not null;default:''"`
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (webhookDelivery0014) TableName() string {
+	return "webhook_deliveries"
+}
+
+var createWebhooks = Migration{
+	Version: 14,
+	Name:    "create_webhooks",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&webhook0014{}, &webhookDelivery0014{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&webhookDelivery0014{}, &webhook0014{})
+	},
+}
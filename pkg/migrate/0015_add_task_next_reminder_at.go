@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"time"
+
+	"task-api/model"
+
+	"gorm.io/gorm"
+)
+
+type task0015 struct {
+	NextReminderAt *time.Time `gorm:"index"`
+}
+
+func (task0015) TableName() string {
+	return "tasks"
+}
+
+var addTaskNextReminderAt = Migration{
+	Version: 15,
+	Name:    "add_task_next_reminder_at",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&task0015{}, "NextReminderAt"); err != nil {
+			return err
+		}
+		if err := tx.Migrator().CreateIndex(&task0015{}, "NextReminderAt"); err != nil {
+			return err
+		}
+
+		// 未結案且有到期日的 task 從最早的提醒開始排程；已認領過的提醒
+		// 由 task_reminders 的唯一鍵擋下，scheduler 會直接跳到下一個
+		var rows []struct {
+			ID        uint
+			DueDate   time.Time
+			Reminders model.Reminders
+		}
+		err := tx.Table("tasks").Select("id, due_date, reminders").
+			Where("due_date IS NOT NULL AND status NOT IN ?", []string{"done", "cancelled"}).
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			next := row.DueDate.UTC()
+			for _, offset := range row.Reminders {
+				if d, err := time.ParseDuration(offset); err == nil && row.DueDate.Add(-d).Before(next) {
+					next = row.DueDate.Add(-d).UTC()
+				}
+			}
+			if err := tx.Exec("UPDATE tasks SET next_reminder_at = ? WHERE id = ?", next, row.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&task0015{}, "NextReminderAt"); err != nil {
+			return err
+		}
+		// see 0005: gorm's sqlite DropColumn would lose the other indexes
+		return execAll(tx, []string{"ALTER TABLE tasks DROP COLUMN next_reminder_at"})
+	},
+}
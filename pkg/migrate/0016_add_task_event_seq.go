@@ -0,0 +1,69 @@
+package migrate
+
+import "gorm.io/gorm"
+
+type taskEvent0016 struct {
+	WorkspaceID uint `gorm:"index:idx_task_events_workspace_seq,priority:1"`
+	Seq         uint `gorm:"not null;default:0;index:idx_task_events_workspace_seq,priority:2"`
+}
+
+func (taskEvent0016) TableName() string {
+	return "task_events"
+}
+
+type taskEventSequence0016 struct {
+	WorkspaceID uint `gorm:"primaryKey;autoIncrement:false"`
+	Seq         uint `gorm:"not null;default:0"`
+}
+
+func (taskEventSequence0016) TableName() string {
+	return "task_event_sequences"
+}
+
+var addTaskEventSeq = Migration{
+	Version: 16,
+	Name:    "add_task_event_seq",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().CreateTable(&taskEventSequence0016{}); err != nil {
+			return err
+		}
+		if err := tx.Migrator().AddColumn(&taskEvent0016{}, "Seq"); err != nil {
+			return err
+		}
+		if err := tx.Migrator().CreateIndex(&taskEvent0016{}, "idx_task_events_workspace_seq"); err != nil {
+			return err
+		}
+
+		// 既有的事件都已提交，依 id 的順序編號
+		var rows []struct {
+			ID          uint
+			WorkspaceID uint
+		}
+		if err := tx.Table("task_events").Select("id, workspace_id").Order("id").Scan(&rows).Error; err != nil {
+			return err
+		}
+		last := map[uint]uint{}
+		for _, row := range rows {
+			last[row.WorkspaceID]++
+			if err := tx.Exec("UPDATE task_events SET seq = ? WHERE id = ?", last[row.WorkspaceID], row.ID).Error; err != nil {
+				return err
+			}
+		}
+		for workspaceID, seq := range last {
+			if err := tx.Create(&taskEventSequence0016{WorkspaceID: workspaceID, Seq: seq}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&taskEvent0016{}, "idx_task_events_workspace_seq"); err != nil {
+			return err
+		}
+		// see 0005: gorm's sqlite DropColumn would lose the other indexes
+		if err := execAll(tx, []string{"ALTER TABLE task_events DROP COLUMN seq"}); err != nil {
+			return err
+		}
+		return tx.Migrator().DropTable(&taskEventSequence0016{})
+	},
+}
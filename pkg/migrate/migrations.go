@@ -0,0 +1,25 @@
+package migrate
+
+// All returns every migration shipped with the binary. Add new migrations as
+// NNNN_description.go files in this package and append them here; never edit
+// a migration that has already been released.
+func All() []Migration {
+	return []Migration{
+		createTasks,
+		createAPIKeys,
+		addAPIKeyRoles,
+		createWorkspaces,
+		addTaskVersion,
+		addTaskDeletedAt,
+		createTaskEvents,
+		convertTaskStatus,
+		addTaskPriorityRank,
+		addTaskParentID,
+		createTaskDependencies,
+		addTaskRecurrence,
+		addTaskReminders,
+		createWebhooks,
+		addTaskNextReminderAt,
+		addTaskEventSeq,
+	}
+}
@@ -0,0 +1,212 @@
+// Package rank generates fractional-index keys: strings that sort in
+// byte order and always leave room for another key between any two, so a
+// task can be moved by rewriting only its own key.
+//
+// Keys have an integer part, whose first character encodes its length
+// ("a0".."az", then "b00".., and "Zz".. below "a0"), followed by an optional
+// fraction that never ends in '0'. Appending increments the integer part, so
+// keys stay short when tasks are mostly added at the end.
+package rank
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// First is the key of the first item in an empty list.
+const First = "a0"
+
+// smallestInteger has no key before it and is never handed out.
+var smallestInteger = "A" + strings.Repeat("0", 26)
+
+// ErrInvalidKey is returned for keys not produced by this package.
+var ErrInvalidKey = errors.New("invalid rank")
+
+// Between returns a key that sorts after a and before b. An empty a or b
+// leaves that side unbounded, so Between("", "") is First.
+func Between(a, b string) (string, error) {
+	if a != "" {
+		if err := Validate(a); err != nil {
+			return "", err
+		}
+	}
+	if b != "" {
+		if err := Validate(b); err != nil {
+			return "", err
+		}
+	}
+	if a != "" && b != "" && a >= b {
+		return "", fmt.Errorf("rank %q is not before %q", a, b)
+	}
+
+	switch {
+	case a == "" && b == "":
+		return First, nil
+	case a == "":
+		ib := integerPart(b)
+		if ib == smallestInteger {
+			return ib + midpoint("", b[len(ib):]), nil
+		}
+		if ib < b {
+			return ib, nil
+		}
+		return decrement(ib)
+	case b == "":
+		ia := integerPart(a)
+		if next, err := increment(ia); err == nil {
+			return next, nil
+		}
+		return ia + midpoint(a[len(ia):], ""), nil
+	}
+
+	ia, ib := integerPart(a), integerPart(b)
+	if ia == ib {
+		return ia + midpoint(a[len(ia):], b[len(ib):]), nil
+	}
+	next, err := increment(ia)
+	if err != nil {
+		return "", err
+	}
+	if next < b {
+		return next, nil
+	}
+	return ia + midpoint(a[len(ia):], ""), nil
+}
+
+// Validate reports whether key is a well-formed rank.
+func Validate(key string) error {
+	if key == "" || key == smallestInteger {
+		return fmt.Errorf("%w %q", ErrInvalidKey, key)
+	}
+	n := integerLength(key[0])
+	if n == 0 || n > len(key) {
+		return fmt.Errorf("%w %q", ErrInvalidKey, key)
+	}
+	for i := 1; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return fmt.Errorf("%w %q", ErrInvalidKey, key)
+		}
+	}
+	if len(key) > n && key[len(key)-1] == '0' {
+		return fmt.Errorf("%w %q", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+// integerLength is the length of the integer part starting with head, or 0.
+func integerLength(head byte) int {
+	switch {
+	case head >= 'a' && head <= 'z':
+		return int(head-'a') + 2
+	case head >= 'A' && head <= 'Z':
+		return int('Z'-head) + 2
+	}
+	return 0
+}
+
+func integerPart(key string) string {
+	return key[:integerLength(key[0])]
+}
+
+func increment(x string) (string, error) {
+	head, digs := x[0], []byte(x[1:])
+	carry := true
+	for i := len(digs) - 1; carry && i >= 0; i-- {
+		d := strings.IndexByte(digits, digs[i]) + 1
+		if d == len(digits) {
+			digs[i] = '0'
+		} else {
+			digs[i] = digits[d]
+			carry = false
+		}
+	}
+	if !carry {
+		return string(head) + string(digs), nil
+	}
+	switch head {
+	case 'Z':
+		return "a0", nil
+	case 'z':
+		return "", errors.New("rank: no key after the largest integer")
+	}
+	head++
+	if head > 'a' {
+		digs = append(digs, '0')
+	} else {
+		digs = digs[:len(digs)-1]
+	}
+	return string(head) + string(digs), nil
+}
+
+func decrement(x string) (string, error) {
+	head, digs := x[0], []byte(x[1:])
+	borrow := true
+	for i := len(digs) - 1; borrow && i >= 0; i-- {
+		d := strings.IndexByte(digits, digs[i]) - 1
+		if d == -1 {
+			digs[i] = digits[len(digits)-1]
+		} else {
+			digs[i] = digits[d]
+			borrow = false
+		}
+	}
+	if !borrow {
+		return string(head) + string(digs), nil
+	}
+	switch head {
+	case 'a':
+		return "Z" + digits[len(digits)-1:], nil
+	case 'A':
+		return "", errors.New("rank: no key before the smallest integer")
+	}
+	head--
+	if head < 'Z' {
+		digs = append(digs, digits[len(digits)-1])
+	} else {
+		digs = digs[:len(digs)-1]
+	}
+	return string(head) + string(digs), nil
+}
+
+// midpoint returns a fraction between a and b; an empty b is unbounded.
+// Neither may end in '0', and a must sort before b.
+func midpoint(a, b string) string {
+	if b != "" {
+		// 共同前綴直接保留，從第一個不同的位數開始取中點
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(a[min(n, len(a)):], b[n:])
+		}
+	}
+
+	digitA := strings.IndexByte(digits, digitAt(a, 0))
+	digitB := len(digits)
+	if b != "" {
+		digitB = strings.IndexByte(digits, b[0])
+	}
+	if digitB-digitA > 1 {
+		return string(digits[(digitA+digitB+1)/2])
+	}
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(digits[digitA]) + midpoint(rest, "")
+}
+
+// digitAt treats a as padded with '0' on the right.
+func digitAt(a string, i int) byte {
+	if i < len(a) {
+		return a[i]
+	}
+	return '0'
+}
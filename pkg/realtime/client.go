@@ -0,0 +1,130 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"task-api/dto"
+
+	"github.com/gorilla/websocket"
+)
+
+// maxMessageBytes caps what a client may send in one message.
+const maxMessageBytes = 4 << 10
+
+var presenceStates = map[string]bool{"": true, "viewing": true, "editing": true}
+
+// Client is one WebSocket connection. send is written by the hub and read
+// by writePump; the maps are guarded by the hub's mutex, except allowed,
+// which only readPump touches.
+type Client struct {
+	hub    *Hub
+	conn   *websocket.Conn
+	user   string
+	access Access
+	send   chan []byte
+
+	closeCode int
+	closeText string
+
+	allowed       map[Topic]bool
+	subscriptions map[Topic]bool
+	presence      map[Topic]string
+}
+
+// readPump handles the client's requests until the connection fails, the
+// client closes it or it stays silent for two ping intervals.
+func (c *Client) readPump(ctx context.Context) {
+	wait := 2 * c.hub.cfg.PingInterval.Duration
+	c.conn.SetReadLimit(maxMessageBytes)
+	_ = c.conn.SetReadDeadline(time.Now().Add(wait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wait))
+	})
+	for {
+		_, raw, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = c.conn.SetReadDeadline(time.Now().Add(wait))
+		var request dto.RealtimeRequest
+		if err := json.Unmarshal(raw, &request); err != nil {
+			c.fail(0, 0, "invalid message: "+err.Error())
+			continue
+		}
+		c.handle(ctx, request)
+	}
+}
+
+func (c *Client) handle(ctx context.Context, request dto.RealtimeRequest) {
+	topic := Topic{WorkspaceID: request.WorkspaceID, TaskID: request.TaskID}
+	if topic.WorkspaceID == 0 {
+		c.fail(topic.WorkspaceID, topic.TaskID, "workspace_id is required")
+		return
+	}
+	switch request.Type {
+	case "subscribe", "unsubscribe", "presence":
+	default:
+		c.fail(topic.WorkspaceID, topic.TaskID, "unknown message type "+request.Type)
+		return
+	}
+	if request.Type == "presence" && (topic.TaskID == 0 || !presenceStates[request.State]) {
+		c.fail(topic.WorkspaceID, topic.TaskID, "presence needs a task_id and a state of viewing, editing or empty")
+		return
+	}
+	// 權限檢查的結果會快取，presence 頻繁更新時不必每次查資料庫
+	if !c.allowed[topic] {
+		if err := c.access(ctx, topic); err != nil {
+			c.fail(topic.WorkspaceID, topic.TaskID, err.Error())
+			return
+		}
+		c.allowed[topic] = true
+	}
+
+	switch request.Type {
+	case "subscribe":
+		c.hub.subscribe(c, topic)
+	case "unsubscribe":
+		c.hub.unsubscribe(c, topic)
+	case "presence":
+		c.hub.setPresence(c, topic, request.State)
+	}
+}
+
+// fail tells the client its request was refused; the connection stays open.
+func (c *Client) fail(workspaceID, taskID uint, message string) {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+	c.hub.reply(c, dto.RealtimeMessage{Type: "error", WorkspaceID: workspaceID, TaskID: taskID, Message: message})
+}
+
+// writePump sends the queued messages and a ping every interval. When the
+// hub closes send it says goodbye with the close code it was given.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(c.hub.cfg.PingInterval.Duration)
+	defer ticker.Stop()
+	defer c.conn.Close()
+	timeout := c.hub.cfg.WriteTimeout.Duration
+	for {
+		select {
+		case msg, ok := <-c.send:
+			if !ok {
+				_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(c.closeCode, c.closeText), deadline(timeout))
+				return
+			}
+			_ = c.conn.SetWriteDeadline(deadline(timeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, deadline(timeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func deadline(timeout time.Duration) time.Time {
+	return time.Now().Add(timeout)
+}
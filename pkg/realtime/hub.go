@@ -0,0 +1,253 @@
+// Package realtime is the in-process pub/sub hub behind the /realtime
+// WebSocket. Connections subscribe to workspaces or single tasks, receive
+// the changes the repository publishes after each commit and share
+// presence ("alice is editing task 42") with the other subscribers.
+//
+// The hub only sees the writes of its own instance and never blocks them: a
+// connection that cannot keep up is dropped once its send buffer is full,
+// and catches up through GET /tasks/stream with the id of the last change
+// it received.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/repository"
+
+	"github.com/gorilla/websocket"
+)
+
+// Topic is a workspace, or a single task of it when TaskID is set.
+type Topic struct {
+	WorkspaceID uint
+	TaskID      uint
+}
+
+// Access reports whether a connection may use topic; the error is sent to
+// the client.
+type Access func(ctx context.Context, topic Topic) error
+
+// Hub fans published changes and presence out to the subscribed clients.
+type Hub struct {
+	cfg     config.RealtimeConfig
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+	topics  map[Topic]map[*Client]struct{}
+	closed  bool
+}
+
+func NewHub(cfg config.RealtimeConfig) *Hub {
+	return &Hub{cfg: cfg, clients: map[*Client]struct{}{}, topics: map[Topic]map[*Client]struct{}{}}
+}
+
+// Publish sends each change to the subscribers of its workspace and task.
+// It implements repository.Publisher and never blocks; the changes of one
+// commit are queued together.
+func (h *Hub) Publish(changes []repository.TaskChange) {
+	msgs := make([][]byte, len(changes))
+	for i := range changes {
+		change := &changes[i]
+		msg, err := json.Marshal(dto.RealtimeMessage{
+			Type:        "change",
+			WorkspaceID: change.Event.WorkspaceID,
+			TaskID:      change.Event.TaskID,
+			Event:       change.Name,
+			Change:      &dto.TaskStreamEvent{TaskEventResponse: dto.NewTaskEventResponse(&change.Event), Task: dto.NewTaskResponse(&change.Task)},
+		})
+		if err != nil {
+			slog.Error("encoding realtime change failed", "event_id", change.Event.ID, "error", err)
+			continue
+		}
+		msgs[i] = msg
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, msg := range msgs {
+		if msg != nil {
+			h.broadcast(Topic{WorkspaceID: changes[i].Event.WorkspaceID, TaskID: changes[i].Event.TaskID}, msg, nil)
+		}
+	}
+}
+
+// Close disconnects every client and refuses new ones. It fits
+// Server.OnDrain.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for c := range h.clients {
+		h.remove(c, websocket.CloseGoingAway, "server shutting down")
+	}
+}
+
+// Serve runs conn until either side closes it. user names the connection
+// in presence; access guards every topic it asks for.
+func (h *Hub) Serve(ctx context.Context, conn *websocket.Conn, user string, access Access) {
+	c := &Client{
+		hub:           h,
+		conn:          conn,
+		user:          user,
+		access:        access,
+		send:          make(chan []byte, h.cfg.SendBuffer),
+		allowed:       map[Topic]bool{},
+		subscriptions: map[Topic]bool{},
+		presence:      map[Topic]string{},
+	}
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), deadline(h.cfg.WriteTimeout.Duration))
+		_ = conn.Close()
+		return
+	}
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		c.writePump()
+	}()
+	c.readPump(ctx)
+	h.mu.Lock()
+	h.remove(c, websocket.CloseNormalClosure, "")
+	h.mu.Unlock()
+	<-written
+}
+
+// subscribe adds c to topic and tells it who is there.
+func (h *Hub) subscribe(c *Client, topic Topic) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	if h.topics[topic] == nil {
+		h.topics[topic] = map[*Client]struct{}{}
+	}
+	h.topics[topic][c] = struct{}{}
+	c.subscriptions[topic] = true
+
+	var presence []dto.PresenceEntry
+	for other := range h.clients {
+		for t, state := range other.presence {
+			if other != c && t.WorkspaceID == topic.WorkspaceID && (topic.TaskID == 0 || t.TaskID == topic.TaskID) {
+				presence = append(presence, dto.PresenceEntry{TaskID: t.TaskID, User: other.user, State: state})
+			}
+		}
+	}
+	h.reply(c, dto.RealtimeMessage{Type: "subscribed", WorkspaceID: topic.WorkspaceID, TaskID: topic.TaskID, Presence: presence})
+}
+
+func (h *Hub) unsubscribe(c *Client, topic Topic) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	h.leave(c, topic)
+	h.reply(c, dto.RealtimeMessage{Type: "unsubscribed", WorkspaceID: topic.WorkspaceID, TaskID: topic.TaskID})
+}
+
+// setPresence records what c is doing on a task topic, "" for nothing, and
+// tells the other subscribers of the task and its workspace.
+func (h *Hub) setPresence(c *Client, topic Topic, state string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; !ok || c.presence[topic] == state {
+		return
+	}
+	if state == "" {
+		delete(c.presence, topic)
+	} else {
+		c.presence[topic] = state
+	}
+	h.announce(c, topic, state)
+}
+
+// reply queues msg for c alone. The caller holds mu.
+func (h *Hub) reply(c *Client, msg dto.RealtimeMessage) {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("encoding realtime message failed", "error", err)
+		return
+	}
+	h.enqueue(c, raw)
+}
+
+// announce broadcasts the presence of c on topic. The caller holds mu.
+func (h *Hub) announce(c *Client, topic Topic, state string) {
+	raw, err := json.Marshal(dto.RealtimeMessage{Type: "presence", WorkspaceID: topic.WorkspaceID, TaskID: topic.TaskID, User: c.user, State: state})
+	if err != nil {
+		slog.Error("encoding realtime presence failed", "error", err)
+		return
+	}
+	h.broadcast(topic, raw, c)
+}
+
+// broadcast queues msg once for every subscriber of topic or of its
+// workspace, except skip. The caller holds mu.
+func (h *Hub) broadcast(topic Topic, msg []byte, skip *Client) {
+	targets := map[*Client]struct{}{}
+	for c := range h.topics[Topic{WorkspaceID: topic.WorkspaceID}] {
+		targets[c] = struct{}{}
+	}
+	if topic.TaskID != 0 {
+		for c := range h.topics[topic] {
+			targets[c] = struct{}{}
+		}
+	}
+	delete(targets, skip)
+	for c := range targets {
+		h.enqueue(c, msg)
+	}
+}
+
+// enqueue hands msg to the writer of c, dropping c if its buffer is full:
+// one slow reader must not hold up the write path. The caller holds mu.
+func (h *Hub) enqueue(c *Client, msg []byte) {
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	select {
+	case c.send <- msg:
+	default:
+		slog.Warn("dropping slow realtime client", "user", c.user, "buffered", len(c.send))
+		h.remove(c, websocket.CloseTryAgainLater, "client too slow")
+	}
+}
+
+// leave drops c from topic. The caller holds mu.
+func (h *Hub) leave(c *Client, topic Topic) {
+	delete(c.subscriptions, topic)
+	if subscribers := h.topics[topic]; subscribers != nil {
+		delete(subscribers, c)
+		if len(subscribers) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// remove unregisters c, clears its presence and makes its writer close the
+// connection with code and text. The caller holds mu.
+func (h *Hub) remove(c *Client, code int, text string) {
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	delete(h.clients, c)
+	for topic := range c.subscriptions {
+		h.leave(c, topic)
+	}
+	c.closeCode, c.closeText = code, text
+	close(c.send)
+	for topic := range c.presence {
+		delete(c.presence, topic)
+		h.announce(c, topic, "")
+	}
+}
@@ -0,0 +1,68 @@
+// Package recurrence computes the occurrences of recurring tasks from RFC
+// 5545 RRULE values such as "FREQ=WEEKLY;BYDAY=MO". A rule has no DTSTART
+// of its own: it is anchored on the due date of the current occurrence, so
+// unless BYHOUR and friends say otherwise the time of day is kept.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+// ErrInvalidRule is returned for values that are not a usable RRULE.
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+type Rule struct {
+	option rrule.ROption
+}
+
+// Parse parses an RRULE value, with or without the "RRULE:" prefix.
+func Parse(value string) (Rule, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "\n") {
+		return Rule{}, fmt.Errorf("%w: expected a single RRULE line", ErrInvalidRule)
+	}
+	option, err := rrule.StrToROption(value)
+	if err != nil {
+		return Rule{}, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	if !option.Dtstart.IsZero() {
+		return Rule{}, fmt.Errorf("%w: DTSTART is taken from the due date", ErrInvalidRule)
+	}
+	if option.Count < 0 || option.Interval < 0 {
+		return Rule{}, fmt.Errorf("%w: COUNT and INTERVAL must be positive", ErrInvalidRule)
+	}
+	if _, err := rrule.NewRRule(*option); err != nil {
+		return Rule{}, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	return Rule{option: *option}, nil
+}
+
+// String is the rule in normalised form, without the "RRULE:" prefix.
+func (r Rule) String() string {
+	return r.option.RRuleString()
+}
+
+// Count is the maximum number of occurrences, or 0 if the rule has no COUNT.
+func (r Rule) Count() int {
+	return r.option.Count
+}
+
+// Next returns the first occurrence strictly after after, for a series with
+// an occurrence at start. COUNT is left to the caller, which knows how many
+// occurrences there already were; ok is false once UNTIL has passed.
+func (r Rule) Next(start, after time.Time) (next time.Time, ok bool) {
+	option := r.option
+	option.Dtstart = start
+	option.Count = 0
+	rule, err := rrule.NewRRule(option)
+	if err != nil {
+		return time.Time{}, false
+	}
+	next = rule.After(after, false)
+	return next, !next.IsZero()
+}
@@ -0,0 +1,114 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"task-api/config"
+	"task-api/model"
+	"task-api/repository"
+)
+
+// Notification is what a Notifier delivers; webhooks receive it as JSON.
+type Notification struct {
+	Kind    string     `json:"kind"`             // reminder or overdue
+	Offset  string     `json:"offset,omitempty"` // how long before the due date, for reminders
+	DueDate time.Time  `json:"due_date"`
+	Task    model.Task `json:"task"`
+}
+
+func NewNotification(reminder *repository.Reminder) Notification {
+	return Notification{Kind: reminder.Kind, Offset: reminder.Offset, DueDate: reminder.DueDate, Task: reminder.Task}
+}
+
+// Subject is a one-line summary, e.g. `Task 3 "write a blog" is due in 1h`.
+func (n Notification) Subject() string {
+	if n.Kind == model.ReminderOverdue {
+		return fmt.Sprintf("Task %d %q is overdue", n.Task.ID, n.Task.Name)
+	}
+	return fmt.Sprintf("Task %d %q is due in %s", n.Task.ID, n.Task.Name, n.Offset)
+}
+
+// Notifier delivers notifications; an error makes the scheduler retry later.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NewNotifier returns the notifier selected by cfg.Notifier, which Validate
+// has checked.
+func NewNotifier(cfg config.RemindersConfig) Notifier {
+	switch cfg.Notifier {
+	case "webhook":
+		return &WebhookNotifier{URL: cfg.WebhookURL, Client: &http.Client{Timeout: 10 * time.Second}}
+	case "smtp":
+		return &SMTPNotifier{Addr: cfg.SMTPAddr, From: cfg.SMTPFrom, To: cfg.SMTPTo}
+	default:
+		return LogNotifier{}
+	}
+}
+
+// LogNotifier writes notifications to the log.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, n Notification) error {
+	slog.Info(n.Subject(), "kind", n.Kind, "task_id", n.Task.ID, "workspace_id", n.Task.WorkspaceID,
+		"assignee", n.Task.Assignee, "due_date", n.DueDate)
+	return nil
+}
+
+// WebhookNotifier POSTs notifications as JSON to URL and expects a 2xx.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s answered %s", w.URL, resp.Status)
+	}
+	return nil
+}
+
+// SMTPNotifier mails notifications to To through an SMTP server at Addr that
+// needs no authentication.
+type SMTPNotifier struct {
+	Addr string
+	From string
+	To   []string
+}
+
+func (s *SMTPNotifier) Notify(ctx context.Context, n Notification) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", n.Subject())
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s.\r\n\r\n", n.Subject())
+	fmt.Fprintf(&msg, "Workspace: %d\r\n", n.Task.WorkspaceID)
+	fmt.Fprintf(&msg, "Due: %s\r\n", n.DueDate.Format(time.RFC3339))
+	if n.Task.Assignee != "" {
+		fmt.Fprintf(&msg, "Assignee: %s\r\n", n.Task.Assignee)
+	}
+	return smtp.SendMail(s.Addr, nil, s.From, s.To, []byte(msg.String()))
+}
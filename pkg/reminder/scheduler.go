@@ -0,0 +1,90 @@
+// Package reminder acts on task due dates: a Scheduler periodically reminds
+// of tasks whose due date is approaching, at the offsets set on each task,
+// and marks tasks overdue once it has passed, handing every notification to
+// a Notifier.
+//
+// Each notification is claimed in the database before it is sent, so it goes
+// out at most once per due date even across restarts or with several
+// instances. A crash between claiming and sending loses that notification
+// rather than risk sending it twice; a Notifier error releases the claim and
+// the next run tries again.
+package reminder
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"task-api/config"
+	"task-api/repository"
+)
+
+// Store claims the reminders that are due and records their delivery.
+type Store interface {
+	ClaimReminders(ctx context.Context, now time.Time) ([]repository.Reminder, error)
+	MarkReminderSent(ctx context.Context, id uint, sentAt time.Time) error
+	ReleaseReminder(ctx context.Context, id uint) error
+}
+
+// Scheduler sends the due reminders every Interval.
+type Scheduler struct {
+	store    Store
+	notifier Notifier
+	cfg      config.RemindersConfig
+	done     chan struct{}
+}
+
+func NewScheduler(store Store, notifier Notifier, cfg config.RemindersConfig) *Scheduler {
+	return &Scheduler{store: store, notifier: notifier, cfg: cfg, done: make(chan struct{})}
+}
+
+// Start runs once and then on every interval until ctx is cancelled. It does
+// nothing when the interval is 0.
+func (s *Scheduler) Start(ctx context.Context) {
+	if s.cfg.Interval.Duration <= 0 {
+		close(s.done)
+		return
+	}
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.cfg.Interval.Duration)
+		defer ticker.Stop()
+		for {
+			s.Run(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Wait blocks until the goroutine started by Start has returned. It fits
+// Server.OnShutdown.
+func (s *Scheduler) Wait() error {
+	<-s.done
+	return nil
+}
+
+// Run sends the reminders that are due at now.
+func (s *Scheduler) Run(ctx context.Context, now time.Time) {
+	reminders, err := s.store.ClaimReminders(ctx, now)
+	if err != nil && ctx.Err() == nil {
+		slog.Error("claiming reminders failed", "error", err)
+	}
+	// 即使認領途中出錯，已認領的仍要送出
+	for i := range reminders {
+		reminder := &reminders[i]
+		if err := s.notifier.Notify(ctx, NewNotification(reminder)); err != nil {
+			slog.Error("sending reminder failed", "task_id", reminder.TaskID, "kind", reminder.Kind, "error", err)
+			if err := s.store.ReleaseReminder(context.WithoutCancel(ctx), reminder.ID); err != nil {
+				slog.Error("releasing reminder failed", "reminder_id", reminder.ID, "error", err)
+			}
+			continue
+		}
+		if err := s.store.MarkReminderSent(context.WithoutCancel(ctx), reminder.ID, time.Now()); err != nil {
+			slog.Error("recording sent reminder failed", "reminder_id", reminder.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"task-api/config"
+	"task-api/pkg/health"
+)
+
+// Server wraps http.Server with graceful shutdown: when the run context is
+// cancelled it marks the process as draining, waits DrainDelay, lets
+// in-flight requests finish within ShutdownTimeout and then runs the
+// registered closers (e.g. the database pool).
+type Server struct {
+	cfg       config.ServerConfig
+	http      *http.Server
+	readiness *health.Readiness
+	closers   []func() error
+	drains    []func()
+}
+
+func New(cfg config.ServerConfig, handler http.Handler, readiness *health.Readiness) *Server {
+	return &Server{
+		cfg:       cfg,
+		readiness: readiness,
+		http: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout.Duration,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout.Duration,
+			WriteTimeout:      cfg.WriteTimeout.Duration,
+			IdleTimeout:       cfg.IdleTimeout.Duration,
+		},
+	}
+}
+
+// OnShutdown registers fn to run after the HTTP server has stopped, in
+// registration order.
+func (s *Server) OnShutdown(fn func() error) {
+	s.closers = append(s.closers, fn)
+}
+
+// OnDrain registers fn to run when shutdown begins, before in-flight
+// requests are awaited; long-lived responses such as event streams use it
+// to end, since they would otherwise hold shutdown until its deadline.
+func (s *Server) OnDrain(fn func()) {
+	s.drains = append(s.drains, fn)
+}
+
+// Run listens on the configured address and serves until ctx is cancelled.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves on ln until ctx is cancelled, then shuts down gracefully.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.http.Serve(ln)
+	}()
+	log.Printf("listening on %s", ln.Addr())
+
+	select {
+	case err := <-serveErr:
+		// The listener failed before shutdown was requested.
+		return errors.Join(err, s.close())
+	case <-ctx.Done():
+	}
+
+	log.Printf("shutting down, draining in-flight requests")
+	s.readiness.SetDraining()
+	if s.cfg.DrainDelay.Duration > 0 {
+		time.Sleep(s.cfg.DrainDelay.Duration)
+	}
+
+	for _, fn := range s.drains {
+		fn()
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout.Duration)
+	defer cancel()
+	err := s.http.Shutdown(shutdownCtx)
+	if err != nil {
+		// Deadline exceeded: drop whatever is still running.
+		err = errors.Join(err, s.http.Close())
+	}
+	if serr := <-serveErr; !errors.Is(serr, http.ErrServerClosed) {
+		err = errors.Join(err, serr)
+	}
+	return errors.Join(err, s.close())
+}
+
+func (s *Server) close() error {
+	var errs []error
+	for _, fn := range s.closers {
+		errs = append(errs, fn())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,16 @@
+// Package tenant carries the workspace resolved for a request.
+package tenant
+
+import "context"
+
+type ctxKey struct{}
+
+func NewContext(ctx context.Context, workspaceID uint) context.Context {
+	return context.WithValue(ctx, ctxKey{}, workspaceID)
+}
+
+// FromContext returns the request's workspace id, or 0 outside a workspace route.
+func FromContext(ctx context.Context) uint {
+	id, _ := ctx.Value(ctxKey{}).(uint)
+	return id
+}
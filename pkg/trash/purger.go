@@ -0,0 +1,69 @@
+package trash
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"task-api/config"
+)
+
+// Store permanently removes tasks deleted before cutoff.
+type Store interface {
+	PurgeDeletedTasks(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// Purger empties the trash in the background: every PurgeInterval it removes
+// the tasks that were deleted more than Retention ago.
+type Purger struct {
+	store Store
+	cfg   config.TrashConfig
+	done  chan struct{}
+}
+
+func NewPurger(store Store, cfg config.TrashConfig) *Purger {
+	return &Purger{store: store, cfg: cfg, done: make(chan struct{})}
+}
+
+// Start purges once and then on every interval until ctx is cancelled. It
+// does nothing when retention is 0.
+func (p *Purger) Start(ctx context.Context) {
+	if p.cfg.Retention.Duration <= 0 {
+		close(p.done)
+		return
+	}
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.cfg.PurgeInterval.Duration)
+		defer ticker.Stop()
+		for {
+			p.Purge(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Wait blocks until the goroutine started by Start has returned, so the
+// database is not closed under a running purge. It fits Server.OnShutdown.
+func (p *Purger) Wait() error {
+	<-p.done
+	return nil
+}
+
+// Purge removes the tasks that expired at now and logs how many.
+func (p *Purger) Purge(ctx context.Context, now time.Time) {
+	n, err := p.store.PurgeDeletedTasks(ctx, now.Add(-p.cfg.Retention.Duration))
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("purging trash failed", "error", err)
+		}
+		return
+	}
+	if n > 0 {
+		slog.Info("purged deleted tasks", "count", n, "retention", p.cfg.Retention.String())
+	}
+}
@@ -0,0 +1,201 @@
+// Package webhook delivers the task events queued for webhooks. Deliveries
+// are written to the database in the transaction of the change they
+// announce; a Dispatcher polls that queue, POSTs each payload signed with
+// the webhook's secret and retries failures with exponential backoff.
+//
+// Delivery is at least once: a receiver may see a delivery again, e.g. when
+// it answered too late, and should deduplicate on X-Webhook-Delivery.
+// Retries can also overtake later deliveries, so receivers that care about
+// order should compare task.version.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/model"
+	"task-api/repository"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// batchSize caps the deliveries attempted per run.
+const batchSize = 100
+
+// Store hands out the deliveries that are due and records their outcome.
+type Store interface {
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]repository.PendingDelivery, error)
+	SaveWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+}
+
+// Dispatcher sends the queued deliveries every Interval.
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+	cfg    config.WebhooksConfig
+	now    func() time.Time
+	done   chan struct{}
+}
+
+func NewDispatcher(store Store, cfg config.WebhooksConfig) *Dispatcher {
+	return &Dispatcher{store: store, client: &http.Client{Timeout: cfg.Timeout.Duration}, cfg: cfg, now: time.Now, done: make(chan struct{})}
+}
+
+// WithClock makes the dispatcher read the time from now: which deliveries
+// are due, when they were delivered and when to retry them.
+func (d *Dispatcher) WithClock(now func() time.Time) *Dispatcher {
+	d.now = now
+	return d
+}
+
+// Start runs once and then on every interval until ctx is cancelled. It does
+// nothing when the interval is 0.
+func (d *Dispatcher) Start(ctx context.Context) {
+	if d.cfg.Interval.Duration <= 0 {
+		close(d.done)
+		return
+	}
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(d.cfg.Interval.Duration)
+		defer ticker.Stop()
+		for {
+			d.Run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Wait blocks until the goroutine started by Start has returned. It fits
+// Server.OnShutdown.
+func (d *Dispatcher) Wait() error {
+	<-d.done
+	return nil
+}
+
+// Run attempts up to batchSize deliveries that are due. It claims them one
+// at a time, right before sending, so a lease only has to cover one attempt
+// however slow the deliveries before it were.
+func (d *Dispatcher) Run(ctx context.Context) {
+	// 租約要比一次嘗試長，否則慢的接收端可能被重複送出
+	lease := 2 * d.cfg.Timeout.Duration
+	for i := 0; i < batchSize && ctx.Err() == nil; i++ {
+		deliveries, err := d.store.ClaimWebhookDeliveries(ctx, d.now(), lease, 1)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("claiming webhook deliveries failed", "error", err)
+			}
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+		delivery := &deliveries[0]
+		d.attempt(ctx, delivery)
+		if err := d.store.SaveWebhookDelivery(context.WithoutCancel(ctx), &delivery.WebhookDelivery); err != nil {
+			slog.Error("recording webhook delivery failed", "delivery_id", delivery.ID, "error", err)
+		}
+	}
+}
+
+// attempt sends delivery and updates it with the outcome.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *repository.PendingDelivery) {
+	status, err := d.send(ctx, delivery)
+	delivery.ResponseStatus = status
+	now := d.now().UTC()
+	if err == nil {
+		delivered := now
+		delivery.Status = model.DeliveryDelivered
+		delivery.DeliveredAt = &delivered
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = truncate(err.Error(), 1024)
+	if delivery.Attempts >= d.cfg.MaxAttempts {
+		delivery.Status = model.DeliveryFailed
+		slog.Warn("webhook delivery failed", "delivery_id", delivery.ID, "webhook_id", delivery.WebhookID, "attempts", delivery.Attempts, "error", err)
+		return
+	}
+	delivery.NextAttemptAt = now.Add(Backoff(d.cfg.Backoff.Duration, delivery.Attempts))
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *repository.PendingDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "task-api-webhooks")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, body))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Encode renders the dto.WebhookPayload of change. It is the
+// repository.WebhookEncoder of the repository that queues the deliveries.
+func Encode(change *repository.TaskChange) ([]byte, error) {
+	return json.Marshal(dto.WebhookPayload{
+		Event:      change.Name,
+		OccurredAt: change.Event.CreatedAt,
+		Actor:      change.Event.Actor,
+		Task:       dto.NewTaskResponse(&change.Task),
+		Changes:    change.Event.Changes,
+	})
+}
+
+// Sign is the X-Webhook-Signature of body: "sha256=" and the hex HMAC-SHA256
+// of the exact body bytes under secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff is the delay after the given number of failed attempts: base,
+// then twice as long after every further failure.
+func Backoff(base time.Duration, attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return base << (attempts - 1)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,65 @@
+// Package workflow defines which task status changes are allowed.
+package workflow
+
+import (
+	"errors"
+	"fmt"
+
+	"task-api/model"
+)
+
+// Workflow maps each status to the statuses a task may move to from it.
+// Staying in the same status is always allowed, and closed tasks can always
+// be reopened to todo regardless of the graph.
+type Workflow map[model.Status][]model.Status
+
+// Default moves work forward through review; done and cancelled are final
+// until the task is reopened.
+func Default() Workflow {
+	return Workflow{
+		model.StatusTodo:       {model.StatusInProgress, model.StatusBlocked, model.StatusDone, model.StatusCancelled},
+		model.StatusInProgress: {model.StatusTodo, model.StatusBlocked, model.StatusInReview, model.StatusDone, model.StatusCancelled},
+		model.StatusBlocked:    {model.StatusTodo, model.StatusInProgress, model.StatusCancelled},
+		model.StatusInReview:   {model.StatusInProgress, model.StatusDone, model.StatusCancelled},
+		model.StatusDone:       {},
+		model.StatusCancelled:  {},
+	}
+}
+
+// Parse builds a workflow from configuration; nil or empty means Default.
+func Parse(transitions map[string][]string) (Workflow, error) {
+	if len(transitions) == 0 {
+		return Default(), nil
+	}
+	w := Workflow{}
+	var errs []error
+	for from, targets := range transitions {
+		status := model.Status(from)
+		if !status.Valid() {
+			errs = append(errs, fmt.Errorf("unknown status %q", from))
+			continue
+		}
+		w[status] = []model.Status{}
+		for _, to := range targets {
+			if !model.Status(to).Valid() {
+				errs = append(errs, fmt.Errorf("unknown status %q in transitions from %s", to, from))
+				continue
+			}
+			w[status] = append(w[status], model.Status(to))
+		}
+	}
+	return w, errors.Join(errs...)
+}
+
+// Allows reports whether a task may move from one status to another.
+func (w Workflow) Allows(from, to model.Status) bool {
+	if from == to {
+		return true
+	}
+	for _, target := range w[from] {
+		if target == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"task-api/model"
+
+	"gorm.io/gorm"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) (*model.APIKey, error) {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return nil, dbError(ctx, "CreateAPIKey", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns every key, revoked ones included, oldest first.
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	if err := r.db.WithContext(ctx).Order("id").Find(&keys).Error; err != nil {
+		return nil, dbError(ctx, "ListAPIKeys", err)
+	}
+	return keys, nil
+}
+
+// FindAPIKeyByHash returns the active (not revoked) key with the given hash.
+func (r *APIKeyRepository) FindAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ? AND revoked_at IS NULL", hash).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, dbError(ctx, "FindAPIKeyByHash", err)
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey marks an active key as revoked; it stays listed for auditing.
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		return dbError(ctx, "RevokeAPIKey", result.Error, "api_key_id", id)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
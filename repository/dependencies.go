@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"task-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInvalidDependency is returned when the blocker is missing or is the
+	// blocked task itself; the wrapped message says which.
+	ErrInvalidDependency = errors.New("invalid dependency")
+	// ErrDependencyExists is returned when adding a dependency twice.
+	ErrDependencyExists = errors.New("dependency already exists")
+	// ErrDependencyNotFound is returned when removing a dependency that does not exist.
+	ErrDependencyNotFound = errors.New("dependency not found")
+	// ErrDependencyCycle is returned when a new dependency would close a
+	// cycle; the wrapped message lists the tasks on it.
+	ErrDependencyCycle = errors.New("dependency cycle")
+	// ErrOpenBlockers is returned when completing a task that is blocked by
+	// open tasks; the wrapped message lists them.
+	ErrOpenBlockers = errors.New("task is blocked by open tasks")
+)
+
+// TaskGraph is a task with every task that transitively blocks it or is
+// blocked by it, and the dependencies between them.
+type TaskGraph struct {
+	Tasks []model.Task
+	Edges []model.TaskDependency
+	// Order lists the task IDs so that every blocker comes before the tasks
+	// it blocks; among tasks that are free at the same point, the more
+	// urgent and then the higher ranked goes first.
+	Order []uint
+}
+
+// AddDependency records that blockerID blocks task id. The blocker may be
+// any task of the workspace; the blocked task must be in scope.
+func (r *TaskRepository) AddDependency(ctx context.Context, scope Scope, id uint, blockerID uint) (*model.TaskDependency, error) {
+	dependency := &model.TaskDependency{WorkspaceID: scope.WorkspaceID, BlockerID: blockerID, BlockedID: id}
+	err := r.inTx(ctx, func(tx *TaskRepository) error {
+		if err := tx.lockDependencies(ctx, scope); err != nil {
+			return err
+		}
+		blocked, err := tx.lockTask(ctx, scope, id)
+		if err != nil {
+			return err
+		}
+		if err := checkTask(blocked, scope, AnyVersion); err != nil {
+			return err
+		}
+		if blockerID == id {
+			return fmt.Errorf("%w: a task cannot block itself", ErrInvalidDependency)
+		}
+		if _, err := tx.GetTaskByID(ctx, Scope{WorkspaceID: scope.WorkspaceID}, blockerID); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("%w: task %d not found", ErrInvalidDependency, blockerID)
+			}
+			return err
+		}
+
+		var count int64
+		if err := tx.dependencies(ctx, scope).Where("blocker_id = ? AND blocked_id = ?", blockerID, id).Count(&count).Error; err != nil {
+			return dbError(ctx, "AddDependency", err, "task_id", id)
+		}
+		if count > 0 {
+			return ErrDependencyExists
+		}
+		// 新的 blocker → id 會形成循環，若 id 已經（間接）擋住 blocker
+		path, err := tx.dependencyPath(ctx, scope, id, blockerID)
+		if err != nil {
+			return err
+		}
+		if path != nil {
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, formatIDs(append([]uint{blockerID}, path...), " blocks "))
+		}
+
+		if err := tx.db.WithContext(ctx).Create(dependency).Error; err != nil {
+			return dbError(ctx, "AddDependency", err, "task_id", id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dependency, nil
+}
+
+// RemoveDependency deletes the dependency of task id on blockerID.
+func (r *TaskRepository) RemoveDependency(ctx context.Context, scope Scope, id uint, blockerID uint) error {
+	blocked, err := r.GetTaskByID(ctx, scope, id)
+	if err != nil {
+		return err
+	}
+	if err := checkTask(blocked, scope, AnyVersion); err != nil {
+		return err
+	}
+	result := r.dependencies(ctx, scope).Where("blocker_id = ? AND blocked_id = ?", blockerID, id).Delete(&model.TaskDependency{})
+	if result.Error != nil {
+		return dbError(ctx, "RemoveDependency", result.Error, "task_id", id)
+	}
+	if result.RowsAffected == 0 {
+		return ErrDependencyNotFound
+	}
+	return nil
+}
+
+// GetTaskGraph returns the dependency graph around task id. Tasks in the
+// trash are left out, together with their dependencies.
+func (r *TaskRepository) GetTaskGraph(ctx context.Context, scope Scope, id uint) (*TaskGraph, error) {
+	if _, err := r.GetTaskByID(ctx, scope, id); err != nil {
+		return nil, err
+	}
+
+	// 往上找所有 blocker、往下找所有被擋住的 task，各自逐層展開
+	seen := map[uint]bool{id: true}
+	edgeSeen := map[uint]bool{}
+	var edges []model.TaskDependency
+	for _, dir := range []struct{ from, to string }{{"blocked_id", "blocker_id"}, {"blocker_id", "blocked_id"}} {
+		for level := []uint{id}; len(level) > 0; {
+			found, err := r.liveDependencies(ctx, scope, dir.from, dir.to, level)
+			if err != nil {
+				return nil, err
+			}
+			level = nil
+			for _, edge := range found {
+				if edgeSeen[edge.ID] {
+					continue
+				}
+				edgeSeen[edge.ID] = true
+				edges = append(edges, edge)
+				next := edge.BlockerID
+				if dir.to == "blocked_id" {
+					next = edge.BlockedID
+				}
+				if !seen[next] {
+					seen[next] = true
+					level = append(level, next)
+				}
+			}
+		}
+	}
+
+	ids := make([]uint, 0, len(seen))
+	for taskID := range seen {
+		ids = append(ids, taskID)
+	}
+	var tasks []model.Task
+	if err := scope.tenant(r.db.WithContext(ctx)).Where("id IN ?", ids).Order("id").Find(&tasks).Error; err != nil {
+		return nil, dbError(ctx, "GetTaskGraph", err, "task_id", id)
+	}
+	return &TaskGraph{Tasks: tasks, Edges: edges, Order: topologicalOrder(tasks, edges)}, nil
+}
+
+// topologicalOrder sorts tasks so that blockers come first (Kahn's
+// algorithm), preferring priority, then rank, among the tasks that are ready.
+func topologicalOrder(tasks []model.Task, edges []model.TaskDependency) []uint {
+	byID := make(map[uint]*model.Task, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+	blocking := make(map[uint]int, len(tasks))
+	blocks := make(map[uint][]uint, len(tasks))
+	for _, edge := range edges {
+		blocking[edge.BlockedID]++
+		blocks[edge.BlockerID] = append(blocks[edge.BlockerID], edge.BlockedID)
+	}
+	var ready []*model.Task
+	for i := range tasks {
+		if blocking[tasks[i].ID] == 0 {
+			ready = append(ready, &tasks[i])
+		}
+	}
+
+	order := make([]uint, 0, len(tasks))
+	for len(ready) > 0 {
+		next := 0
+		for i, task := range ready {
+			if plansBefore(task, ready[next]) {
+				next = i
+			}
+		}
+		task := ready[next]
+		ready = append(ready[:next], ready[next+1:]...)
+		order = append(order, task.ID)
+		for _, blocked := range blocks[task.ID] {
+			if blocking[blocked]--; blocking[blocked] == 0 {
+				ready = append(ready, byID[blocked])
+			}
+		}
+	}
+	return order
+}
+
+func plansBefore(a, b *model.Task) bool {
+	if a.Priority != b.Priority {
+		return a.Priority < b.Priority
+	}
+	if a.Rank != b.Rank {
+		return a.Rank < b.Rank
+	}
+	return a.ID < b.ID
+}
+
+// openBlockers lists the IDs of the tasks that block id and are neither
+// done nor cancelled.
+func (r *TaskRepository) openBlockers(ctx context.Context, scope Scope, id uint) ([]uint, error) {
+	blockers := r.dependencies(ctx, scope).Select("blocker_id").Where("blocked_id = ?", id)
+	var ids []uint
+	err := scope.tenant(r.db.WithContext(ctx).Model(&model.Task{})).
+		Where("id IN (?) AND status NOT IN ?", blockers, []model.Status{model.StatusDone, model.StatusCancelled}).
+		Order("id").Pluck("id", &ids).Error
+	if err != nil {
+		return nil, dbError(ctx, "openBlockers", err, "task_id", id)
+	}
+	return ids, nil
+}
+
+// dependencyPath finds a chain of dependencies from task from to task to,
+// returned as the task IDs along it, or nil if to is not reachable.
+// Dependencies of tasks in the trash count, as the tasks may be restored.
+func (r *TaskRepository) dependencyPath(ctx context.Context, scope Scope, from, to uint) ([]uint, error) {
+	via := map[uint]uint{from: 0}
+	for level := []uint{from}; len(level) > 0; {
+		var edges []model.TaskDependency
+		if err := r.dependencies(ctx, scope).Where("blocker_id IN ?", level).Order("id").Find(&edges).Error; err != nil {
+			return nil, dbError(ctx, "dependencyPath", err)
+		}
+		level = nil
+		for _, edge := range edges {
+			if _, ok := via[edge.BlockedID]; ok {
+				continue
+			}
+			via[edge.BlockedID] = edge.BlockerID
+			if edge.BlockedID == to {
+				path := []uint{to}
+				for step := to; step != from; {
+					step = via[step]
+					path = append([]uint{step}, path...)
+				}
+				return path, nil
+			}
+			level = append(level, edge.BlockedID)
+		}
+	}
+	return nil, nil
+}
+
+// liveDependencies lists the dependencies whose from column is one of ids
+// and whose to column is a task that is not in the trash.
+func (r *TaskRepository) liveDependencies(ctx context.Context, scope Scope, from, to string, ids []uint) ([]model.TaskDependency, error) {
+	var edges []model.TaskDependency
+	err := r.db.WithContext(ctx).Model(&model.TaskDependency{}).
+		Joins("JOIN tasks ON tasks.id = task_dependencies."+to+" AND tasks.deleted_at IS NULL").
+		Where("task_dependencies.workspace_id = ? AND task_dependencies."+from+" IN ?", scope.WorkspaceID, ids).
+		Order("task_dependencies.id").Find(&edges).Error
+	if err != nil {
+		return nil, dbError(ctx, "liveDependencies", err)
+	}
+	return edges, nil
+}
+
+func (r *TaskRepository) dependencies(ctx context.Context, scope Scope) *gorm.DB {
+	return scope.tenant(r.db.WithContext(ctx).Model(&model.TaskDependency{}))
+}
+
+// lockDependencies serialises dependency changes within a workspace, so two
+// concurrent additions cannot each close half of a cycle.
+func (r *TaskRepository) lockDependencies(ctx context.Context, scope Scope) error {
+	// sqlite 同時只允許一個寫入者，不需要另外上鎖
+	if r.db.Dialector.Name() == "sqlite" {
+		return nil
+	}
+	var workspace model.Workspace
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", scope.WorkspaceID).Take(&workspace).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dbError(ctx, "lockDependencies", err)
+	}
+	return nil
+}
+
+func formatIDs(ids []uint, sep string) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	return strings.Join(parts, sep)
+}
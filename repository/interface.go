@@ -1,11 +1,51 @@
 package repository
 
-import "task-api/model"
+import (
+	"context"
+
+	"task-api/model"
+)
 
 type RepositoryInterface interface {
-	CreateTask(task *model.Task) (*model.Task, error)
-	GetTaskByID(id uint) (*model.Task, error)
-	GetAllTasks() ([]model.Task, error)
-	UpdateTask(fields map[string]interface{}, id uint) error
-	DeleteTask(id uint) (bool, error)
+	CreateTask(ctx context.Context, scope Scope, task *model.Task) (*model.Task, error)
+	GetTaskByID(ctx context.Context, scope Scope, id uint) (*model.Task, error)
+	ListTasks(ctx context.Context, scope Scope, query TaskQuery) (*TaskPage, error)
+	UpdateTask(ctx context.Context, scope Scope, fields map[string]interface{}, id uint, version uint) (*model.Task, error)
+	DeleteTask(ctx context.Context, scope Scope, id uint, version uint) error
+	RestoreTask(ctx context.Context, scope Scope, id uint) (*model.Task, error)
+	ReopenTask(ctx context.Context, scope Scope, id uint, version uint) (*model.Task, error)
+	MoveTask(ctx context.Context, scope Scope, id uint, move Move, version uint) (*model.Task, error)
+	UpdateSeries(ctx context.Context, scope Scope, fields map[string]interface{}, id uint) ([]model.Task, error)
+	GetTaskTree(ctx context.Context, scope Scope, id uint, depth int) (*TaskNode, error)
+	TaskProgress(ctx context.Context, scope Scope, ids []uint) (map[uint]Progress, error)
+	AddDependency(ctx context.Context, scope Scope, id uint, blockerID uint) (*model.TaskDependency, error)
+	RemoveDependency(ctx context.Context, scope Scope, id uint, blockerID uint) error
+	GetTaskGraph(ctx context.Context, scope Scope, id uint) (*TaskGraph, error)
+	ListTaskEvents(ctx context.Context, scope Scope, id uint, cursor string, limit int) (*TaskEventPage, error)
+	ListTaskChanges(ctx context.Context, scope Scope, query TaskQuery, after uint) (*TaskChangePage, error)
+	LatestTaskEventSeq(ctx context.Context, scope Scope) (uint, error)
+	// Transaction runs fn against a repository bound to one database
+	// transaction, committed if fn returns nil and rolled back otherwise.
+	Transaction(ctx context.Context, fn func(repo RepositoryInterface) error) error
+}
+
+type APIKeyRepositoryInterface interface {
+	CreateAPIKey(ctx context.Context, key *model.APIKey) (*model.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]model.APIKey, error)
+	FindAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uint) error
+}
+
+type WebhookRepositoryInterface interface {
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error)
+	ListWebhooks(ctx context.Context, workspaceID uint) ([]model.Webhook, error)
+	GetWebhook(ctx context.Context, workspaceID uint, id uint) (*model.Webhook, error)
+	DeleteWebhook(ctx context.Context, workspaceID uint, id uint) error
+	ListWebhookDeliveries(ctx context.Context, workspaceID uint, id uint, status string, cursor string, limit int) (*WebhookDeliveryPage, error)
+}
+
+type WorkspaceRepositoryInterface interface {
+	CreateWorkspace(ctx context.Context, ws *model.Workspace) (*model.Workspace, error)
+	GetWorkspace(ctx context.Context, id uint) (*model.Workspace, error)
+	ListWorkspaces(ctx context.Context, ids []uint) ([]model.Workspace, error)
 }
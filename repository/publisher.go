@@ -0,0 +1,27 @@
+package repository
+
+// Publisher is told about every task change once its transaction has
+// committed, e.g. to push it to connected clients. Publish must not block.
+type Publisher interface {
+	Publish(changes []TaskChange)
+}
+
+// WithPublisher makes the repository hand committed changes to p.
+func (r *TaskRepository) WithPublisher(p Publisher) *TaskRepository {
+	r.publisher = p
+	return r
+}
+
+// published collects the change of the current transaction: the outermost
+// transaction numbers it right before it commits and then passes it to the
+// publisher, and it is dropped if the savepoint or transaction it was made in
+// rolls back.
+func (r *TaskRepository) published(change TaskChange) {
+	if r.pending != nil {
+		*r.pending = append(*r.pending, change)
+		return
+	}
+	if r.publisher != nil {
+		r.publisher.Publish([]TaskChange{change})
+	}
+}
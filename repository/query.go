@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"task-api/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort field")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+type TagMatch string
+
+const (
+	TagMatchAny TagMatch = "any"
+	TagMatchAll TagMatch = "all"
+)
+
+// Scope restricts repository operations to the tasks a caller may touch.
+// Every task method is confined to WorkspaceID; tasks in other workspaces
+// behave as if they did not exist.
+type Scope struct {
+	WorkspaceID uint
+	Assignee    string // if set, updates are limited to tasks assigned to this name
+}
+
+func (s Scope) tenant(db *gorm.DB) *gorm.DB {
+	return db.Where("workspace_id = ?", s.WorkspaceID)
+}
+
+// TaskQuery describes the filters, ordering and page requested from ListTasks.
+// Zero values mean "no constraint".
+type TaskQuery struct {
+	Status        model.Status
+	Priorities    []model.Priority
+	ParentID      *uint
+	SeriesID      *uint // every occurrence of a recurring series
+	Overdue       bool  // open tasks the reminder scheduler marked overdue
+	Assignee      string
+	Tags          []string
+	TagMatch      TagMatch
+	DueAfter      *time.Time
+	DueBefore     *time.Time
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	Sort          string
+	Desc          bool
+	Limit         int
+	Cursor        string
+	// IncludeDeleted adds tasks in the trash; OnlyDeleted lists nothing else.
+	IncludeDeleted bool
+	OnlyDeleted    bool
+}
+
+// TaskPage is one page of ListTasks results. NextCursor is empty on the last page.
+type TaskPage struct {
+	Tasks      []model.Task
+	NextCursor string
+}
+
+type columnKind int
+
+const (
+	kindInt columnKind = iota
+	kindString
+	kindTime
+)
+
+type sortColumn struct {
+	kind     columnKind
+	nullable bool
+	column   string // when it differs from the sort key
+	value    func(t *model.Task) interface{}
+}
+
+var sortColumns = map[string]sortColumn{
+	"id":         {kind: kindInt, value: func(t *model.Task) interface{} { return t.ID }},
+	"name":       {kind: kindString, value: func(t *model.Task) interface{} { return t.Name }},
+	"status":     {kind: kindString, value: func(t *model.Task) interface{} { return string(t.Status) }},
+	"priority":   {kind: kindInt, value: func(t *model.Task) interface{} { return int(t.Priority) }},
+	"rank":       {kind: kindString, column: "position", value: func(t *model.Task) interface{} { return t.Rank }},
+	"assignee":   {kind: kindString, value: func(t *model.Task) interface{} { return t.Assignee }},
+	"created_at": {kind: kindTime, value: func(t *model.Task) interface{} { return t.CreatedAt }},
+	"updated_at": {kind: kindTime, value: func(t *model.Task) interface{} { return t.UpdatedAt }},
+	"due_date": {kind: kindTime, nullable: true, value: func(t *model.Task) interface{} {
+		if t.DueDate == nil {
+			return nil
+		}
+		return *t.DueDate
+	}},
+	"completed_at": {kind: kindTime, nullable: true, value: func(t *model.Task) interface{} {
+		if t.CompletedAt == nil {
+			return nil
+		}
+		return *t.CompletedAt
+	}},
+	"deleted_at": {kind: kindTime, nullable: true, value: func(t *model.Task) interface{} {
+		if !t.DeletedAt.Valid {
+			return nil
+		}
+		return t.DeletedAt.Time
+	}},
+}
+
+// cursor is the keyset position after the last row of a page: the sort
+// column value plus the id used as tie-breaker.
+type cursor struct {
+	Sort  string          `json:"s"`
+	Desc  bool            `json:"d"`
+	Value json.RawMessage `json:"v"`
+	ID    uint            `json:"id"`
+}
+
+func encodeCursor(sort string, desc bool, col sortColumn, task *model.Task) (string, error) {
+	value, err := json.Marshal(col.value(task))
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(cursor{Sort: sort, Desc: desc, Value: value, ID: task.ID})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(s string, sort string, desc bool, col sortColumn) (interface{}, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+	// A cursor is only meaningful for the ordering that produced it.
+	if c.Sort != sort || c.Desc != desc {
+		return nil, 0, ErrInvalidCursor
+	}
+	if string(c.Value) == "null" {
+		if !col.nullable {
+			return nil, 0, ErrInvalidCursor
+		}
+		return nil, c.ID, nil
+	}
+
+	var value interface{}
+	switch col.kind {
+	case kindInt:
+		var v int64
+		err = json.Unmarshal(c.Value, &v)
+		value = v
+	case kindString:
+		var v string
+		err = json.Unmarshal(c.Value, &v)
+		value = v
+	case kindTime:
+		var v time.Time
+		err = json.Unmarshal(c.Value, &v)
+		value = v.UTC()
+	}
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+	return value, c.ID, nil
+}
+
+func applyFilters(db *gorm.DB, q TaskQuery) *gorm.DB {
+	if q.Status != "" {
+		db = db.Where("status = ?", q.Status)
+	}
+	if q.ParentID != nil {
+		db = db.Where("parent_id = ?", *q.ParentID)
+	}
+	if q.SeriesID != nil {
+		db = db.Where("(id = ? OR series_id = ?)", *q.SeriesID, *q.SeriesID)
+	}
+	if q.Overdue {
+		db = db.Where("overdue_at IS NOT NULL AND status NOT IN ?", []model.Status{model.StatusDone, model.StatusCancelled})
+	}
+	if len(q.Priorities) > 0 {
+		db = db.Where("priority IN ?", q.Priorities)
+	}
+	if q.Assignee != "" {
+		db = db.Where("assignee = ?", q.Assignee)
+	}
+	if len(q.Tags) > 0 {
+		db = applyTagFilter(db, q.Tags, q.TagMatch)
+	}
+	ranges := []struct {
+		column string
+		op     string
+		value  *time.Time
+	}{
+		{"due_date", ">=", q.DueAfter},
+		{"due_date", "<", q.DueBefore},
+		{"created_at", ">=", q.CreatedAfter},
+		{"created_at", "<", q.CreatedBefore},
+		{"updated_at", ">=", q.UpdatedAfter},
+		{"updated_at", "<", q.UpdatedBefore},
+	}
+	for _, r := range ranges {
+		if r.value != nil {
+			db = db.Where(fmt.Sprintf("%s %s ?", r.column, r.op), r.value.UTC())
+		}
+	}
+	return db
+}
+
+func applyTagFilter(db *gorm.DB, tags []string, match TagMatch) *gorm.DB {
+	source := tagSource(db.Dialector.Name())
+	if match == TagMatchAll {
+		return db.Where("(SELECT COUNT(DISTINCT je.value) FROM "+source+" WHERE je.value IN ?) = ?", tags, len(distinct(tags)))
+	}
+	return db.Where("EXISTS (SELECT 1 FROM "+source+" WHERE je.value IN ?)", tags)
+}
+
+// tagSource expands tasks.tags into rows aliased je with a single value column.
+func tagSource(dialect string) string {
+	switch dialect {
+	case "postgres":
+		return "jsonb_array_elements_text(tasks.tags) AS je(value)"
+	case "mysql":
+		return "JSON_TABLE(tasks.tags, '$[*]' COLUMNS (value VARCHAR(255) PATH '$')) AS je"
+	default:
+		return "json_each(tasks.tags) AS je"
+	}
+}
+
+// applyKeyset restricts the query to rows strictly after the cursor position.
+// NULLs sort last in ascending order and first in descending order.
+func applyKeyset(db *gorm.DB, column string, col sortColumn, desc bool, value interface{}, id uint) *gorm.DB {
+	cmp, idCmp := ">", ">"
+	if desc {
+		cmp, idCmp = "<", "<"
+	}
+
+	if column == "id" {
+		return db.Where("id "+idCmp+" ?", id)
+	}
+	if value == nil {
+		if desc {
+			return db.Where(fmt.Sprintf("((%[1]s IS NULL AND id %[2]s ?) OR %[1]s IS NOT NULL)", column, idCmp), id)
+		}
+		return db.Where(fmt.Sprintf("%s IS NULL AND id %s ?", column, idCmp), id)
+	}
+
+	cond := fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[3]s ?))", column, cmp, idCmp)
+	if col.nullable {
+		if desc {
+			return db.Where(fmt.Sprintf("%s IS NOT NULL AND %s", column, cond), value, value, id)
+		}
+		return db.Where(fmt.Sprintf("(%s OR %s IS NULL)", cond, column), value, value, id)
+	}
+	return db.Where(cond, value, value, id)
+}
+
+func applyOrder(db *gorm.DB, column string, col sortColumn, desc bool) *gorm.DB {
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	if col.nullable {
+		db = db.Order(fmt.Sprintf("%s IS NULL %s", column, dir))
+	}
+	if column != "id" {
+		db = db.Order(fmt.Sprintf("%s %s", column, dir))
+	}
+	return db.Order("id " + dir)
+}
+
+func distinct(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}
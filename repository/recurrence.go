@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"task-api/model"
+	"task-api/pkg/recurrence"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidRecurrence is returned for a malformed RRULE or a recurring task
+// without a due date; the wrapped message says which.
+var ErrInvalidRecurrence = errors.New("invalid recurrence")
+
+// WithClock makes the repository read the time from now, for completed_at,
+// deleted_at, the timestamps gorm maintains and the occurrences of
+// recurring tasks.
+func (r *TaskRepository) WithClock(now func() time.Time) *TaskRepository {
+	r.db = r.db.Session(&gorm.Session{NowFunc: func() time.Time { return now().UTC() }})
+	return r
+}
+
+// UpdateSeries applies fields to every open occurrence of the series task id
+// belongs to, and so to the occurrences they will generate. Done, cancelled
+// and deleted occurrences keep their values.
+func (r *TaskRepository) UpdateSeries(ctx context.Context, scope Scope, fields map[string]interface{}, id uint) ([]model.Task, error) {
+	var updated []model.Task
+	err := r.inTx(ctx, func(tx *TaskRepository) error {
+		task, err := tx.GetTaskByID(ctx, scope, id)
+		if err != nil {
+			return err
+		}
+		var open []model.Task
+		err = scope.tenant(tx.db.WithContext(ctx)).Where("(id = ? OR series_id = ?) AND status NOT IN ?", seriesOf(task), seriesOf(task), []model.Status{model.StatusDone, model.StatusCancelled}).
+			Order("id").Find(&open).Error
+		if err != nil {
+			return dbError(ctx, "UpdateSeries", err, "task_id", id)
+		}
+		for _, occurrence := range open {
+			// UpdateTask 會改寫 fields，每次給一份新的
+			copied := make(map[string]interface{}, len(fields))
+			for k, v := range fields {
+				copied[k] = v
+			}
+			task, err := tx.UpdateTask(ctx, scope, copied, occurrence.ID, AnyVersion)
+			if err != nil {
+				return err
+			}
+			updated = append(updated, *task)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// seriesOf is the ID the occurrences of task's series refer to.
+func seriesOf(task *model.Task) uint {
+	if task.SeriesID != nil {
+		return *task.SeriesID
+	}
+	return task.ID
+}
+
+// normalizeRecurrence validates rule for a task due at due and returns it
+// in normalised form; an empty rule stays empty.
+func normalizeRecurrence(rule string, due *time.Time) (string, error) {
+	if rule == "" {
+		return "", nil
+	}
+	parsed, err := recurrence.Parse(rule)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+	}
+	if due == nil {
+		return "", fmt.Errorf("%w: a recurring task needs a due_date", ErrInvalidRecurrence)
+	}
+	return parsed.String(), nil
+}
+
+// checkRecurrence normalises fields["recurrence"] of an update to task
+// before, against the due date the task will have afterwards.
+func checkRecurrence(fields map[string]interface{}, before *model.Task) error {
+	due := before.DueDate
+	if value, ok := fields["due_date"]; ok {
+		due = nil
+		if d, ok := value.(time.Time); ok {
+			due = &d
+		}
+	}
+	rule, ok := fields["recurrence"].(string)
+	if !ok {
+		// 未修改規則時，仍不能把週期性 task 的到期日清掉
+		rule = before.Recurrence
+		if rule != "" && due == nil {
+			return fmt.Errorf("%w: a recurring task needs a due_date", ErrInvalidRecurrence)
+		}
+		return nil
+	}
+	normalized, err := normalizeRecurrence(rule, due)
+	if err != nil {
+		return err
+	}
+	fields["recurrence"] = normalized
+	return nil
+}
+
+// nextOccurrence creates the occurrence that follows task, which was just
+// completed: a copy due at the first date of its rule after both its due
+// date and now, so missed occurrences are skipped. Nothing is created once
+// the rule has ended or if a later occurrence already exists, e.g. because
+// the task was reopened and completed again.
+func (r *TaskRepository) nextOccurrence(ctx context.Context, scope Scope, task *model.Task) error {
+	if task.Recurrence == "" || task.DueDate == nil {
+		return nil
+	}
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+	}
+	series := seriesOf(task)
+
+	var later, occurrences int64
+	db := scope.tenant(r.db.WithContext(ctx).Unscoped().Model(&model.Task{}))
+	if err := db.Where("series_id = ? AND id > ?", series, task.ID).Count(&later).Error; err != nil {
+		return dbError(ctx, "nextOccurrence", err, "task_id", task.ID)
+	}
+	if later > 0 {
+		return nil
+	}
+	if rule.Count() > 0 {
+		db := scope.tenant(r.db.WithContext(ctx).Unscoped().Model(&model.Task{}))
+		if err := db.Where("id = ? OR series_id = ?", series, series).Count(&occurrences).Error; err != nil {
+			return dbError(ctx, "nextOccurrence", err, "task_id", task.ID)
+		}
+		if occurrences >= int64(rule.Count()) {
+			return nil
+		}
+	}
+
+	after := *task.DueDate
+	if now := r.db.NowFunc(); now.After(after) {
+		after = now
+	}
+	due, ok := rule.Next(*task.DueDate, after)
+	if !ok {
+		return nil
+	}
+	_, err = r.CreateTask(ctx, scope, &model.Task{
+		Name:       task.Name,
+		Priority:   task.Priority,
+		DueDate:    &due,
+		Assignee:   task.Assignee,
+		Tags:       task.Tags,
+		ParentID:   task.ParentID,
+		Recurrence: task.Recurrence,
+		SeriesID:   &series,
+		Reminders:  task.Reminders,
+	})
+	return err
+}
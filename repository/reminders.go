@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"task-api/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidReminder is returned for a reminder offset that is not a
+// positive duration; the wrapped message names it.
+var ErrInvalidReminder = errors.New("invalid reminder")
+
+// Reminder is a notification claimed by ClaimReminders, with the task it is
+// about as it was when claimed.
+type Reminder struct {
+	model.TaskReminder
+	Task model.Task
+}
+
+// reminderBatch is how many due tasks ClaimReminders loads at a time.
+const reminderBatch = 100
+
+// ClaimReminders finds, in every workspace, the open tasks that are due for
+// a notification at now and claims one per task: the overdue notice once the
+// due date has passed (marking the task overdue), otherwise the reminder with
+// the smallest offset that has been reached. Larger offsets reached at the
+// same time, e.g. after downtime, are skipped rather than sent late. A claim
+// is never returned twice, so the caller must send it, then MarkReminderSent
+// or, if sending failed, ReleaseReminder to have it claimed again.
+//
+// Only tasks whose next_reminder_at has been reached are read, a batch at a
+// time; each one is moved on to its next notification as it is claimed.
+func (r *TaskRepository) ClaimReminders(ctx context.Context, now time.Time) ([]Reminder, error) {
+	now = now.UTC()
+	var reminders []Reminder
+	for {
+		var tasks []model.Task
+		err := r.db.WithContext(ctx).Where("next_reminder_at <= ?", now).
+			Order("next_reminder_at, id").Limit(reminderBatch).Find(&tasks).Error
+		if err != nil {
+			return reminders, dbError(ctx, "ClaimReminders", err)
+		}
+		for i := range tasks {
+			reminder, ok, err := r.claimReminder(ctx, &tasks[i], now)
+			if err != nil {
+				return reminders, err
+			}
+			if ok {
+				reminders = append(reminders, reminder)
+			}
+		}
+		if len(tasks) < reminderBatch {
+			return reminders, nil
+		}
+	}
+}
+
+// claimReminder claims the notification task is due for at now, if any, and
+// schedules the next one. It reports false when there was nothing to claim,
+// another instance got there first or task changed since it was read.
+func (r *TaskRepository) claimReminder(ctx context.Context, task *model.Task, now time.Time) (Reminder, bool, error) {
+	reminder, ok := dueReminder(task, now)
+	err := r.inTx(ctx, func(tx *TaskRepository) error {
+		// 以讀到的 next_reminder_at 為條件，同時被更新的 task 留到下一輪再看
+		result := tx.db.WithContext(ctx).Model(&model.Task{}).
+			Where("id = ? AND next_reminder_at = ?", task.ID, *task.NextReminderAt).
+			UpdateColumn("next_reminder_at", nextReminderAt(task.Status, task.DueDate, task.Reminders, now))
+		if result.Error != nil {
+			return dbError(ctx, "ClaimReminders", result.Error, "task_id", task.ID)
+		}
+		if result.RowsAffected == 0 || !ok {
+			ok = false
+			return nil
+		}
+
+		result = tx.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&reminder.TaskReminder)
+		if result.Error != nil {
+			return dbError(ctx, "ClaimReminders", result.Error, "task_id", task.ID)
+		}
+		if result.RowsAffected == 0 {
+			ok = false // 已被其他 instance 或先前的執行認領
+			return nil
+		}
+		if reminder.Kind == model.ReminderOverdue && reminder.Task.OverdueAt == nil {
+			return tx.markOverdue(ctx, &reminder.Task, now)
+		}
+		return nil
+	})
+	return reminder, ok && err == nil, err
+}
+
+// MarkReminderSent records that the claimed reminder id was delivered.
+func (r *TaskRepository) MarkReminderSent(ctx context.Context, id uint, sentAt time.Time) error {
+	err := r.db.WithContext(ctx).Model(&model.TaskReminder{}).Where("id = ?", id).Update("sent_at", sentAt.UTC()).Error
+	if err != nil {
+		return dbError(ctx, "MarkReminderSent", err, "reminder_id", id)
+	}
+	return nil
+}
+
+// ReleaseReminder drops the claim on reminder id, which could not be sent,
+// and moves the task's next_reminder_at back so that the next
+// ClaimReminders tries again.
+func (r *TaskRepository) ReleaseReminder(ctx context.Context, id uint) error {
+	return r.inTx(ctx, func(tx *TaskRepository) error {
+		var reminder model.TaskReminder
+		if err := tx.db.WithContext(ctx).Where("id = ? AND sent_at IS NULL", id).First(&reminder).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return dbError(ctx, "ReleaseReminder", err, "reminder_id", id)
+		}
+		if err := tx.db.WithContext(ctx).Delete(&reminder).Error; err != nil {
+			return dbError(ctx, "ReleaseReminder", err, "reminder_id", id)
+		}
+
+		at := reminder.DueDate
+		if d, err := time.ParseDuration(reminder.Offset); err == nil {
+			at = at.Add(-d)
+		}
+		// 到期日已改過的 task 有自己的排程，不必回頭
+		err := tx.db.WithContext(ctx).Model(&model.Task{}).
+			Where("id = ? AND due_date = ? AND (next_reminder_at IS NULL OR next_reminder_at > ?)", reminder.TaskID, reminder.DueDate, at).
+			UpdateColumn("next_reminder_at", at).Error
+		if err != nil {
+			return dbError(ctx, "ReleaseReminder", err, "reminder_id", id)
+		}
+		return nil
+	})
+}
+
+// dueReminder is the notification task is due for at now, if any.
+func dueReminder(task *model.Task, now time.Time) (Reminder, bool) {
+	due := task.DueDate.UTC()
+	reminder := Reminder{
+		TaskReminder: model.TaskReminder{WorkspaceID: task.WorkspaceID, TaskID: task.ID, DueDate: due, Kind: model.ReminderOverdue},
+		Task:         *task,
+	}
+	if !due.After(now) {
+		return reminder, true
+	}
+	// Reminders 已依偏移量由大到小排序，最後一個到期的就是最接近到期日的
+	reminder.Kind = model.ReminderBefore
+	for _, offset := range task.Reminders {
+		d, err := time.ParseDuration(offset)
+		if err != nil || due.Add(-d).After(now) {
+			continue
+		}
+		reminder.Offset = offset
+	}
+	return reminder, reminder.Offset != ""
+}
+
+// nextReminderAt is the first time after the instant after at which a task
+// with these fields needs a notification: a reminder offset before the due
+// date or, last, the due date itself for the overdue notice. It is nil for
+// closed tasks, tasks without a due date and when every notification lies
+// before after. The zero after starts the schedule over.
+func nextReminderAt(status model.Status, due *time.Time, offsets model.Reminders, after time.Time) *time.Time {
+	if due == nil || status.Closed() {
+		return nil
+	}
+	var next *time.Time
+	consider := func(at time.Time) {
+		if at.After(after) && (next == nil || at.Before(*next)) {
+			next = &at
+		}
+	}
+	consider(due.UTC())
+	for _, offset := range offsets {
+		if d, err := time.ParseDuration(offset); err == nil {
+			consider(due.UTC().Add(-d))
+		}
+	}
+	return next
+}
+
+// scheduleReminders restarts the reminder schedule of task before when an
+// update changes its due date, reminders or status. Claims already made for
+// the same due date are not sent again.
+func scheduleReminders(fields map[string]interface{}, before *model.Task) {
+	status, due, offsets := before.Status, before.DueDate, before.Reminders
+	_, hasStatus := fields["status"]
+	_, hasDue := fields["due_date"]
+	_, hasReminders := fields["reminders"]
+	if !hasStatus && !hasDue && !hasReminders {
+		return
+	}
+	if value, ok := fields["status"].(model.Status); ok {
+		status = value
+	}
+	if hasDue {
+		due = nil
+		switch value := fields["due_date"].(type) {
+		case time.Time:
+			due = &value
+		case *time.Time:
+			due = value
+		}
+	}
+	if hasReminders {
+		offsets, _ = fields["reminders"].(model.Reminders)
+	}
+	fields["next_reminder_at"] = nextReminderAt(status, due, offsets, time.Time{})
+}
+
+// markOverdue sets overdue_at on task and records an overdue event.
+func (r *TaskRepository) markOverdue(ctx context.Context, task *model.Task, now time.Time) error {
+	fields := map[string]interface{}{"overdue_at": now, "version": gorm.Expr("version + 1")}
+	if err := r.db.WithContext(ctx).Model(&model.Task{}).Where("id = ?", task.ID).Updates(fields).Error; err != nil {
+		return dbError(ctx, "markOverdue", err, "task_id", task.ID)
+	}
+	before := *task
+	if err := r.db.WithContext(ctx).Where("id = ?", task.ID).First(task).Error; err != nil {
+		return dbError(ctx, "markOverdue", err, "task_id", task.ID)
+	}
+	return r.recordEvent(ctx, model.TaskOverdue, task, diff(&before, task, fields))
+}
+
+// normalizeReminders validates reminder offsets and returns them without
+// duplicates, largest first, in the short form of formatOffset.
+func normalizeReminders(offsets model.Reminders) (model.Reminders, error) {
+	if len(offsets) == 0 {
+		return nil, nil
+	}
+	durations := make([]time.Duration, 0, len(offsets))
+	seen := map[time.Duration]bool{}
+	for _, offset := range offsets {
+		d, err := time.ParseDuration(offset)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%w: %q is not a positive duration such as 30m or 24h", ErrInvalidReminder, offset)
+		}
+		if !seen[d] {
+			seen[d] = true
+			durations = append(durations, d)
+		}
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] > durations[j] })
+	normalized := make(model.Reminders, len(durations))
+	for i, d := range durations {
+		normalized[i] = formatOffset(d)
+	}
+	return normalized, nil
+}
+
+// formatOffset formats d like time.Duration.String without the zero
+// trailing units, e.g. "24h" instead of "24h0m0s".
+func formatOffset(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// checkReminders normalises fields["reminders"] of an update, and clears
+// overdue_at when the update moves the due date of task before.
+func checkReminders(fields map[string]interface{}, before *model.Task) error {
+	if offsets, ok := fields["reminders"].(model.Reminders); ok {
+		normalized, err := normalizeReminders(offsets)
+		if err != nil {
+			return err
+		}
+		fields["reminders"] = normalized
+	}
+	if value, ok := fields["due_date"]; ok && before.OverdueAt != nil {
+		due, _ := value.(time.Time)
+		if before.DueDate == nil || !due.Equal(*before.DueDate) {
+			fields["overdue_at"] = nil
+		}
+	}
+	return nil
+}
@@ -1,58 +1,657 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
+
 	"task-api/model"
+	"task-api/pkg/logger"
+	"task-api/pkg/rank"
+	"task-api/pkg/workflow"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNotFound is returned by every repository method when the task does not exist.
+	ErrNotFound = errors.New("task not found")
+	// ErrNotOwner is returned when the task exists but is outside the caller's Scope.
+	ErrNotOwner = errors.New("task is not assigned to you")
+	// ErrVersionMismatch is returned when an update or delete was conditioned
+	// on a version the task no longer has.
+	ErrVersionMismatch = errors.New("task has been modified")
+	// ErrNotDeleted is returned when restoring a task that is not in the trash.
+	ErrNotDeleted = errors.New("task is not in the trash")
+	// ErrInvalidTransition is returned when the workflow does not allow a
+	// status change; the wrapped message names both statuses.
+	ErrInvalidTransition = errors.New("status transition not allowed")
+	// ErrNotClosed is returned when reopening a task that is neither done nor cancelled.
+	ErrNotClosed = errors.New("task is not done or cancelled")
+	// ErrInvalidMove is returned when a task cannot be placed next to the
+	// requested neighbour; the wrapped message says why.
+	ErrInvalidMove = errors.New("invalid move")
 )
 
+// AnyVersion makes UpdateTask and DeleteTask skip the version check.
+const AnyVersion uint = 0
+
 type TaskRepository struct {
-	db *gorm.DB
+	db        *gorm.DB
+	workflow  workflow.Workflow
+	subtasks  SubtaskPolicy
+	publisher Publisher
+	webhooks  WebhookEncoder
+	pending   *[]TaskChange // changes of the current transaction, see published
 }
 
 func NewTaskRepository(db *gorm.DB) *TaskRepository {
-	return &TaskRepository{db: db}
+	return &TaskRepository{
+		db:       db,
+		workflow: workflow.Default(),
+		subtasks: SubtaskPolicy{OnDelete: DeleteCascade, OnComplete: CompleteAllow},
+	}
+}
+
+// WithWorkflow replaces the default status transitions checked by UpdateTask.
+func (r *TaskRepository) WithWorkflow(w workflow.Workflow) *TaskRepository {
+	r.workflow = w
+	return r
 }
 
-func (r *TaskRepository) CreateTask(task *model.Task) (*model.Task, error) {
-	if err := r.db.Create(task).Error; err != nil {
+// CreateTask stores task in the scope's workspace and records a created
+// event. Unless task.Rank is set, the task is ranked after every other task.
+func (r *TaskRepository) CreateTask(ctx context.Context, scope Scope, task *model.Task) (*model.Task, error) {
+	task.WorkspaceID = scope.WorkspaceID
+	task.Version = 1
+	if task.Status == "" {
+		task.Status = model.StatusTodo
+	}
+	if task.Status == model.StatusDone && task.CompletedAt == nil {
+		now := r.db.NowFunc()
+		task.CompletedAt = &now
+	}
+	if task.DueDate != nil {
+		due := task.DueDate.UTC()
+		task.DueDate = &due
+	}
+	rule, err := normalizeRecurrence(task.Recurrence, task.DueDate)
+	if err != nil {
+		return nil, err
+	}
+	task.Recurrence = rule
+	if task.Reminders, err = normalizeReminders(task.Reminders); err != nil {
+		return nil, err
+	}
+	task.NextReminderAt = nextReminderAt(task.Status, task.DueDate, task.Reminders, time.Time{})
+	err = r.inTx(ctx, func(tx *TaskRepository) error {
+		if task.ParentID != nil {
+			if err := tx.checkParent(ctx, scope, 0, *task.ParentID); err != nil {
+				return err
+			}
+		}
+		if task.Rank == "" {
+			last, err := tx.lastRank(ctx, scope)
+			if err != nil {
+				return err
+			}
+			if task.Rank, err = rank.Between(last, ""); err != nil {
+				return err
+			}
+		}
+		if err := tx.db.WithContext(ctx).Create(task).Error; err != nil {
+			return dbError(ctx, "CreateTask", err)
+		}
+		return tx.recordEvent(ctx, model.TaskCreated, task, createdChanges(task))
+	})
+	if err != nil {
 		return nil, err
 	}
 	return task, nil
 }
 
-func (r *TaskRepository) GetTaskByID(id uint) (*model.Task, error) {
+func (r *TaskRepository) GetTaskByID(ctx context.Context, scope Scope, id uint) (*model.Task, error) {
 	var task model.Task
-	if err := r.db.Where("id = ?", id).First(&task).Error; err != nil {
-		return nil, err
+	if err := scope.tenant(r.db.WithContext(ctx)).Where("id = ?", id).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, dbError(ctx, "GetTaskByID", err, "task_id", id)
 	}
 	return &task, nil
 }
 
-func (r *TaskRepository) GetAllTasks() ([]model.Task, error) {
+func (r *TaskRepository) ListTasks(ctx context.Context, scope Scope, query TaskQuery) (*TaskPage, error) {
+	sort := query.Sort
+	if sort == "" {
+		sort = "id"
+	}
+	col, ok := sortColumns[sort]
+	if !ok {
+		return nil, ErrInvalidSort
+	}
+	column := sort
+	if col.column != "" {
+		column = col.column
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	db := r.db.WithContext(ctx)
+	if query.IncludeDeleted || query.OnlyDeleted {
+		db = db.Unscoped()
+	}
+	if query.OnlyDeleted {
+		db = db.Where("deleted_at IS NOT NULL")
+	}
+	db = applyFilters(scope.tenant(db.Model(&model.Task{})), query)
+	if query.Cursor != "" {
+		value, id, err := decodeCursor(query.Cursor, sort, query.Desc, col)
+		if err != nil {
+			return nil, err
+		}
+		db = applyKeyset(db, column, col, query.Desc, value, id)
+	}
+
 	var tasks []model.Task
-	if err := r.db.Find(&tasks).Error; err != nil {
+	if err := applyOrder(db, column, col, query.Desc).Limit(limit + 1).Find(&tasks).Error; err != nil {
+		return nil, dbError(ctx, "ListTasks", err)
+	}
+
+	page := &TaskPage{Tasks: tasks}
+	if len(tasks) > limit {
+		page.Tasks = tasks[:limit]
+		next, err := encodeCursor(sort, query.Desc, col, &page.Tasks[limit-1])
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = next
+	}
+	return page, nil
+}
+
+// CountTasksByStatus is used by the metrics endpoint and spans all workspaces.
+func (r *TaskRepository) CountTasksByStatus(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Model(&model.Task{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error; err != nil {
+		return nil, dbError(ctx, "CountTasksByStatus", err)
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// UpdateTask applies fields, bumps the version and records the changed
+// fields as an updated event. Unless version is AnyVersion the update only
+// happens if the task still has that version; the check is part of the
+// UPDATE statement, so concurrent writers cannot both win. A status change
+// must be allowed by the workflow and maintains completed_at; a task with
+// open blockers cannot become done, and a recurring task that becomes done
+// gets its next occurrence.
+func (r *TaskRepository) UpdateTask(ctx context.Context, scope Scope, fields map[string]interface{}, id uint, version uint) (*model.Task, error) {
+	if len(fields) == 0 {
+		task, err := r.GetTaskByID(ctx, scope, id)
+		if err != nil {
+			return nil, err
+		}
+		return task, checkTask(task, scope, version)
+	}
+	if due, ok := fields["due_date"].(time.Time); ok {
+		fields["due_date"] = due.UTC()
+	}
+
+	var updated *model.Task
+	err := r.inTx(ctx, func(tx *TaskRepository) error {
+		before, err := tx.lockTask(ctx, scope, id)
+		if err != nil {
+			return err
+		}
+		if err := checkRecurrence(fields, before); err != nil {
+			return err
+		}
+		if err := checkReminders(fields, before); err != nil {
+			return err
+		}
+		if status, ok := fields["status"].(model.Status); ok {
+			// 先確認版本與擁有者，避免以過期的狀態判斷轉換
+			if err := checkTask(before, scope, version); err != nil {
+				return err
+			}
+			if !tx.workflow.Allows(before.Status, status) {
+				return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, before.Status, status)
+			}
+			if status == model.StatusDone && before.Status != model.StatusDone {
+				if err := tx.checkCompletable(ctx, scope, id); err != nil {
+					return err
+				}
+			}
+			tx.complete(fields, before.Status, status)
+		}
+		if parent, ok := fields["parent_id"].(*uint); ok && parent != nil {
+			if err := tx.checkParent(ctx, scope, id, *parent); err != nil {
+				return err
+			}
+		}
+		scheduleReminders(fields, before)
+
+		fields["version"] = gorm.Expr("version + 1")
+		result := tx.conditional(ctx, scope, id, version).Model(&model.Task{}).Updates(fields)
+		if result.Error != nil {
+			return dbError(ctx, "UpdateTask", result.Error, "task_id", id)
+		}
+		if result.RowsAffected == 0 {
+			return tx.explainMiss(ctx, scope, id, version)
+		}
+		if updated, err = tx.GetTaskByID(ctx, scope, id); err != nil {
+			return err
+		}
+		if err := tx.recordEvent(ctx, model.TaskUpdated, updated, diff(before, updated, fields)); err != nil {
+			return err
+		}
+		if updated.Status == model.StatusDone && before.Status != model.StatusDone {
+			return tx.nextOccurrence(ctx, scope, updated)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	return tasks, nil
+	return updated, nil
 }
 
-func (r *TaskRepository) UpdateTask(fields map[string]interface{}, id uint) error {
-	result := r.db.Model(&model.Task{}).
-		Where("id = ?", id).
-		Updates(fields)
+// checkCompletable verifies that task id has no open blockers and, under
+// CompleteRestrict, no open subtasks.
+func (r *TaskRepository) checkCompletable(ctx context.Context, scope Scope, id uint) error {
+	blockers, err := r.openBlockers(ctx, scope, id)
+	if err != nil {
+		return err
+	}
+	if len(blockers) > 0 {
+		return fmt.Errorf("%w: %s", ErrOpenBlockers, formatIDs(blockers, ", "))
+	}
+	if r.subtasks.OnComplete == CompleteRestrict {
+		open, err := r.openSubtasks(ctx, scope, id)
+		if err != nil {
+			return err
+		}
+		if open {
+			return ErrOpenSubtasks
+		}
+	}
+	return nil
+}
 
-	if result.RowsAffected == 0 {
-		return errors.New("task not found")
+// ReopenTask moves a done or cancelled task back to todo, which the
+// workflow never allows through UpdateTask.
+func (r *TaskRepository) ReopenTask(ctx context.Context, scope Scope, id uint, version uint) (*model.Task, error) {
+	var reopened *model.Task
+	err := r.inTx(ctx, func(tx *TaskRepository) error {
+		before, err := tx.lockTask(ctx, scope, id)
+		if err != nil {
+			return err
+		}
+		if err := checkTask(before, scope, version); err != nil {
+			return err
+		}
+		if !before.Status.Closed() {
+			return ErrNotClosed
+		}
+
+		fields := map[string]interface{}{"status": model.StatusTodo}
+		tx.complete(fields, before.Status, model.StatusTodo)
+		scheduleReminders(fields, before)
+		fields["version"] = gorm.Expr("version + 1")
+		result := tx.conditional(ctx, scope, id, version).Model(&model.Task{}).Updates(fields)
+		if result.Error != nil {
+			return dbError(ctx, "ReopenTask", result.Error, "task_id", id)
+		}
+		if result.RowsAffected == 0 {
+			return tx.explainMiss(ctx, scope, id, version)
+		}
+		if reopened, err = tx.GetTaskByID(ctx, scope, id); err != nil {
+			return err
+		}
+		return tx.recordEvent(ctx, model.TaskReopened, reopened, diff(before, reopened, fields))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reopened, nil
+}
+
+// Move places a task directly after AfterID or directly before BeforeID
+// in rank order; exactly one of them is set.
+type Move struct {
+	AfterID  uint
+	BeforeID uint
+}
+
+// MoveTask gives task id a rank next to the neighbour named by move,
+// conditioned on version like UpdateTask. Only the moved task is rewritten.
+func (r *TaskRepository) MoveTask(ctx context.Context, scope Scope, id uint, move Move, version uint) (*model.Task, error) {
+	var moved *model.Task
+	err := r.inTx(ctx, func(tx *TaskRepository) error {
+		before, err := tx.lockTask(ctx, scope, id)
+		if err != nil {
+			return err
+		}
+		if err := checkTask(before, scope, version); err != nil {
+			return err
+		}
+		key, err := tx.rankNextTo(ctx, scope, id, move)
+		if err != nil {
+			return err
+		}
+
+		fields := map[string]interface{}{"position": key, "version": gorm.Expr("version + 1")}
+		result := tx.conditional(ctx, scope, id, version).Model(&model.Task{}).Updates(fields)
+		if result.Error != nil {
+			return dbError(ctx, "MoveTask", result.Error, "task_id", id)
+		}
+		if result.RowsAffected == 0 {
+			return tx.explainMiss(ctx, scope, id, version)
+		}
+		if moved, err = tx.GetTaskByID(ctx, scope, id); err != nil {
+			return err
+		}
+		return tx.recordEvent(ctx, model.TaskUpdated, moved, diff(before, moved, fields))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
+
+// rankNextTo returns a rank between the anchor of move and the task on its
+// other side, skipping task id itself.
+func (r *TaskRepository) rankNextTo(ctx context.Context, scope Scope, id uint, move Move) (string, error) {
+	anchorID, after := move.AfterID, true
+	if anchorID == 0 {
+		anchorID, after = move.BeforeID, false
+	}
+	if anchorID == id {
+		return "", fmt.Errorf("%w: a task cannot be moved next to itself", ErrInvalidMove)
+	}
+	anchor, err := r.GetTaskByID(ctx, Scope{WorkspaceID: scope.WorkspaceID}, anchorID)
+	if errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("%w: task %d not found", ErrInvalidMove, anchorID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// 與 anchor 相鄰、排序在另一側的 task（不含被移動的 task 本身）
+	cmp, dir := ">", "ASC"
+	if !after {
+		cmp, dir = "<", "DESC"
+	}
+	var neighbour []model.Task
+	err = scope.tenant(r.db.WithContext(ctx)).Select("id", "position").
+		Where("id <> ?", id).
+		Where(fmt.Sprintf("(position %[1]s ? OR (position = ? AND id %[1]s ?))", cmp), anchor.Rank, anchor.Rank, anchor.ID).
+		Order("position " + dir).Order("id " + dir).Limit(1).Find(&neighbour).Error
+	if err != nil {
+		return "", dbError(ctx, "rankNextTo", err, "task_id", id)
+	}
+
+	lower, upper := anchor.Rank, ""
+	if len(neighbour) > 0 {
+		upper = neighbour[0].Rank
+	}
+	if !after {
+		lower, upper = upper, lower
+	}
+	key, err := rank.Between(lower, upper)
+	if err != nil {
+		// 同時建立的 task 可能拿到相同的 rank，兩者之間放不下新位置
+		return "", fmt.Errorf("%w: %v", ErrInvalidMove, err)
+	}
+	return key, nil
+}
+
+// lastRank is the highest rank in the scope's workspace, counting the
+// trash so that restored tasks keep a distinct position.
+func (r *TaskRepository) lastRank(ctx context.Context, scope Scope) (string, error) {
+	var last *string
+	err := scope.tenant(r.db.WithContext(ctx).Unscoped().Model(&model.Task{})).Select("MAX(position)").Scan(&last).Error
+	if err != nil {
+		return "", dbError(ctx, "lastRank", err)
+	}
+	if last == nil {
+		return "", nil
+	}
+	return *last, nil
+}
+
+// complete sets completed_at in fields when a task enters done and clears it
+// when the task leaves done.
+func (r *TaskRepository) complete(fields map[string]interface{}, from, to model.Status) {
+	switch {
+	case to == model.StatusDone && from != model.StatusDone:
+		fields["completed_at"] = r.db.NowFunc()
+	case to != model.StatusDone && from == model.StatusDone:
+		fields["completed_at"] = nil
+	}
+}
+
+// DeleteTask moves the task to the trash, conditioned on version like
+// UpdateTask, and applies the subtask delete policy. It stays restorable
+// until PurgeDeletedTasks removes it.
+func (r *TaskRepository) DeleteTask(ctx context.Context, scope Scope, id uint, version uint) error {
+	return r.inTx(ctx, func(tx *TaskRepository) error {
+		task, err := tx.lockTask(ctx, scope, id)
+		if err != nil {
+			return err
+		}
+		if tx.subtasks.OnDelete == DeleteRestrict {
+			children, err := tx.children(ctx, scope, []uint{id}, false)
+			if err != nil {
+				return err
+			}
+			if len(children) > 0 {
+				return ErrHasSubtasks
+			}
+		}
+
+		// 刪除也算一次寫入：version 一併遞增，還原與之後的更新才不會撞上同一個 ETag
+		task.DeletedAt = gorm.DeletedAt{Time: tx.db.NowFunc(), Valid: true}
+		result := tx.conditional(ctx, scope, id, version).Model(&model.Task{}).
+			UpdateColumns(map[string]interface{}{"deleted_at": task.DeletedAt, "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return dbError(ctx, "DeleteTask", result.Error, "task_id", id)
+		}
+		if result.RowsAffected == 0 {
+			return tx.explainMiss(ctx, scope, id, version)
+		}
+		task.Version++
+		if err := tx.recordEvent(ctx, model.TaskDeleted, task, nil); err != nil {
+			return err
+		}
+		return tx.deleteSubtasks(ctx, scope, task)
+	})
+}
+
+// RestoreTask takes a task out of the trash and bumps its version, along
+// with the subtasks that were deleted with it. A subtask whose parent is
+// still in the trash becomes a top-level task.
+func (r *TaskRepository) RestoreTask(ctx context.Context, scope Scope, id uint) (*model.Task, error) {
+	var restored *model.Task
+	err := r.inTx(ctx, func(tx *TaskRepository) error {
+		var trashed model.Task
+		if err := scope.tenant(tx.db.WithContext(ctx).Unscoped()).Where("id = ?", id).First(&trashed).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return dbError(ctx, "RestoreTask", err, "task_id", id)
+		}
+		if !trashed.DeletedAt.Valid {
+			return ErrNotDeleted
+		}
+
+		fields := map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}
+		if trashed.ParentID != nil {
+			if _, err := tx.GetTaskByID(ctx, scope, *trashed.ParentID); errors.Is(err, ErrNotFound) {
+				fields["parent_id"] = nil
+			} else if err != nil {
+				return err
+			}
+		}
+		result := scope.tenant(tx.db.WithContext(ctx).Unscoped().Model(&model.Task{})).
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Updates(fields)
+		if result.Error != nil {
+			return dbError(ctx, "RestoreTask", result.Error, "task_id", id)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotDeleted
+		}
+		var err error
+		if restored, err = tx.GetTaskByID(ctx, scope, id); err != nil {
+			return err
+		}
+		if err := tx.recordEvent(ctx, model.TaskRestored, restored, diff(&trashed, restored, fields)); err != nil {
+			return err
+		}
+		return tx.restoreSubtasks(ctx, scope, restored, trashed.DeletedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// PurgeDeletedTasks permanently removes tasks of every workspace that were
+// moved to the trash before cutoff, together with their dependencies and
+// reminders, returning how many were removed.
+func (r *TaskRepository) PurgeDeletedTasks(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := r.inTx(ctx, func(tx *TaskRepository) error {
+		expired := tx.db.WithContext(ctx).Unscoped().Model(&model.Task{}).Select("id").Where("deleted_at < ?", cutoff.UTC())
+		if err := tx.db.WithContext(ctx).Where("blocker_id IN (?) OR blocked_id IN (?)", expired, expired).Delete(&model.TaskDependency{}).Error; err != nil {
+			return dbError(ctx, "PurgeDeletedTasks", err)
+		}
+		if err := tx.db.WithContext(ctx).Where("task_id IN (?)", expired).Delete(&model.TaskReminder{}).Error; err != nil {
+			return dbError(ctx, "PurgeDeletedTasks", err)
+		}
+		result := tx.db.WithContext(ctx).Unscoped().Where("deleted_at < ?", cutoff.UTC()).Delete(&model.Task{})
+		if result.Error != nil {
+			return dbError(ctx, "PurgeDeletedTasks", result.Error)
+		}
+		purged = result.RowsAffected
+		return nil
+	})
+	return purged, err
+}
+
+func (r *TaskRepository) Transaction(ctx context.Context, fn func(repo RepositoryInterface) error) error {
+	return r.inTx(ctx, func(tx *TaskRepository) error {
+		return fn(tx)
+	})
+}
+
+// inTx runs fn in a transaction, or in a savepoint when r is already
+// inside one (e.g. an atomic batch). The outermost transaction numbers the
+// events recorded in it before it commits, see sequenceEvents.
+func (r *TaskRepository) inTx(ctx context.Context, fn func(tx *TaskRepository) error) error {
+	var pending []TaskChange
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repo := &TaskRepository{db: tx, workflow: r.workflow, subtasks: r.subtasks, publisher: r.publisher, webhooks: r.webhooks, pending: &pending}
+		if err := fn(repo); err != nil {
+			return err
+		}
+		if r.pending != nil {
+			return nil // savepoint：由外層交易編號
+		}
+		return repo.sequenceEvents(ctx, pending)
+	})
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+	if r.pending != nil {
+		// savepoint：是否送出由外層交易決定
+		*r.pending = append(*r.pending, pending...)
+	} else if r.publisher != nil {
+		r.publisher.Publish(pending)
 	}
 	return nil
 }
 
-func (r *TaskRepository) DeleteTask(id uint) (bool, error) {
-	result := r.db.Delete(&model.Task{}, id)
-	if result.Error != nil {
-		return false, result.Error
+// lockTask reads task id for a write in the current transaction, holding a
+// row lock where the database supports it so the audited "before" is exact.
+func (r *TaskRepository) lockTask(ctx context.Context, scope Scope, id uint) (*model.Task, error) {
+	db := scope.tenant(r.db.WithContext(ctx))
+	if db.Dialector.Name() != "sqlite" {
+		db = db.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var task model.Task
+	if err := db.Where("id = ?", id).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, dbError(ctx, "lockTask", err, "task_id", id)
+	}
+	return &task, nil
+}
+
+// conditional selects task id only if it is in scope and, unless version
+// is AnyVersion, still at that version.
+func (r *TaskRepository) conditional(ctx context.Context, scope Scope, id uint, version uint) *gorm.DB {
+	db := scope.tenant(r.db.WithContext(ctx)).Where("id = ?", id)
+	if scope.Assignee != "" {
+		db = db.Where("assignee = ?", scope.Assignee)
+	}
+	if version != AnyVersion {
+		db = db.Where("version = ?", version)
 	}
-	return result.RowsAffected > 0, nil
+	return db
+}
+
+// explainMiss tells why a conditional write matched no row: the task is
+// missing, not the caller's, or at another version.
+func (r *TaskRepository) explainMiss(ctx context.Context, scope Scope, id uint, version uint) error {
+	task, err := r.GetTaskByID(ctx, scope, id)
+	if err != nil {
+		return err
+	}
+	if err := checkTask(task, scope, version); err != nil {
+		return err
+	}
+	// 重新讀取時已符合條件，代表在兩次查詢之間被其他人改過
+	return ErrVersionMismatch
+}
+
+func checkTask(task *model.Task, scope Scope, version uint) error {
+	if scope.Assignee != "" && task.Assignee != scope.Assignee {
+		return ErrNotOwner
+	}
+	if version != AnyVersion && task.Version != version {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+// dbError logs an unexpected database error with the request-scoped logger
+// (so it carries the request ID) and returns it unchanged.
+func dbError(ctx context.Context, op string, err error, attrs ...any) error {
+	logger.FromContext(ctx).Error("database error", append([]any{"op", op, "error", err}, attrs...)...)
+	return err
+}
+
+// isDuplicate reports whether err is a unique constraint violation, in
+// whichever form the dialect reports it.
+func isDuplicate(db *gorm.DB, err error) bool {
+	translator, ok := db.Dialector.(gorm.ErrorTranslator)
+	return ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey)
 }
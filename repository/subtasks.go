@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"task-api/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidParent is returned when parent_id names a missing task, the
+	// task itself or one of its subtasks; the wrapped message says which.
+	ErrInvalidParent = errors.New("invalid parent")
+	// ErrHasSubtasks is returned when deleting a task with subtasks under DeleteRestrict.
+	ErrHasSubtasks = errors.New("task has subtasks")
+	// ErrOpenSubtasks is returned when completing a task with open subtasks under CompleteRestrict.
+	ErrOpenSubtasks = errors.New("task has open subtasks")
+)
+
+// DeletePolicy decides what happens to the subtasks of a deleted task.
+type DeletePolicy string
+
+const (
+	// DeleteCascade moves subtasks to the trash with their parent; restoring
+	// the parent restores them too.
+	DeleteCascade DeletePolicy = "cascade"
+	// DeleteOrphan turns the direct subtasks into top-level tasks.
+	DeleteOrphan DeletePolicy = "orphan"
+	// DeleteRestrict refuses to delete a task that has subtasks.
+	DeleteRestrict DeletePolicy = "restrict"
+)
+
+// CompletePolicy decides whether a task can be done before its subtasks.
+type CompletePolicy string
+
+const (
+	CompleteAllow    CompletePolicy = "allow"
+	CompleteRestrict CompletePolicy = "restrict"
+)
+
+// SubtaskPolicy is configured by subtasks.on_delete and subtasks.on_complete.
+type SubtaskPolicy struct {
+	OnDelete   DeletePolicy
+	OnComplete CompletePolicy
+}
+
+// WithSubtaskPolicy replaces the default policy (cascade, allow).
+func (r *TaskRepository) WithSubtaskPolicy(p SubtaskPolicy) *TaskRepository {
+	r.subtasks = p
+	return r
+}
+
+// TaskNode is a task with its subtasks loaded to some depth.
+type TaskNode struct {
+	Task     model.Task
+	Children []*TaskNode
+}
+
+// Progress counts the descendants of a task. Cancelled descendants do not
+// count towards completion.
+type Progress struct {
+	Descendants int
+	Done        int
+	Cancelled   int
+}
+
+// Percent is the share of done descendants among those not cancelled, or
+// 100 when every descendant was cancelled.
+func (p Progress) Percent() int {
+	counted := p.Descendants - p.Cancelled
+	if counted == 0 {
+		return 100
+	}
+	return p.Done * 100 / counted
+}
+
+// GetTaskTree returns task id with its subtasks, depth levels deep, each
+// level ordered by rank.
+func (r *TaskRepository) GetTaskTree(ctx context.Context, scope Scope, id uint, depth int) (*TaskNode, error) {
+	task, err := r.GetTaskByID(ctx, scope, id)
+	if err != nil {
+		return nil, err
+	}
+	root := &TaskNode{Task: *task}
+	level := []*TaskNode{root}
+	for d := 0; d < depth && len(level) > 0; d++ {
+		byID := make(map[uint]*TaskNode, len(level))
+		ids := make([]uint, 0, len(level))
+		for _, node := range level {
+			byID[node.Task.ID] = node
+			ids = append(ids, node.Task.ID)
+		}
+		children, err := r.children(ctx, scope, ids, false)
+		if err != nil {
+			return nil, err
+		}
+		level = nil
+		for _, child := range children {
+			node := &TaskNode{Task: child}
+			parent := byID[*child.ParentID]
+			parent.Children = append(parent.Children, node)
+			level = append(level, node)
+		}
+	}
+	return root, nil
+}
+
+// progressQuery rolls the subtree of every root up in the database, reading
+// only ids, parents and statuses. UNION drops repeated rows, so the walk
+// ends even if the parent links ever formed a cycle.
+const progressQuery = `WITH RECURSIVE tree (root_id, id, status) AS (
+	SELECT parent_id, id, status FROM tasks
+	WHERE parent_id IN ? AND workspace_id = ? AND deleted_at IS NULL
+	UNION
+	SELECT tree.root_id, tasks.id, tasks.status FROM tasks JOIN tree ON tasks.parent_id = tree.id
+	WHERE tasks.workspace_id = ? AND tasks.deleted_at IS NULL AND tasks.id <> tree.root_id
+)
+SELECT root_id, COUNT(*) AS descendants,
+	COUNT(CASE WHEN status = ? THEN 1 END) AS done,
+	COUNT(CASE WHEN status = ? THEN 1 END) AS cancelled
+FROM tree GROUP BY root_id`
+
+// TaskProgress rolls up the descendants of each of ids; tasks without
+// subtasks are left out of the result. ids that are ancestors of one
+// another are each counted on their own.
+func (r *TaskRepository) TaskProgress(ctx context.Context, scope Scope, ids []uint) (map[uint]Progress, error) {
+	progress := make(map[uint]Progress)
+	if len(ids) == 0 {
+		return progress, nil
+	}
+	var rows []struct {
+		RootID uint
+		Progress
+	}
+	err := r.db.WithContext(ctx).Raw(progressQuery, ids, scope.WorkspaceID, scope.WorkspaceID, model.StatusDone, model.StatusCancelled).Scan(&rows).Error
+	if err != nil {
+		return nil, dbError(ctx, "TaskProgress", err)
+	}
+	for _, row := range rows {
+		progress[row.RootID] = row.Progress
+	}
+	return progress, nil
+}
+
+// children lists the direct subtasks of parents, ordered by rank.
+func (r *TaskRepository) children(ctx context.Context, scope Scope, parents []uint, unscoped bool) ([]model.Task, error) {
+	db := r.db.WithContext(ctx)
+	if unscoped {
+		db = db.Unscoped()
+	}
+	var tasks []model.Task
+	if err := scope.tenant(db).Where("parent_id IN ?", parents).Order("position").Order("id").Find(&tasks).Error; err != nil {
+		return nil, dbError(ctx, "children", err)
+	}
+	return tasks, nil
+}
+
+// descendants lists every subtask below id that is not in the trash.
+func (r *TaskRepository) descendants(ctx context.Context, scope Scope, id uint) ([]model.Task, error) {
+	var all []model.Task
+	seen := map[uint]bool{id: true}
+	for level := []uint{id}; len(level) > 0; {
+		children, err := r.children(ctx, scope, level, false)
+		if err != nil {
+			return nil, err
+		}
+		level = nil
+		for _, child := range children {
+			if !seen[child.ID] {
+				seen[child.ID] = true
+				all = append(all, child)
+				level = append(level, child.ID)
+			}
+		}
+	}
+	return all, nil
+}
+
+// checkParent verifies that task id (0 for a new task) may become a
+// subtask of parentID: the parent exists and is not id or below it.
+func (r *TaskRepository) checkParent(ctx context.Context, scope Scope, id uint, parentID uint) error {
+	if parentID == id {
+		return fmt.Errorf("%w: a task cannot be its own parent", ErrInvalidParent)
+	}
+	// 沿著 parent 往上走，若遇到自己就會形成循環
+	seen := map[uint]bool{}
+	for ancestor := &parentID; ancestor != nil; {
+		if *ancestor == id {
+			return fmt.Errorf("%w: task %d is a subtask of task %d", ErrInvalidParent, parentID, id)
+		}
+		if seen[*ancestor] {
+			break
+		}
+		seen[*ancestor] = true
+		var task model.Task
+		err := scope.tenant(r.db.WithContext(ctx)).Select("id", "parent_id").Where("id = ?", *ancestor).First(&task).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if *ancestor == parentID {
+				return fmt.Errorf("%w: task %d not found", ErrInvalidParent, parentID)
+			}
+			break
+		}
+		if err != nil {
+			return dbError(ctx, "checkParent", err, "task_id", id)
+		}
+		ancestor = task.ParentID
+	}
+	return nil
+}
+
+// openSubtasks reports whether any subtask below id is neither done nor cancelled.
+func (r *TaskRepository) openSubtasks(ctx context.Context, scope Scope, id uint) (bool, error) {
+	tasks, err := r.descendants(ctx, scope, id)
+	if err != nil {
+		return false, err
+	}
+	for _, task := range tasks {
+		if !task.Status.Closed() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// deleteSubtasks applies the delete policy to the subtasks of task, which
+// was just moved to the trash at task.DeletedAt.
+func (r *TaskRepository) deleteSubtasks(ctx context.Context, scope Scope, task *model.Task) error {
+	switch r.subtasks.OnDelete {
+	case DeleteOrphan:
+		children, err := r.children(ctx, scope, []uint{task.ID}, true)
+		if err != nil {
+			return err
+		}
+		for i := range children {
+			child := &children[i]
+			before := *child
+			err := scope.tenant(r.db.WithContext(ctx).Unscoped().Model(&model.Task{})).Where("id = ?", child.ID).
+				Updates(map[string]interface{}{"parent_id": nil, "version": gorm.Expr("version + 1")}).Error
+			if err != nil {
+				return dbError(ctx, "deleteSubtasks", err, "task_id", child.ID)
+			}
+			child.ParentID = nil
+			child.Version++
+			if err := r.recordEvent(ctx, model.TaskUpdated, child, diff(&before, child, map[string]interface{}{"parent_id": nil})); err != nil {
+				return err
+			}
+		}
+	case DeleteCascade:
+		tasks, err := r.descendants(ctx, scope, task.ID)
+		if err != nil || len(tasks) == 0 {
+			return err
+		}
+		ids := make([]uint, len(tasks))
+		for i, t := range tasks {
+			ids[i] = t.ID
+		}
+		// 與 parent 使用相同的 deleted_at，還原 parent 時據此一併還原
+		if err := scope.tenant(r.db.WithContext(ctx).Model(&model.Task{})).Where("id IN ?", ids).
+			UpdateColumns(map[string]interface{}{"deleted_at": task.DeletedAt, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return dbError(ctx, "deleteSubtasks", err, "task_id", task.ID)
+		}
+		for i := range tasks {
+			tasks[i].DeletedAt = task.DeletedAt
+			tasks[i].Version++
+			if err := r.recordEvent(ctx, model.TaskDeleted, &tasks[i], nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restoreSubtasks takes the subtasks that were cascaded into the trash with
+// task (same deleted_at) out of it again.
+func (r *TaskRepository) restoreSubtasks(ctx context.Context, scope Scope, task *model.Task, deletedAt gorm.DeletedAt) error {
+	for level := []uint{task.ID}; len(level) > 0; {
+		children, err := r.children(ctx, scope, level, true)
+		if err != nil {
+			return err
+		}
+		level = nil
+		for i := range children {
+			child := &children[i]
+			if !child.DeletedAt.Valid || !child.DeletedAt.Time.Equal(deletedAt.Time) {
+				continue
+			}
+			err := scope.tenant(r.db.WithContext(ctx).Unscoped().Model(&model.Task{})).Where("id = ?", child.ID).
+				Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error
+			if err != nil {
+				return dbError(ctx, "restoreSubtasks", err, "task_id", child.ID)
+			}
+			child.DeletedAt = gorm.DeletedAt{}
+			child.Version++
+			if err := r.recordEvent(ctx, model.TaskRestored, child, nil); err != nil {
+				return err
+			}
+			level = append(level, child.ID)
+		}
+	}
+	return nil
+}
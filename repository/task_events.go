@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"task-api/model"
+	"task-api/pkg/actor"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// auditedColumns are the task columns whose changes are recorded in events.
+var auditedColumns = map[string]func(t *model.Task) interface{}{
+	"name":     func(t *model.Task) interface{} { return t.Name },
+	"status":   func(t *model.Task) interface{} { return t.Status },
+	"priority": func(t *model.Task) interface{} { return t.Priority.String() },
+	"position": func(t *model.Task) interface{} { return t.Rank },
+	"parent_id": func(t *model.Task) interface{} {
+		if t.ParentID == nil {
+			return nil
+		}
+		return *t.ParentID
+	},
+	"assignee":   func(t *model.Task) interface{} { return t.Assignee },
+	"tags":       func(t *model.Task) interface{} { return []string(t.Tags) },
+	"recurrence": func(t *model.Task) interface{} { return t.Recurrence },
+	"reminders":  func(t *model.Task) interface{} { return []string(t.Reminders) },
+	"due_date": func(t *model.Task) interface{} {
+		if t.DueDate == nil {
+			return nil
+		}
+		return *t.DueDate
+	},
+	"completed_at": func(t *model.Task) interface{} {
+		if t.CompletedAt == nil {
+			return nil
+		}
+		return *t.CompletedAt
+	},
+	"overdue_at": func(t *model.Task) interface{} {
+		if t.OverdueAt == nil {
+			return nil
+		}
+		return *t.OverdueAt
+	},
+}
+
+// changeNames renames the columns the API calls differently in recorded
+// changes.
+var changeNames = map[string]string{"position": "rank"}
+
+func changeName(column string) string {
+	if name, ok := changeNames[column]; ok {
+		return name
+	}
+	return column
+}
+
+// TaskEventPage is one page of a task's history, oldest first.
+type TaskEventPage struct {
+	Events     []model.TaskEvent
+	NextCursor string
+}
+
+// ListTaskEvents returns the history of task id, including tasks in the trash.
+func (r *TaskRepository) ListTaskEvents(ctx context.Context, scope Scope, id uint, cursor string, limit int) (*TaskEventPage, error) {
+	after, err := parseIDCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	// 已刪除（在垃圾桶中）的 task 仍可查詢歷史
+	var task model.Task
+	if err := scope.tenant(r.db.WithContext(ctx).Unscoped()).Select("id").Where("id = ?", id).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, dbError(ctx, "ListTaskEvents", err, "task_id", id)
+	}
+
+	var events []model.TaskEvent
+	err = scope.tenant(r.db.WithContext(ctx)).
+		Where("task_id = ? AND id > ?", id, after).
+		Order("id").Limit(limit + 1).Find(&events).Error
+	if err != nil {
+		return nil, dbError(ctx, "ListTaskEvents", err, "task_id", id)
+	}
+
+	page := &TaskEventPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		page.NextCursor = idCursor(page.Events[limit-1].ID)
+	}
+	return page, nil
+}
+
+// TaskChange is a recorded event together with its task as it is now, which
+// may be newer than the event.
+type TaskChange struct {
+	Event model.TaskEvent
+	Name  string // the webhook event name, e.g. task.completed
+	Task  model.Task
+}
+
+// TaskChangePage is a batch of the workspace's events in Seq order.
+// LastSeq is the last event examined, including events filtered out, so the
+// next batch continues after it.
+type TaskChangePage struct {
+	Changes []TaskChange
+	LastSeq uint
+}
+
+// ListTaskChanges returns up to query.Limit events of the workspace after
+// the sequence number after whose task matches the filters of query. The
+// filters are matched against the task as it is now, not as it was after the
+// event: a task that no longer matches takes its earlier events with it, and
+// one that has come to match brings them along.
+func (r *TaskRepository) ListTaskChanges(ctx context.Context, scope Scope, query TaskQuery, after uint) (*TaskChangePage, error) {
+	limit := query.Limit
+	if limit <= 0 || limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	var events []model.TaskEvent
+	err := scope.tenant(r.db.WithContext(ctx)).Where("seq > ?", after).
+		Order("seq").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, dbError(ctx, "ListTaskChanges", err)
+	}
+	page := &TaskChangePage{LastSeq: after}
+	if len(events) == 0 {
+		return page, nil
+	}
+	page.LastSeq = events[len(events)-1].Seq
+
+	ids := make([]uint, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.TaskID)
+	}
+	// 已刪除的 task 也要比對，刪除事件才送得出去
+	var tasks []model.Task
+	db := applyFilters(scope.tenant(r.db.WithContext(ctx).Unscoped().Model(&model.Task{})), query)
+	if err := db.Where("id IN ?", ids).Find(&tasks).Error; err != nil {
+		return nil, dbError(ctx, "ListTaskChanges", err)
+	}
+	byID := make(map[uint]*model.Task, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+	for i := range events {
+		if task, ok := byID[events[i].TaskID]; ok {
+			page.Changes = append(page.Changes, TaskChange{Event: events[i], Name: webhookEvent(&events[i]), Task: *task})
+		}
+	}
+	return page, nil
+}
+
+// LatestTaskEventSeq is the sequence number of the newest event of the
+// workspace, 0 if there is none.
+func (r *TaskRepository) LatestTaskEventSeq(ctx context.Context, scope Scope) (uint, error) {
+	var seq uint
+	err := r.db.WithContext(ctx).Model(&model.TaskEventSequence{}).
+		Where("workspace_id = ?", scope.WorkspaceID).Select("COALESCE(MAX(seq), 0)").Scan(&seq).Error
+	if err != nil {
+		return 0, dbError(ctx, "LatestTaskEventSeq", err)
+	}
+	return seq, nil
+}
+
+// sequenceEvents numbers the events of changes, which the transaction of r
+// recorded, in TaskEventSequence. It runs right before the commit: the row
+// lock on the counter then makes the other writers of the workspace wait
+// only for the commit, and numbers become visible in order, so a reader that
+// has seen one has seen all lower ones.
+func (r *TaskRepository) sequenceEvents(ctx context.Context, changes []TaskChange) error {
+	var workspaces []uint
+	counts := map[uint]uint{}
+	for i := range changes {
+		id := changes[i].Event.WorkspaceID
+		if counts[id] == 0 {
+			workspaces = append(workspaces, id)
+		}
+		counts[id]++
+	}
+	// 固定的上鎖順序，避免兩個交易互相等待
+	slices.Sort(workspaces)
+	for _, id := range workspaces {
+		last, err := r.bumpSequence(ctx, id, counts[id])
+		if err != nil {
+			return dbError(ctx, "sequenceEvents", err, "workspace_id", id)
+		}
+		seq := last - counts[id]
+		for i := range changes {
+			event := &changes[i].Event
+			if event.WorkspaceID != id {
+				continue
+			}
+			seq++
+			event.Seq = seq
+			if err := r.db.WithContext(ctx).Model(event).Update("seq", seq).Error; err != nil {
+				return dbError(ctx, "sequenceEvents", err, "event_id", event.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// bumpSequence adds n to the counter of the workspace and returns the new
+// value, creating the counter on the workspace's first event.
+func (r *TaskRepository) bumpSequence(ctx context.Context, workspaceID uint, n uint) (uint, error) {
+	db := r.db.WithContext(ctx)
+	bump := func() (int64, error) {
+		result := db.Model(&model.TaskEventSequence{}).Where("workspace_id = ?", workspaceID).
+			Update("seq", gorm.Expr("seq + ?", n))
+		return result.RowsAffected, result.Error
+	}
+	bumped, err := bump()
+	if err == nil && bumped == 0 {
+		// 同時建立計數器時只有一個 INSERT 生效，另一個等它提交後再加
+		err = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.TaskEventSequence{WorkspaceID: workspaceID}).Error
+		if err == nil {
+			_, err = bump()
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	var seq uint
+	err = db.Model(&model.TaskEventSequence{}).Where("workspace_id = ?", workspaceID).Select("seq").Scan(&seq).Error
+	return seq, err
+}
+
+// idCursor is an opaque cursor for pages ordered by id.
+func idCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+// parseIDCursor reads an idCursor; the empty cursor is 0.
+func parseIDCursor(cursor string) (uint64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// recordEvent appends an event for task, which must hold the state after the
+// change, and queues it for the workspace's webhooks and the publisher.
+func (r *TaskRepository) recordEvent(ctx context.Context, typ string, task *model.Task, changes model.Changes) error {
+	event := model.TaskEvent{
+		WorkspaceID: task.WorkspaceID,
+		TaskID:      task.ID,
+		Type:        typ,
+		Actor:       actor.FromContext(ctx),
+		Version:     task.Version,
+		Changes:     changes,
+	}
+	if err := r.db.WithContext(ctx).Create(&event).Error; err != nil {
+		return dbError(ctx, "recordEvent", err, "task_id", task.ID)
+	}
+	change := TaskChange{Event: event, Name: webhookEvent(&event), Task: *task}
+	r.published(change)
+	return r.enqueueWebhooks(ctx, &change)
+}
+
+// createdChanges lists the initial value of every field that was set.
+func createdChanges(task *model.Task) model.Changes {
+	changes := model.Changes{}
+	for column, value := range auditedColumns {
+		v := value(task)
+		if v != nil && !reflect.ValueOf(v).IsZero() {
+			changes[changeName(column)] = model.Change{After: v}
+		}
+	}
+	return changes
+}
+
+// diff compares the audited columns named in fields before and after an update.
+func diff(before, after *model.Task, fields map[string]interface{}) model.Changes {
+	changes := model.Changes{}
+	for column := range fields {
+		value, ok := auditedColumns[column]
+		if !ok {
+			continue
+		}
+		b, a := value(before), value(after)
+		if !sameJSON(b, a) {
+			changes[changeName(column)] = model.Change{Before: b, After: a}
+		}
+	}
+	return changes
+}
+
+// sameJSON compares values by their JSON encoding, so that e.g. a nil and an
+// empty tag list, or equal times in different locations, are not a change.
+func sameJSON(a, b interface{}) bool {
+	normalize := func(v interface{}) string {
+		raw, _ := json.Marshal(v)
+		if s := string(raw); s != "[]" {
+			return s
+		}
+		return "null"
+	}
+	return normalize(a) == normalize(b)
+}
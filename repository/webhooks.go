@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"task-api/model"
+
+	"gorm.io/gorm"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error) {
+	if err := r.db.WithContext(ctx).Create(webhook).Error; err != nil {
+		return nil, dbError(ctx, "CreateWebhook", err)
+	}
+	return webhook, nil
+}
+
+// ListWebhooks returns the webhooks of a workspace, oldest first.
+func (r *WebhookRepository) ListWebhooks(ctx context.Context, workspaceID uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := r.db.WithContext(ctx).Where("workspace_id = ?", workspaceID).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, dbError(ctx, "ListWebhooks", err, "workspace_id", workspaceID)
+	}
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) GetWebhook(ctx context.Context, workspaceID uint, id uint) (*model.Webhook, error) {
+	var webhook model.Webhook
+	if err := r.db.WithContext(ctx).Where("workspace_id = ? AND id = ?", workspaceID, id).First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, dbError(ctx, "GetWebhook", err, "webhook_id", id)
+	}
+	return &webhook, nil
+}
+
+// DeleteWebhook removes a webhook together with its delivery log, which
+// also cancels the deliveries still pending.
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, workspaceID uint, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("workspace_id = ? AND id = ?", workspaceID, id).Delete(&model.Webhook{})
+		if result.Error != nil {
+			return dbError(ctx, "DeleteWebhook", result.Error, "webhook_id", id)
+		}
+		if result.RowsAffected == 0 {
+			return ErrWebhookNotFound
+		}
+		if err := tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return dbError(ctx, "DeleteWebhook", err, "webhook_id", id)
+		}
+		return nil
+	})
+}
+
+// WebhookDeliveryPage is one page of a webhook's delivery log, newest first.
+type WebhookDeliveryPage struct {
+	Deliveries []model.WebhookDelivery
+	NextCursor string
+}
+
+// ListWebhookDeliveries returns the deliveries of webhook id, optionally
+// only those with the given status.
+func (r *WebhookRepository) ListWebhookDeliveries(ctx context.Context, workspaceID uint, id uint, status string, cursor string, limit int) (*WebhookDeliveryPage, error) {
+	before, err := parseIDCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	if _, err := r.GetWebhook(ctx, workspaceID, id); err != nil {
+		return nil, err
+	}
+
+	db := r.db.WithContext(ctx).Where("webhook_id = ?", id)
+	if before > 0 {
+		db = db.Where("id < ?", before)
+	}
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	var deliveries []model.WebhookDelivery
+	if err := db.Order("id DESC").Limit(limit + 1).Find(&deliveries).Error; err != nil {
+		return nil, dbError(ctx, "ListWebhookDeliveries", err, "webhook_id", id)
+	}
+
+	page := &WebhookDeliveryPage{Deliveries: deliveries}
+	if len(deliveries) > limit {
+		page.Deliveries = deliveries[:limit]
+		page.NextCursor = idCursor(page.Deliveries[limit-1].ID)
+	}
+	return page, nil
+}
+
+// PendingDelivery is a delivery claimed by ClaimWebhookDeliveries, with
+// where to send it and how to sign it.
+type PendingDelivery struct {
+	model.WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// ClaimWebhookDeliveries claims up to limit pending deliveries of every
+// workspace that are due at now, counting the attempt. A claim is a lease:
+// the delivery is not handed out again before now+lease, so a dispatcher
+// that dies mid-attempt only delays it. The caller reports the outcome with
+// SaveWebhookDelivery.
+func (r *WebhookRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]PendingDelivery, error) {
+	now = now.UTC()
+	var due []model.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("status = ? AND next_attempt_at <= ?", model.DeliveryPending, now).
+		Order("next_attempt_at, id").Limit(limit).Find(&due).Error
+	if err != nil {
+		return nil, dbError(ctx, "ClaimWebhookDeliveries", err)
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, 0, len(due))
+	for _, delivery := range due {
+		ids = append(ids, delivery.WebhookID)
+	}
+	var webhooks []model.Webhook
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&webhooks).Error; err != nil {
+		return nil, dbError(ctx, "ClaimWebhookDeliveries", err)
+	}
+	byID := make(map[uint]*model.Webhook, len(webhooks))
+	for i := range webhooks {
+		byID[webhooks[i].ID] = &webhooks[i]
+	}
+
+	var claimed []PendingDelivery
+	for _, delivery := range due {
+		webhook, ok := byID[delivery.WebhookID]
+		if !ok {
+			continue
+		}
+		// 以 next_attempt_at 為條件更新，同時執行的其他 dispatcher 只有一個會成功
+		result := r.db.WithContext(ctx).Model(&model.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, model.DeliveryPending, delivery.NextAttemptAt).
+			Updates(map[string]interface{}{"next_attempt_at": now.Add(lease), "attempts": gorm.Expr("attempts + 1")})
+		if result.Error != nil {
+			return claimed, dbError(ctx, "ClaimWebhookDeliveries", result.Error, "delivery_id", delivery.ID)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		delivery.Attempts++
+		delivery.NextAttemptAt = now.Add(lease)
+		claimed = append(claimed, PendingDelivery{WebhookDelivery: delivery, URL: webhook.URL, Secret: webhook.Secret})
+	}
+	return claimed, nil
+}
+
+// SaveWebhookDelivery records the outcome of an attempt: the status, when
+// to try again, and the response or error.
+func (r *WebhookRepository) SaveWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	err := r.db.WithContext(ctx).Model(delivery).
+		Select("status", "next_attempt_at", "response_status", "last_error", "delivered_at").
+		Updates(delivery).Error
+	if err != nil {
+		return dbError(ctx, "SaveWebhookDelivery", err, "delivery_id", delivery.ID)
+	}
+	return nil
+}
+
+// WebhookEncoder renders the body of the webhook deliveries queued for a
+// change. The repository stores it as is, so every attempt sends (and
+// signs) the same bytes; pkg/webhook provides the encoder.
+type WebhookEncoder func(change *TaskChange) ([]byte, error)
+
+// WithWebhooks makes the repository queue a delivery, encoded by encode, for
+// every webhook subscribed to a change. Without it nothing is queued.
+func (r *TaskRepository) WithWebhooks(encode WebhookEncoder) *TaskRepository {
+	r.webhooks = encode
+	return r
+}
+
+// enqueueWebhooks queues change for every webhook of its workspace that
+// subscribed to it. It runs in the transaction of the change, so
+// rolled-back changes are never announced.
+func (r *TaskRepository) enqueueWebhooks(ctx context.Context, change *TaskChange) error {
+	if r.webhooks == nil {
+		return nil
+	}
+	event := &change.Event
+	var webhooks []model.Webhook
+	if err := r.db.WithContext(ctx).Where("workspace_id = ?", event.WorkspaceID).Order("id").Find(&webhooks).Error; err != nil {
+		return dbError(ctx, "enqueueWebhooks", err, "task_id", event.TaskID)
+	}
+	var deliveries []model.WebhookDelivery
+	for i := range webhooks {
+		if webhooks[i].Wants(change.Name) {
+			deliveries = append(deliveries, model.WebhookDelivery{WorkspaceID: event.WorkspaceID, WebhookID: webhooks[i].ID})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	payload, err := r.webhooks(change)
+	if err != nil {
+		return err
+	}
+	for i := range deliveries {
+		deliveries[i].TaskID = event.TaskID
+		deliveries[i].Event = change.Name
+		deliveries[i].Payload = string(payload)
+		deliveries[i].Status = model.DeliveryPending
+		deliveries[i].NextAttemptAt = event.CreatedAt
+	}
+	if err := r.db.WithContext(ctx).Create(&deliveries).Error; err != nil {
+		return dbError(ctx, "enqueueWebhooks", err, "task_id", event.TaskID)
+	}
+	return nil
+}
+
+// webhookEvent names the webhook event for a task event: an update that
+// moves the task to done is task.completed, and restoring, reopening and
+// marking overdue are updates.
+func webhookEvent(event *model.TaskEvent) string {
+	switch event.Type {
+	case model.TaskCreated:
+		return model.WebhookTaskCreated
+	case model.TaskDeleted:
+		return model.WebhookTaskDeleted
+	}
+	// After 是 model.Status，從資料庫讀回的事件則是 string
+	if change, ok := event.Changes["status"]; ok && fmt.Sprint(change.After) == string(model.StatusDone) {
+		return model.WebhookTaskCompleted
+	}
+	return model.WebhookTaskUpdated
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"task-api/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	// ErrWorkspaceExists is returned when another workspace has the same name.
+	ErrWorkspaceExists = errors.New("workspace already exists")
+)
+
+// DefaultWorkspaceID is the workspace that pre-existing tasks were moved into.
+const DefaultWorkspaceID = 1
+
+type WorkspaceRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkspaceRepository(db *gorm.DB) *WorkspaceRepository {
+	return &WorkspaceRepository{db: db}
+}
+
+func (r *WorkspaceRepository) CreateWorkspace(ctx context.Context, ws *model.Workspace) (*model.Workspace, error) {
+	if err := r.db.WithContext(ctx).Create(ws).Error; err != nil {
+		if isDuplicate(r.db, err) {
+			return nil, ErrWorkspaceExists
+		}
+		return nil, dbError(ctx, "CreateWorkspace", err)
+	}
+	return ws, nil
+}
+
+func (r *WorkspaceRepository) GetWorkspace(ctx context.Context, id uint) (*model.Workspace, error) {
+	var ws model.Workspace
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&ws).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, dbError(ctx, "GetWorkspace", err, "workspace_id", id)
+	}
+	return &ws, nil
+}
+
+// ListWorkspaces returns the workspaces with the given ids, or all of them
+// when ids is nil.
+func (r *WorkspaceRepository) ListWorkspaces(ctx context.Context, ids []uint) ([]model.Workspace, error) {
+	db := r.db.WithContext(ctx).Order("id")
+	if ids != nil {
+		if len(ids) == 0 {
+			return []model.Workspace{}, nil
+		}
+		db = db.Where("id IN ?", ids)
+	}
+	var list []model.Workspace
+	if err := db.Find(&list).Error; err != nil {
+		return nil, dbError(ctx, "ListWorkspaces", err)
+	}
+	return list, nil
+}
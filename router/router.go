@@ -1,18 +1,107 @@
 package router
 
 import (
+	"log/slog"
+
+	"task-api/config"
 	"task-api/handler"
+	"task-api/middleware"
+	"task-api/pkg/auth"
+	"task-api/pkg/metrics"
 
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRouter(handler *handler.TaskHandler) *gin.Engine {
-	r := gin.Default()
+type Handlers struct {
+	Task      *handler.TaskHandler
+	Workspace *handler.WorkspaceHandler
+	APIKey    *handler.APIKeyHandler   // optional
+	Webhook   *handler.WebhookHandler  // optional
+	Stream    *handler.StreamHandler   // optional
+	Realtime  *handler.RealtimeHandler // optional
+	Health    *handler.HealthHandler
+	Metrics   *metrics.Metrics    // optional
+	Auth      *auth.Authenticator // nil leaves the API unauthenticated
+}
+
+func SetupRouter(h Handlers, cfg *config.Config) *gin.Engine {
+	gin.SetMode(cfg.Server.Mode)
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.AccessLog(slog.Default()), middleware.Recovery())
+
+	if h.Metrics != nil {
+		r.Use(h.Metrics.Middleware())
+		r.GET("/metrics", gin.WrapH(h.Metrics.Handler()))
+	}
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		r.Use(middleware.CORS(cfg.CORS.AllowedOrigins))
+	}
+	r.Use(middleware.BodyLimit(cfg.Limits.MaxBodyBytes))
+
+	r.GET("/healthz", h.Health.Healthz)
+	r.GET("/readyz", h.Health.Readyz)
+
+	// 健康檢查、metrics 與 swagger 不需要驗證，其餘 API 都掛在 api group 下
+	api := r.Group("/")
+	if h.Auth != nil {
+		api.Use(middleware.Authenticate(h.Auth))
+	}
+
+	// 各路由需要的權限；角色與權限的對應定義在 auth.policy
+	can := middleware.Authorize
+	api.POST("/workspaces", can(auth.PermWorkspacesManage), h.Workspace.CreateWorkspace)
+	api.GET("/workspaces", can(auth.PermTaskRead), h.Workspace.ListWorkspaces)
+
+	// task 一律掛在 workspace 底下，Resolve 會檢查呼叫者能否存取該 workspace
+	ws := api.Group("/workspaces/:ws", h.Workspace.Resolve)
+	ws.GET("", can(auth.PermTaskRead), h.Workspace.GetWorkspace)
+	ws.POST("/tasks", can(auth.PermTaskCreate), h.Task.CreateTask)
+	// POST /tasks:batch；每個 operation 各自檢查權限
+	ws.POST("/tasks:action", h.Task.BatchTasks)
+	ws.GET("/tasks", can(auth.PermTaskRead), h.Task.GetTasks)
+	ws.GET("/tasks/:id", can(auth.PermTaskRead), h.Task.GetTask)
+	if h.Stream != nil {
+		ws.GET("/tasks/stream", can(auth.PermTaskRead), h.Stream.StreamTasks)
+	}
+	ws.PUT("/tasks/:id", can(auth.PermTaskUpdate), h.Task.UpdateTask)
+	ws.PATCH("/tasks/:id", can(auth.PermTaskUpdate), h.Task.PatchTask)
+	ws.DELETE("/tasks/:id", can(auth.PermTaskDelete), h.Task.DeleteTask)
+	ws.POST("/tasks/:id/restore", can(auth.PermTaskDelete), h.Task.RestoreTask)
+	ws.POST("/tasks/:id/reopen", can(auth.PermTaskUpdate), h.Task.ReopenTask)
+	ws.POST("/tasks/:id/move", can(auth.PermTaskUpdate), h.Task.MoveTask)
+	ws.GET("/tasks/:id/history", can(auth.PermTaskRead), h.Task.GetTaskHistory)
+	ws.GET("/tasks/:id/subtasks", can(auth.PermTaskRead), h.Task.GetSubtasks)
+	ws.GET("/tasks/:id/tree", can(auth.PermTaskRead), h.Task.GetTaskTree)
+	ws.POST("/tasks/:id/dependencies", can(auth.PermTaskUpdate), h.Task.AddDependency)
+	ws.DELETE("/tasks/:id/dependencies/:blocker_id", can(auth.PermTaskUpdate), h.Task.RemoveDependency)
+	ws.GET("/tasks/:id/graph", can(auth.PermTaskRead), h.Task.GetTaskGraph)
+	ws.GET("/tasks/:id/series", can(auth.PermTaskRead), h.Task.GetSeries)
+	ws.PATCH("/tasks/:id/series", can(auth.PermTaskUpdate), h.Task.UpdateSeries)
+	ws.GET("/trash", can(auth.PermTaskRead), h.Task.GetTrash)
+	if h.Webhook != nil {
+		ws.POST("/webhooks", can(auth.PermWebhooksManage), h.Webhook.CreateWebhook)
+		ws.GET("/webhooks", can(auth.PermWebhooksManage), h.Webhook.ListWebhooks)
+		ws.GET("/webhooks/:id", can(auth.PermWebhooksManage), h.Webhook.GetWebhook)
+		ws.DELETE("/webhooks/:id", can(auth.PermWebhooksManage), h.Webhook.DeleteWebhook)
+		ws.GET("/webhooks/:id/deliveries", can(auth.PermWebhooksManage), h.Webhook.ListWebhookDeliveries)
+	}
+
+	if h.Realtime != nil {
+		// 連線後才以訊息訂閱 workspace，各訂閱分別檢查存取權
+		api.GET("/realtime", can(auth.PermTaskRead), h.Realtime.Connect)
+	}
+
+	if h.APIKey != nil {
+		api.POST("/api-keys", can(auth.PermAPIKeysManage), h.APIKey.CreateAPIKey)
+		api.GET("/api-keys", can(auth.PermAPIKeysManage), h.APIKey.ListAPIKeys)
+		api.DELETE("/api-keys/:id", can(auth.PermAPIKeysManage), h.APIKey.RevokeAPIKey)
+	}
 
-	r.POST("/tasks", handler.CreateTask)
-	r.GET("/tasks", handler.GetTasks)
-	r.PUT("/tasks/:id", handler.UpdateTask)
-	r.DELETE("/tasks/:id", handler.DeleteTask)
+	if cfg.Server.Swagger {
+		r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	}
 
 	return r
 }
@@ -0,0 +1,175 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/handler"
+	"task-api/pkg/auth"
+	"task-api/pkg/health"
+	"task-api/repository"
+	"task-api/router"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var hmacSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// writeJWKS writes a JWKS with an HS256 key "hs" and an RS256 key "rs".
+func writeJWKS(t *testing.T, rsaKey *rsa.PrivateKey) string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	set := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "oct", "kid": "hs", "alg": "HS256", "k": b64(hmacSecret)},
+		{"kty": "RSA", "kid": "rs", "alg": "RS256", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+	}}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func setupAuthRouter(t *testing.T) (http.Handler, *repository.APIKeyRepository, *rsa.PrivateKey) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwks, err := auth.LoadJWKS(writeJWKS(t, rsaKey))
+	require.NoError(t, err)
+
+	db := openDB(t, "sqlite")
+	keys := repository.NewAPIKeyRepository(db)
+	workspaces := repository.NewWorkspaceRepository(db)
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	r := router.SetupRouter(router.Handlers{
+		Task:      handler.NewTaskHandler(&mockRepo{}, cfg.Limits, cfg.Concurrency),
+		Workspace: handler.NewWorkspaceHandler(workspaces),
+		APIKey:    handler.NewAPIKeyHandler(keys, workspaces),
+		Health:    handler.NewHealthHandler(&health.Readiness{}, nil, nil),
+		Auth:      auth.New(keys, jwks, "task-api-test", ""),
+	}, cfg)
+	return r, keys, rsaKey
+}
+
+func request(r http.Handler, method, path, authorization string, body []byte) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuth_RequiresCredentials(t *testing.T) {
+	r, _, _ := setupAuthRouter(t)
+
+	w := request(r, "GET", "/workspaces/1/tasks", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+	var body dto.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.NotEmpty(t, body.RequestID)
+
+	assert.Equal(t, http.StatusUnauthorized, request(r, "GET", "/workspaces/1/tasks", "Bearer tk_unknown", nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, request(r, "GET", "/workspaces/1/tasks", "Basic Zm9vOmJhcg==", nil).Code)
+	// probes stay public
+	assert.Equal(t, http.StatusOK, request(r, "GET", "/healthz", "", nil).Code)
+}
+
+func TestAuth_APIKeyLifecycle(t *testing.T) {
+	r, keys, _ := setupAuthRouter(t)
+	_, bootstrap, err := auth.IssueKey(context.Background(), keys, "bootstrap", auth.RoleAdmin, nil)
+	require.NoError(t, err)
+
+	w := request(r, "POST", "/api-keys", "Bearer "+bootstrap, []byte(`{"name":"ci"}`))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created dto.CreatedAPIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "ci", created.Name)
+	assert.Equal(t, created.Key[:len(created.Prefix)], created.Prefix)
+
+	req, _ := http.NewRequest("GET", "/workspaces/1/tasks", nil)
+	req.Header.Set("X-API-Key", created.Key)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// keys created over HTTP default to member, which can't manage keys
+	assert.Equal(t, "member", created.Role)
+	assert.Equal(t, http.StatusForbidden, request(r, "GET", "/api-keys", "Bearer "+created.Key, nil).Code)
+
+	w = request(r, "GET", "/api-keys", "Bearer "+bootstrap, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Key)
+	assert.NotContains(t, w.Body.String(), auth.HashKey(created.Key))
+
+	assert.Equal(t, http.StatusNoContent, request(r, "DELETE", "/api-keys/2", "Bearer "+bootstrap, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, request(r, "GET", "/workspaces/1/tasks", "Bearer "+created.Key, nil).Code)
+	assert.Equal(t, http.StatusNotFound, request(r, "DELETE", "/api-keys/2", "Bearer "+bootstrap, nil).Code)
+}
+
+func TestAuth_JWT(t *testing.T) {
+	r, _, rsaKey := setupAuthRouter(t)
+	claims := func(mutate func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{"sub": "user-1", "iss": "task-api-test", "exp": time.Now().Add(time.Hour).Unix(), "workspaces": []interface{}{1}}
+		if mutate != nil {
+			mutate(c)
+		}
+		return c
+	}
+
+	valid := []string{
+		signToken(t, jwt.SigningMethodHS256, "hs", hmacSecret, claims(nil)),
+		signToken(t, jwt.SigningMethodRS256, "rs", rsaKey, claims(nil)),
+	}
+	for _, token := range valid {
+		assert.Equal(t, http.StatusOK, request(r, "GET", "/workspaces/1/tasks", "Bearer "+token, nil).Code)
+	}
+
+	// the workspaces claim limits which workspaces the token can reach
+	noWorkspace := signToken(t, jwt.SigningMethodHS256, "hs", hmacSecret, claims(func(c jwt.MapClaims) { delete(c, "workspaces") }))
+	assert.Equal(t, http.StatusNotFound, request(r, "GET", "/workspaces/1/tasks", "Bearer "+noWorkspace, nil).Code)
+	anyWorkspace := signToken(t, jwt.SigningMethodHS256, "hs", hmacSecret, claims(func(c jwt.MapClaims) { c["workspaces"] = []interface{}{"*"} }))
+	assert.Equal(t, http.StatusOK, request(r, "GET", "/workspaces/1/tasks", "Bearer "+anyWorkspace, nil).Code)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	invalid := map[string]string{
+		"expired":        signToken(t, jwt.SigningMethodHS256, "hs", hmacSecret, claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() })),
+		"no exp":         signToken(t, jwt.SigningMethodHS256, "hs", hmacSecret, claims(func(c jwt.MapClaims) { delete(c, "exp") })),
+		"wrong issuer":   signToken(t, jwt.SigningMethodHS256, "hs", hmacSecret, claims(func(c jwt.MapClaims) { c["iss"] = "someone-else" })),
+		"no subject":     signToken(t, jwt.SigningMethodHS256, "hs", hmacSecret, claims(func(c jwt.MapClaims) { delete(c, "sub") })),
+		"unknown kid":    signToken(t, jwt.SigningMethodHS256, "nope", hmacSecret, claims(nil)),
+		"wrong secret":   signToken(t, jwt.SigningMethodHS256, "hs", []byte("not-the-secret"), claims(nil)),
+		"wrong rsa key":  signToken(t, jwt.SigningMethodRS256, "rs", otherKey, claims(nil)),
+		"alg mismatch":   signToken(t, jwt.SigningMethodHS256, "rs", hmacSecret, claims(nil)),
+		"bad workspaces": signToken(t, jwt.SigningMethodHS256, "hs", hmacSecret, claims(func(c jwt.MapClaims) { c["workspaces"] = "all" })),
+	}
+	for name, token := range invalid {
+		assert.Equal(t, http.StatusUnauthorized, request(r, "GET", "/workspaces/1/tasks", "Bearer "+token, nil).Code, name)
+	}
+}
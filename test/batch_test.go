@@ -0,0 +1,213 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/handler"
+	"task-api/model"
+	"task-api/pkg/auth"
+	"task-api/pkg/health"
+	"task-api/repository"
+	"task-api/router"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestTaskRepository_Transaction(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+		boom := errors.New("boom")
+
+		err := repo.Transaction(ctx, func(tx repository.RepositoryInterface) error {
+			_, err := tx.CreateTask(ctx, defaultScope, &model.Task{Name: "discarded"})
+			require.NoError(t, err)
+			return boom
+		})
+		assert.ErrorIs(t, err, boom)
+
+		require.NoError(t, repo.Transaction(ctx, func(tx repository.RepositoryInterface) error {
+			_, err := tx.CreateTask(ctx, defaultScope, &model.Task{Name: "kept"})
+			return err
+		}))
+
+		page, err := repo.ListTasks(ctx, defaultScope, repository.TaskQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kept"}, names(page.Tasks))
+	})
+}
+
+func postBatch(t *testing.T, r http.Handler, body string) dto.BatchResponse {
+	t.Helper()
+	w := conditional(r, "POST", "/workspaces/1/tasks:batch", nil, body)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response dto.BatchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func statuses(results []dto.BatchResult) []int {
+	out := make([]int, 0, len(results))
+	for _, r := range results {
+		out = append(out, r.Status)
+	}
+	return out
+}
+
+func TestBatch_BestEffort(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+	require.Equal(t, http.StatusCreated, conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"existing"}`).Code)
+
+	response := postBatch(t, r, `{"operations":[
+		{"op":"create","task":{"name":"imported","tags":["doc"]}},
+		{"op":"create","task":{"assignee":"Barney"}},
+		{"op":"update","id":1,"task":{"name":"renamed","status":1}},
+		{"op":"update","id":999,"task":{"name":"missing"}},
+		{"op":"delete","id":1,"version":1},
+		{"op":"delete"}
+	]}`)
+	assert.False(t, response.RolledBack)
+	assert.Equal(t, []int{201, 400, 200, 404, 412, 400}, statuses(response.Results))
+	require.NotNil(t, response.Results[0].Task)
+	assert.Equal(t, "imported", response.Results[0].Task.Name)
+	assert.Equal(t, uint(2), response.Results[2].Task.Version)
+	assert.Contains(t, response.Results[1].Error, "Name")
+	assert.Equal(t, "task has been modified", response.Results[4].Error)
+	for i, result := range response.Results {
+		assert.Equal(t, i, result.Index)
+	}
+
+	w := conditional(r, "GET", "/workspaces/1/tasks", nil, "")
+	assert.Contains(t, w.Body.String(), "renamed")
+	assert.Contains(t, w.Body.String(), "imported")
+}
+
+func TestBatch_Atomic(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+
+	response := postBatch(t, r, `{"atomic":true,"operations":[
+		{"op":"create","task":{"name":"a"}},
+		{"op":"create","task":{"name":"b"}},
+		{"op":"delete","id":42},
+		{"op":"create","task":{"name":"c"}}
+	]}`)
+	assert.True(t, response.RolledBack)
+	assert.Equal(t, []int{424, 424, 404, 424}, statuses(response.Results))
+	assert.Nil(t, response.Results[0].Task)
+	assert.Equal(t, "not applied: operation 2 failed", response.Results[3].Error)
+	assert.Contains(t, conditional(r, "GET", "/workspaces/1/tasks", nil, "").Body.String(), `"data":[]`)
+
+	response = postBatch(t, r, `{"atomic":true,"operations":[
+		{"op":"create","task":{"name":"a"}},
+		{"op":"create","task":{"name":"b"}},
+		{"op":"update","id":1,"version":1,"task":{"name":"a2"}}
+	]}`)
+	assert.False(t, response.RolledBack)
+	assert.Equal(t, []int{201, 201, 200}, statuses(response.Results))
+	assert.Equal(t, "a2", response.Results[2].Task.Name)
+}
+
+// require_if_match 也適用於 batch：沒有 version 的 update/delete 以 428 失敗。
+func TestBatch_RequireVersion(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{RequireIfMatch: true})
+	require.Equal(t, http.StatusCreated, conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"a"}`).Code)
+	require.Equal(t, http.StatusCreated, conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"b"}`).Code)
+
+	response := postBatch(t, r, `{"operations":[
+		{"op":"update","id":1,"task":{"name":"a2"}},
+		{"op":"delete","id":2},
+		{"op":"update","id":1,"version":1,"task":{"name":"a3"}},
+		{"op":"create","task":{"name":"c"}}
+	]}`)
+	assert.False(t, response.RolledBack)
+	assert.Equal(t, []int{428, 428, 200, 201}, statuses(response.Results))
+	assert.Equal(t, "version is required", response.Results[0].Error)
+	assert.Equal(t, "version is required", response.Results[1].Error)
+	assert.Equal(t, "a3", response.Results[2].Task.Name)
+
+	response = postBatch(t, r, `{"atomic":true,"operations":[
+		{"op":"update","id":1,"version":2,"task":{"name":"a4"}},
+		{"op":"delete","id":2},
+		{"op":"create","task":{"name":"d"}}
+	]}`)
+	assert.True(t, response.RolledBack)
+	assert.Equal(t, []int{424, 428, 424}, statuses(response.Results))
+	body := conditional(r, "GET", "/workspaces/1/tasks", nil, "").Body.String()
+	assert.Contains(t, body, `"a3"`)
+	assert.Contains(t, body, `"b"`)
+	assert.NotContains(t, body, `"d"`)
+}
+
+func TestBatch_InvalidRequests(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	cfg.Limits.MaxBatchSize = 2
+	r := setupConfiguredRouter(cfg)
+
+	ops := `{"op":"delete","id":1}`
+	cases := []struct {
+		path, body string
+		status     int
+	}{
+		{"/workspaces/1/tasks:batch", `{"operations":[]}`, http.StatusBadRequest},
+		{"/workspaces/1/tasks:batch", `{"operations":[{"op":"upsert"}]}`, http.StatusBadRequest},
+		{"/workspaces/1/tasks:batch", `{"operations":[` + strings.Repeat(ops+",", 2) + ops + `]}`, http.StatusBadRequest},
+		{"/workspaces/1/tasks:purge", `{"operations":[` + ops + `]}`, http.StatusNotFound},
+		{"/workspaces/2/tasks:batch", `{"operations":[` + ops + `]}`, http.StatusNotFound},
+		{"/workspaces/1/tasks:batch", `{"operations":[` + ops + `]}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		w := conditional(r, "POST", tc.path, nil, tc.body)
+		assert.Equal(t, tc.status, w.Code, tc.path+" "+tc.body)
+	}
+}
+
+// 資料庫錯誤只寫進 log，500 回應與 batch 結果都不帶原始訊息。
+func TestBatch_HidesDatabaseErrors(t *testing.T) {
+	db := openDB(t, "sqlite")
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	r := router.SetupRouter(router.Handlers{
+		Task:      handler.NewTaskHandler(repository.NewTaskRepository(db), cfg.Limits, cfg.Concurrency),
+		Workspace: handler.NewWorkspaceHandler(repository.NewWorkspaceRepository(db)),
+		Health:    handler.NewHealthHandler(&health.Readiness{}, nil, nil),
+	}, cfg)
+	require.NoError(t, db.Migrator().DropTable("tasks"))
+
+	w := conditional(r, "GET", "/workspaces/1/tasks", nil, "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"internal server error"`)
+	assert.NotContains(t, w.Body.String(), "tasks")
+
+	response := postBatch(t, r, `{"operations":[{"op":"create","task":{"name":"lost"}}]}`)
+	assert.Equal(t, []int{500}, statuses(response.Results))
+	assert.Equal(t, "internal server error", response.Results[0].Error)
+}
+
+func TestBatch_Permissions(t *testing.T) {
+	r, keys, _ := setupAuthRouter(t)
+	_, key, err := auth.IssueKey(context.Background(), keys, testTask.Assignee, auth.RoleMember, nil)
+	require.NoError(t, err)
+
+	w := request(r, "POST", "/workspaces/1/tasks:batch", "Bearer "+key, []byte(`{"operations":[
+		{"op":"create","task":{"name":"mine"}},
+		{"op":"create","task":{"name":"theirs","assignee":"Other"}},
+		{"op":"update","id":1,"task":{"name":"x","assignee":"Other"}},
+		{"op":"delete","id":1}
+	]}`))
+	require.Equal(t, http.StatusOK, w.Code)
+	var response dto.BatchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []int{201, 403, 403, 403}, statuses(response.Results))
+	assert.Equal(t, testTask.Assignee, response.Results[0].Task.Assignee)
+	assert.Contains(t, response.Results[3].Error, `lacks permission "tasks:delete"`)
+}
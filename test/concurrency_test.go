@@ -0,0 +1,126 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"task-api/config"
+	"task-api/handler"
+	"task-api/model"
+	"task-api/pkg/health"
+	"task-api/repository"
+	"task-api/router"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestTaskRepository_Versioning(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+
+		task, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "draft"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), task.Version)
+
+		updated, err := repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"name": "first"}, task.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), updated.Version)
+
+		// 第二個人仍拿著舊版本
+		_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"name": "second"}, task.ID, 1)
+		assert.ErrorIs(t, err, repository.ErrVersionMismatch)
+		_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{}, task.ID, 1)
+		assert.ErrorIs(t, err, repository.ErrVersionMismatch)
+		assert.ErrorIs(t, repo.DeleteTask(ctx, defaultScope, task.ID, 1), repository.ErrVersionMismatch)
+
+		got, err := repo.GetTaskByID(ctx, defaultScope, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first", got.Name)
+
+		updated, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"name": "forced"}, task.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		assert.Equal(t, uint(3), updated.Version)
+
+		require.NoError(t, repo.DeleteTask(ctx, defaultScope, task.ID, 3))
+		assert.ErrorIs(t, repo.DeleteTask(ctx, defaultScope, task.ID, 3), repository.ErrNotFound)
+	})
+}
+
+func setupConcurrencyRouter(t *testing.T, concurrency config.ConcurrencyConfig) http.Handler {
+	db := openDB(t, "sqlite")
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	return router.SetupRouter(router.Handlers{
+		Task:      handler.NewTaskHandler(repository.NewTaskRepository(db), cfg.Limits, concurrency),
+		Workspace: handler.NewWorkspaceHandler(repository.NewWorkspaceRepository(db)),
+		Health:    handler.NewHealthHandler(&health.Readiness{}, nil, nil),
+	}, cfg)
+}
+
+func conditional(r http.Handler, method, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestConcurrency_ETagAndIfMatch(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+
+	w := conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"draft"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), `"version":1`)
+
+	w = conditional(r, "GET", "/workspaces/1/tasks/1", map[string]string{"If-None-Match": `"1"`}, "")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = conditional(r, "PUT", "/workspaces/1/tasks/1", map[string]string{"If-Match": `"1"`}, `{"name":"first"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	w = conditional(r, "PUT", "/workspaces/1/tasks/1", map[string]string{"If-Match": `"1"`}, `{"name":"second"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	w = conditional(r, "DELETE", "/workspaces/1/tasks/1", map[string]string{"If-Match": `"1"`}, "")
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	w = conditional(r, "PUT", "/workspaces/1/tasks/1", map[string]string{"If-Match": `W/"2"`}, `{"name":"weak"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = conditional(r, "GET", "/workspaces/1/tasks/1", map[string]string{"If-None-Match": `"1"`}, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), `"name":"first"`)
+
+	// 沒帶 If-Match 時照舊直接覆寫
+	w = conditional(r, "PUT", "/workspaces/1/tasks/1", nil, `{"name":"blind"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	w = conditional(r, "DELETE", "/workspaces/1/tasks/1", map[string]string{"If-Match": `"3"`}, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestConcurrency_RequireIfMatch(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{RequireIfMatch: true})
+
+	require.Equal(t, http.StatusCreated, conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"draft"}`).Code)
+
+	w := conditional(r, "PUT", "/workspaces/1/tasks/1", nil, `{"name":"blind"}`)
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+	assert.Equal(t, http.StatusPreconditionRequired, conditional(r, "DELETE", "/workspaces/1/tasks/1", nil, "").Code)
+
+	w = conditional(r, "PUT", "/workspaces/1/tasks/1", map[string]string{"If-Match": "*"}, `{"name":"any"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusNoContent, conditional(r, "DELETE", "/workspaces/1/tasks/1", map[string]string{"If-Match": `"2"`}, "").Code)
+}
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"task-api/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestConfig_Defaults(t *testing.T) {
+	cfg, args, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.Empty(t, args)
+	assert.Equal(t, ":8080", cfg.Server.Addr)
+	assert.Equal(t, "sqlite://task.db", cfg.Database.DSN)
+	assert.Equal(t, 20, cfg.Limits.DefaultPageSize)
+}
+
+func TestConfig_Precedence(t *testing.T) {
+	path := writeConfig(t, "config.yaml", `
+server:
+  addr: ":9000"
+  mode: release
+database:
+  dsn: "sqlite://file.db"
+  conn_max_lifetime: 30m
+log:
+  level: warn
+cors:
+  allowed_origins: ["https://board.example.com"]
+`)
+	t.Setenv("TASK_API_CONFIG", path)
+	t.Setenv("TASK_API_DB_DSN", "sqlite://env.db")
+	t.Setenv("TASK_API_LOG_LEVEL", "debug")
+
+	cfg, args, err := config.Load([]string{"-log-level", "error", "migrate", "status"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"migrate", "status"}, args)
+	assert.Equal(t, ":9000", cfg.Server.Addr)   // file
+	assert.Equal(t, "release", cfg.Server.Mode) // file
+	assert.Equal(t, 30*time.Minute, cfg.Database.ConnMaxLifetime.Duration)
+	assert.Equal(t, "sqlite://env.db", cfg.Database.DSN) // env beats file
+	assert.Equal(t, "error", cfg.Log.Level)              // flag beats env
+	assert.Equal(t, []string{"https://board.example.com"}, cfg.CORS.AllowedOrigins)
+	assert.True(t, cfg.Server.Swagger) // default kept
+}
+
+func TestConfig_TOML(t *testing.T) {
+	path := writeConfig(t, "config.toml", `
+[server]
+addr = ":7000"
+
+[limits]
+max_page_size = 50
+default_page_size = 10
+`)
+	cfg, _, err := config.Load([]string{"-config", path})
+	require.NoError(t, err)
+	assert.Equal(t, ":7000", cfg.Server.Addr)
+	assert.Equal(t, 50, cfg.Limits.MaxPageSize)
+	assert.Equal(t, 10, cfg.Limits.DefaultPageSize)
+}
+
+func TestConfig_Workflow(t *testing.T) {
+	path := writeConfig(t, "config.yaml", `
+workflow:
+  transitions:
+    todo: [done]
+    done: [todo]
+`)
+	cfg, _, err := config.Load([]string{"-config", path})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"todo": {"done"}, "done": {"todo"}}, cfg.Workflow.Transitions)
+
+	path = writeConfig(t, "invalid.yaml", `
+workflow:
+  transitions:
+    todo: [finished]
+`)
+	_, _, err = config.Load([]string{"-config", path})
+	assert.ErrorContains(t, err, `workflow.transitions: unknown status "finished"`)
+}
+
+func TestConfig_Validation(t *testing.T) {
+	t.Setenv("TASK_API_LOG_LEVEL", "loud")
+	t.Setenv("TASK_API_CORS_ORIGINS", "board.example.com")
+	t.Setenv("TASK_API_DEFAULT_PAGE_SIZE", "500")
+	t.Setenv("TASK_API_TRASH_RETENTION", "-1h")
+	t.Setenv("TASK_API_REMINDERS_NOTIFIER", "webhook")
+	t.Setenv("TASK_API_STREAM_HEARTBEAT", "0s")
+	t.Setenv("TASK_API_REALTIME_SEND_BUFFER", "0")
+
+	_, _, err := config.Load(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "log.level")
+	assert.Contains(t, err.Error(), "cors origin")
+	assert.Contains(t, err.Error(), "default_page_size")
+	assert.Contains(t, err.Error(), "trash.retention")
+	assert.Contains(t, err.Error(), "reminders.webhook_url")
+	assert.Contains(t, err.Error(), "stream.heartbeat")
+	assert.Contains(t, err.Error(), "realtime.send_buffer")
+}
+
+func TestConfig_InvalidEnvValue(t *testing.T) {
+	t.Setenv("TASK_API_MAX_BODY_BYTES", "lots")
+
+	_, _, err := config.Load(nil)
+	assert.ErrorContains(t, err, "TASK_API_MAX_BODY_BYTES")
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/model"
+	"task-api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestTaskRepository_Dependencies(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+		for _, name := range []string{"design", "build", "test", "ship"} {
+			_, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: name})
+			require.NoError(t, err)
+		}
+		// design(1) → build(2) → test(3) → ship(4)
+		for _, link := range [][2]uint{{2, 1}, {3, 2}, {4, 3}} {
+			_, err := repo.AddDependency(ctx, defaultScope, link[0], link[1])
+			require.NoError(t, err)
+		}
+
+		_, err := repo.AddDependency(ctx, defaultScope, 2, 1)
+		assert.ErrorIs(t, err, repository.ErrDependencyExists)
+		_, err = repo.AddDependency(ctx, defaultScope, 2, 2)
+		assert.ErrorIs(t, err, repository.ErrInvalidDependency)
+		_, err = repo.AddDependency(ctx, defaultScope, 2, 99)
+		assert.ErrorIs(t, err, repository.ErrInvalidDependency)
+		_, err = repo.AddDependency(ctx, repository.Scope{WorkspaceID: 2}, 2, 1)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		_, err = repo.AddDependency(ctx, defaultScope, 1, 4)
+		assert.ErrorIs(t, err, repository.ErrDependencyCycle)
+		assert.EqualError(t, err, "dependency cycle: 4 blocks 1 blocks 2 blocks 3 blocks 4")
+
+		// 有未完成的 blocker 時不能完成
+		_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"status": model.StatusDone}, 3, repository.AnyVersion)
+		assert.EqualError(t, err, "task is blocked by open tasks: 2")
+		_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"status": model.StatusCancelled}, 2, repository.AnyVersion)
+		require.NoError(t, err)
+		_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"status": model.StatusDone}, 3, repository.AnyVersion)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.RemoveDependency(ctx, defaultScope, 4, 3))
+		assert.ErrorIs(t, repo.RemoveDependency(ctx, defaultScope, 4, 3), repository.ErrDependencyNotFound)
+		_, err = repo.AddDependency(ctx, defaultScope, 1, 4)
+		assert.NoError(t, err)
+	})
+}
+
+func TestTaskRepository_TaskGraph(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+		// 1 與 2 同時擋住 3；4 被 3 擋住；5 無關
+		priorities := []model.Priority{model.PriorityP3, model.PriorityP1, model.PriorityP2, model.PriorityP2, model.PriorityP0}
+		for i, priority := range priorities {
+			_, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: string(rune('a' + i)), Priority: priority})
+			require.NoError(t, err)
+		}
+		for _, link := range [][2]uint{{3, 1}, {3, 2}, {4, 3}} {
+			_, err := repo.AddDependency(ctx, defaultScope, link[0], link[1])
+			require.NoError(t, err)
+		}
+
+		graph, err := repo.GetTaskGraph(ctx, defaultScope, 3)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c", "d"}, names(graph.Tasks))
+		assert.Len(t, graph.Edges, 3)
+		assert.Equal(t, []uint{2, 1, 3, 4}, graph.Order)
+
+		graph, err = repo.GetTaskGraph(ctx, defaultScope, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{1, 3, 4}, graph.Order)
+
+		require.NoError(t, repo.DeleteTask(ctx, defaultScope, 2, repository.AnyVersion))
+		graph, err = repo.GetTaskGraph(ctx, defaultScope, 4)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{1, 3, 4}, graph.Order)
+		assert.Len(t, graph.Edges, 2)
+
+		// 清除垃圾桶時一併刪除相依關係
+		_, err = repo.PurgeDeletedTasks(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		var count int64
+		require.NoError(t, db.Model(&model.TaskDependency{}).Count(&count).Error)
+		assert.Equal(t, int64(2), count)
+	})
+}
+
+func TestTaskAPI_Dependencies(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+	for _, body := range []string{`{"name":"blocker"}`, `{"name":"blocked"}`} {
+		require.Equal(t, http.StatusCreated, conditional(r, "POST", "/workspaces/1/tasks", nil, body).Code)
+	}
+
+	w := conditional(r, "POST", "/workspaces/1/tasks/2/dependencies", nil, `{"blocker_id":1}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusConflict, conditional(r, "POST", "/workspaces/1/tasks/2/dependencies", nil, `{"blocker_id":1}`).Code)
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "POST", "/workspaces/1/tasks/2/dependencies", nil, `{"blocker_id":42}`).Code)
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "POST", "/workspaces/1/tasks/2/dependencies", nil, `{}`).Code)
+	assert.Equal(t, http.StatusNotFound, conditional(r, "POST", "/workspaces/1/tasks/42/dependencies", nil, `{"blocker_id":1}`).Code)
+	w = conditional(r, "POST", "/workspaces/1/tasks/1/dependencies", nil, `{"blocker_id":2}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "2 blocks 1 blocks 2")
+
+	w = conditional(r, "PUT", "/workspaces/1/tasks/2", nil, `{"name":"blocked","status":"done"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "blocked by open tasks: 1")
+
+	w = conditional(r, "GET", "/workspaces/1/tasks/2/graph", nil, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var graph dto.TaskGraphResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &graph))
+	assert.Equal(t, []uint{1, 2}, graph.Order)
+	require.Len(t, graph.Edges, 1)
+	assert.Equal(t, dto.DependencyResponse{BlockerID: 1, BlockedID: 2, CreatedAt: graph.Edges[0].CreatedAt}, graph.Edges[0])
+	assert.Equal(t, http.StatusNotFound, conditional(r, "GET", "/workspaces/1/tasks/42/graph", nil, "").Code)
+
+	assert.Equal(t, http.StatusNoContent, conditional(r, "DELETE", "/workspaces/1/tasks/2/dependencies/1", nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, conditional(r, "DELETE", "/workspaces/1/tasks/2/dependencies/1", nil, "").Code)
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "DELETE", "/workspaces/1/tasks/2/dependencies/x", nil, "").Code)
+	w = conditional(r, "PUT", "/workspaces/1/tasks/2", nil, `{"name":"blocked","status":"done"}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"task-api/config"
+	"task-api/handler"
+	"task-api/model"
+	"task-api/pkg/health"
+	"task-api/pkg/metrics"
+	"task-api/pkg/migrate"
+	"task-api/pkg/orm"
+	"task-api/repository"
+	"task-api/router"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingPinger struct{}
+
+func (failingPinger) PingContext(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func get(t *testing.T, h http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	req, _ := http.NewRequest("GET", path, nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestHealth_Probes(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	db, err := orm.InitDB(config.DatabaseConfig{DSN: "sqlite://" + filepath.Join(t.TempDir(), "test.db")}, "error")
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+
+	readiness := &health.Readiness{}
+	migrator := migrate.New(db, migrate.All())
+	r := router.SetupRouter(router.Handlers{
+		Task:   handler.NewTaskHandler(&mockRepo{}, cfg.Limits, cfg.Concurrency),
+		Health: handler.NewHealthHandler(readiness, sqlDB, migrator),
+	}, cfg)
+
+	assert.Equal(t, http.StatusOK, get(t, r, "/healthz").Code)
+
+	w := get(t, r, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "pending")
+
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	w = get(t, r, "/readyz")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"database":"ok"`)
+
+	readiness.SetDraining()
+	assert.Equal(t, http.StatusServiceUnavailable, get(t, r, "/readyz").Code)
+	assert.Equal(t, http.StatusOK, get(t, r, "/healthz").Code)
+}
+
+func TestHealth_DatabaseDown(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	r := router.SetupRouter(router.Handlers{
+		Task:   handler.NewTaskHandler(&mockRepo{}, cfg.Limits, cfg.Concurrency),
+		Health: handler.NewHealthHandler(&health.Readiness{}, failingPinger{}, nil),
+	}, cfg)
+
+	w := get(t, r, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "connection refused")
+}
+
+func TestMetrics_Endpoint(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	db := openDB(t, "sqlite")
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	repo := repository.NewTaskRepository(db)
+	_, err = repo.CreateTask(context.Background(), defaultScope, &model.Task{Name: "open"})
+	require.NoError(t, err)
+	_, err = repo.CreateTask(context.Background(), defaultScope, &model.Task{Name: "done", Status: model.StatusDone})
+	require.NoError(t, err)
+
+	r := router.SetupRouter(router.Handlers{
+		Task:      handler.NewTaskHandler(repo, cfg.Limits, cfg.Concurrency),
+		Workspace: handler.NewWorkspaceHandler(repository.NewWorkspaceRepository(db)),
+		Health:    handler.NewHealthHandler(&health.Readiness{}, sqlDB, nil),
+		Metrics:   metrics.New(sqlDB, repo),
+	}, cfg)
+
+	get(t, r, "/workspaces/1/tasks/1")
+	get(t, r, "/workspaces/1/tasks/999")
+	get(t, r, "/nope")
+
+	w := get(t, r, "/metrics")
+	require.Equal(t, http.StatusOK, w.Code)
+	body, _ := io.ReadAll(w.Body)
+	text := string(body)
+	assert.Contains(t, text, `task_api_http_requests_total{method="GET",route="/workspaces/:ws/tasks/:id",status="200"} 1`)
+	assert.Contains(t, text, `task_api_http_requests_total{method="GET",route="/workspaces/:ws/tasks/:id",status="404"} 1`)
+	assert.Contains(t, text, `task_api_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, text, `task_api_http_request_duration_seconds_bucket{method="GET",route="/workspaces/:ws/tasks/:id"`)
+	assert.Contains(t, text, `task_api_tasks{status="todo"} 1`)
+	assert.Contains(t, text, `task_api_tasks{status="done"} 1`)
+	assert.Contains(t, text, `go_sql_max_open_connections{db_name="task_api"}`)
+}
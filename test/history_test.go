@@ -0,0 +1,137 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/model"
+	"task-api/pkg/actor"
+	"task-api/pkg/auth"
+	"task-api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func eventTypes(events []model.TaskEvent) []string {
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		out = append(out, e.Type)
+	}
+	return out
+}
+
+func TestTaskRepository_History(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := actor.NewContext(context.Background(), "apikey:7")
+
+		due := time.Date(2025, 6, 20, 10, 0, 0, 0, time.UTC)
+		task, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "draft", DueDate: &due, Tags: model.Tags{"doc"}})
+		require.NoError(t, err)
+		_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"name": "final", "status": model.StatusTodo, "due_date": nil, "tags": model.Tags{"doc"}}, task.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		// 版本不符的更新不應留下紀錄
+		_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"name": "stale"}, task.ID, 1)
+		require.ErrorIs(t, err, repository.ErrVersionMismatch)
+		require.NoError(t, repo.DeleteTask(ctx, defaultScope, task.ID, repository.AnyVersion))
+
+		// history survives soft deletion
+		page, err := repo.ListTaskEvents(ctx, defaultScope, task.ID, "", 0)
+		require.NoError(t, err)
+		require.Equal(t, []string{model.TaskCreated, model.TaskUpdated, model.TaskDeleted}, eventTypes(page.Events))
+
+		created := page.Events[0]
+		assert.Equal(t, "apikey:7", created.Actor)
+		assert.Equal(t, uint(1), created.Version)
+		assert.Equal(t, "draft", created.Changes["name"].After)
+		assert.Equal(t, []interface{}{"doc"}, created.Changes["tags"].After)
+		assert.NotContains(t, created.Changes, "assignee")
+
+		updated := page.Events[1]
+		assert.Equal(t, uint(2), updated.Version)
+		assert.Equal(t, model.Changes{
+			"name":     {Before: "draft", After: "final"},
+			"due_date": {Before: "2025-06-20T10:00:00Z", After: nil},
+		}, updated.Changes)
+		assert.Empty(t, page.Events[2].Changes)
+		assert.Equal(t, uint(3), page.Events[2].Version)
+
+		_, err = repo.RestoreTask(ctx, defaultScope, task.ID)
+		require.NoError(t, err)
+		page, err = repo.ListTaskEvents(ctx, defaultScope, task.ID, "", 3)
+		require.NoError(t, err)
+		require.Len(t, page.Events, 3)
+		require.NotEmpty(t, page.NextCursor)
+		page, err = repo.ListTaskEvents(ctx, defaultScope, task.ID, page.NextCursor, 3)
+		require.NoError(t, err)
+		assert.Equal(t, []string{model.TaskRestored}, eventTypes(page.Events))
+		assert.Equal(t, uint(4), page.Events[0].Version)
+		assert.Empty(t, page.NextCursor)
+
+		_, err = repo.ListTaskEvents(ctx, repository.Scope{WorkspaceID: 99}, task.ID, "", 0)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = repo.ListTaskEvents(ctx, defaultScope, task.ID, "!!", 0)
+		assert.ErrorIs(t, err, repository.ErrInvalidCursor)
+	})
+}
+
+func TestTaskRepository_HistoryRolledBackWithBatch(t *testing.T) {
+	db := openDB(t, "sqlite")
+	repo := repository.NewTaskRepository(db)
+	ctx := context.Background()
+
+	task, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "draft"})
+	require.NoError(t, err)
+	err = repo.Transaction(ctx, func(tx repository.RepositoryInterface) error {
+		if _, err := tx.UpdateTask(ctx, defaultScope, map[string]interface{}{"name": "x"}, task.ID, repository.AnyVersion); err != nil {
+			return err
+		}
+		return tx.DeleteTask(ctx, defaultScope, 999, repository.AnyVersion)
+	})
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	page, err := repo.ListTaskEvents(ctx, defaultScope, task.ID, "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{model.TaskCreated}, eventTypes(page.Events))
+}
+
+func TestTaskHistory_HTTP(t *testing.T) {
+	r, keys, _ := setupAuthRouter(t)
+	_, key, err := auth.IssueKey(context.Background(), keys, "Admin", auth.RoleAdmin, nil)
+	require.NoError(t, err)
+	bearer := "Bearer " + key
+
+	// setupAuthRouter 使用 mockRepo，這裡只驗證路由、權限與回應格式
+	w := request(r, "GET", "/workspaces/1/tasks/1/history", bearer, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var body dto.TaskEventListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.NotNil(t, body.Data)
+
+	assert.Equal(t, http.StatusNotFound, request(r, "GET", "/workspaces/1/tasks/999/history", bearer, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, request(r, "GET", "/workspaces/1/tasks/1/history?limit=1000", bearer, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, request(r, "GET", "/workspaces/1/tasks/1/history", "", nil).Code)
+}
+
+func TestTaskHistory_SurvivesTrash(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+	require.Equal(t, http.StatusCreated, conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"draft"}`).Code)
+	require.Equal(t, http.StatusOK, conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": "application/merge-patch+json"}, `{"assignee":"Barney"}`).Code)
+	require.Equal(t, http.StatusNoContent, conditional(r, "DELETE", "/workspaces/1/tasks/1", nil, "").Code)
+
+	w := conditional(r, "GET", "/workspaces/1/tasks/1/history", nil, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var body dto.TaskEventListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Data, 3)
+	assert.Equal(t, "deleted", body.Data[2].Type)
+	assert.Equal(t, model.Changes{"assignee": {Before: "", After: "Barney"}}, model.Changes(body.Data[1].Changes))
+	assert.Empty(t, body.Data[1].Actor)
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/pkg/logger"
+	"task-api/pkg/requestid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLogs routes slog.Default() into a buffer for the duration of the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(logger.New("debug", &buf))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func TestRequestID_Propagated(t *testing.T) {
+	logs := captureLogs(t)
+	r := setupConfiguredRouter(config.Default())
+
+	req, _ := http.NewRequest("GET", "/workspaces/1/tasks/1", nil)
+	req.Header.Set(requestid.Header, "abc-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "abc-123", w.Header().Get(requestid.Header))
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+	assert.Equal(t, "request", record["msg"])
+	assert.Equal(t, "abc-123", record["request_id"])
+	assert.Equal(t, "GET", record["method"])
+	assert.Equal(t, "/workspaces/:ws/tasks/:id", record["route"])
+	assert.Equal(t, float64(http.StatusOK), record["status"])
+	assert.Equal(t, "1", record["task_id"])
+	assert.Equal(t, "1", record["workspace_id"])
+	assert.Contains(t, record, "latency_ms")
+	assert.Contains(t, record, "client_ip")
+}
+
+func TestRequestID_GeneratedAndInErrorResponse(t *testing.T) {
+	logs := captureLogs(t)
+	r := setupConfiguredRouter(config.Default())
+
+	req, _ := http.NewRequest("GET", "/workspaces/1/tasks/999", nil)
+	req.Header.Set(requestid.Header, "not valid\n")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	id := w.Header().Get(requestid.Header)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Len(t, id, 32)
+
+	var body dto.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "task not found", body.Error)
+	assert.Equal(t, id, body.RequestID)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, id, record["request_id"])
+}
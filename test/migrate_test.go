@@ -0,0 +1,103 @@
+package test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"task-api/config"
+	"task-api/model"
+	"task-api/pkg/migrate"
+	"task-api/pkg/orm"
+	"task-api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestMigrate_UpDownStatus(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		m := migrate.New(db, migrate.All())
+
+		pending, err := m.Pending()
+		require.NoError(t, err)
+		assert.Zero(t, pending)
+		assert.True(t, db.Migrator().HasTable("tasks"))
+
+		statuses, err := m.Status()
+		require.NoError(t, err)
+		require.Len(t, statuses, len(migrate.All()))
+		for _, s := range statuses {
+			assert.NotNil(t, s.AppliedAt, s.Name)
+		}
+
+		reverted, err := m.Down(len(migrate.All()))
+		require.NoError(t, err)
+		assert.Len(t, reverted, len(migrate.All()))
+		assert.False(t, db.Migrator().HasTable("tasks"))
+
+		pending, err = m.Pending()
+		require.NoError(t, err)
+		assert.Equal(t, len(migrate.All()), pending)
+
+		applied, err := m.Up()
+		require.NoError(t, err)
+		assert.Len(t, applied, len(migrate.All()))
+		assert.True(t, db.Migrator().HasTable("tasks"))
+	})
+}
+
+func TestMigrate_Run(t *testing.T) {
+	db, err := orm.InitDB(config.DatabaseConfig{DSN: "sqlite://" + filepath.Join(t.TempDir(), "test.db")}, "error")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, migrate.Run(db, []string{"status"}, &out))
+	assert.Regexp(t, `0001\s+create_tasks\s+pending`, out.String())
+
+	out.Reset()
+	require.NoError(t, migrate.Run(db, []string{"up"}, &out))
+	assert.Contains(t, out.String(), "applied  0001_create_tasks")
+
+	out.Reset()
+	require.NoError(t, migrate.Run(db, []string{"up"}, &out))
+	assert.Contains(t, out.String(), "no pending migrations")
+
+	assert.ErrorIs(t, migrate.Run(db, []string{"sideways"}, &out), migrate.ErrUsage)
+	assert.ErrorIs(t, migrate.Run(db, []string{"down", "zero"}, &out), migrate.ErrUsage)
+}
+
+// legacyTask is model.Task as it was when the schema was managed by AutoMigrate.
+type legacyTask struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:255;not null"`
+	Status    int    `gorm:"type:int;default:0"`
+	DueDate   *time.Time
+	Assignee  string
+	Tags      model.Tags
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (legacyTask) TableName() string {
+	return "tasks"
+}
+
+// 在 migration 出現前以 AutoMigrate 建立的資料庫，升級時應沿用既有資料。
+func TestMigrate_AdoptsAutoMigratedSchema(t *testing.T) {
+	db, err := orm.InitDB(config.DatabaseConfig{DSN: "sqlite://" + filepath.Join(t.TempDir(), "legacy.db")}, "error")
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&legacyTask{}))
+	require.NoError(t, db.Create(&legacyTask{Name: "legacy"}).Error)
+
+	_, err = migrate.New(db, migrate.All()).Up()
+	require.NoError(t, err)
+
+	var tasks []model.Task
+	require.NoError(t, db.Find(&tasks).Error)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "legacy", tasks[0].Name)
+	assert.Equal(t, uint(repository.DefaultWorkspaceID), tasks[0].WorkspaceID)
+}
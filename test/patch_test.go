@@ -0,0 +1,116 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	mergePatch = "application/merge-patch+json"
+	jsonPatch  = "application/json-patch+json"
+)
+
+func decodeTask(t *testing.T, body []byte) dto.TaskResponse {
+	t.Helper()
+	var task dto.TaskResponse
+	require.NoError(t, json.Unmarshal(body, &task))
+	return task
+}
+
+func TestPatchTask_MergePatch(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+	w := conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"draft","due_date":"2025-06-20T10:00:00Z","assignee":"Barney","tags":["doc"]}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// null 會清掉欄位，未提到的欄位維持原樣
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": mergePatch}, `{"status":1,"due_date":null,"assignee":null}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	task := decodeTask(t, w.Body.Bytes())
+	assert.Equal(t, "draft", task.Name)
+	assert.Equal(t, model.StatusDone, task.Status)
+	assert.Nil(t, task.DueDate)
+	assert.Empty(t, task.Assignee)
+	assert.Equal(t, []string{"doc"}, task.Tags)
+
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": mergePatch}, `{"name":null}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": mergePatch}, `{"name":"`+strings.Repeat("a", 101)+`"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": mergePatch}, `{"version":9}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "unknown field")
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": mergePatch}, `{"name":`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": "application/json"}, `{"name":"x"}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": mergePatch, "If-Match": `"1"`}, `{"name":"stale"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/999", map[string]string{"Content-Type": mergePatch}, `{"name":"x"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPatchTask_JSONPatch(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+	require.Equal(t, http.StatusCreated, conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"draft"}`).Code)
+
+	headers := map[string]string{"Content-Type": jsonPatch}
+	w := conditional(r, "PATCH", "/workspaces/1/tasks/1", headers, `[
+		{"op":"add","path":"/tags/-","value":"doc"},
+		{"op":"add","path":"/tags/-","value":"urgent"},
+		{"op":"replace","path":"/assignee","value":"Barney"}
+	]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	task := decodeTask(t, w.Body.Bytes())
+	assert.Equal(t, []string{"doc", "urgent"}, task.Tags)
+	assert.Equal(t, "Barney", task.Assignee)
+
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", headers, `[{"op":"test","path":"/tags/0","value":"doc"},{"op":"remove","path":"/tags/0"}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"urgent"}, decodeTask(t, w.Body.Bytes()).Tags)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	// test 失敗時整個 patch 不套用
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", headers, `[{"op":"replace","path":"/name","value":"x"},{"op":"test","path":"/tags/0","value":"doc"}]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", headers, `[{"op":"remove","path":"/tags/5"}]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", headers, `[{"op":"add","path":"/tags/-","value":"a"},{"op":"add","path":"/tags/-","value":"b"},{"op":"add","path":"/tags/-","value":"c"}]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", headers, `{"op":"remove"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = conditional(r, "GET", "/workspaces/1/tasks/1", nil, "")
+	task = decodeTask(t, w.Body.Bytes())
+	assert.Equal(t, "draft", task.Name)
+	assert.Equal(t, uint(3), task.Version)
+}
+
+func TestUpdateTask_ReplacesAllFields(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+	w := conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"draft","due_date":"2025-06-20T10:00:00Z","assignee":"Barney","tags":["doc"]}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	w = conditional(r, "PUT", "/workspaces/1/tasks/1", nil, `{"name":"renamed","status":1}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	task := decodeTask(t, w.Body.Bytes())
+	assert.Equal(t, "renamed", task.Name)
+	assert.Equal(t, model.StatusDone, task.Status)
+	assert.Nil(t, task.DueDate)
+	assert.Empty(t, task.Assignee)
+	assert.Empty(t, task.Tags)
+
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "PUT", "/workspaces/1/tasks/1", nil, `{"status":1}`).Code)
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "PUT", "/workspaces/1/tasks/1", nil, `{"name":"x","status":2}`).Code)
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "PUT", "/workspaces/1/tasks/1", nil, `{"name":"x","tags":["a","b","c","d"]}`).Code)
+}
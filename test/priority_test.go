@@ -0,0 +1,176 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/model"
+	"task-api/pkg/migrate"
+	"task-api/pkg/orm"
+	"task-api/pkg/rank"
+	"task-api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRank_Between(t *testing.T) {
+	key, err := rank.Between("", "")
+	require.NoError(t, err)
+	assert.Equal(t, rank.First, key)
+
+	// 一直往後加，key 長度只隨數量對數成長
+	last := ""
+	for i := 0; i < 5000; i++ {
+		next, err := rank.Between(last, "")
+		require.NoError(t, err)
+		require.Greater(t, next, last)
+		last = next
+	}
+	assert.LessOrEqual(t, len(last), 4)
+
+	keys := []string{rank.First}
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		j := random.Intn(len(keys) + 1)
+		var a, b string
+		if j > 0 {
+			a = keys[j-1]
+		}
+		if j < len(keys) {
+			b = keys[j]
+		}
+		key, err := rank.Between(a, b)
+		require.NoError(t, err, "%q %q", a, b)
+		require.NoError(t, rank.Validate(key))
+		keys = append(keys[:j], append([]string{key}, keys[j:]...)...)
+	}
+	assert.True(t, sort.StringsAreSorted(keys))
+
+	_, err = rank.Between("a1", "a0")
+	assert.Error(t, err)
+	_, err = rank.Between("a0", "a0")
+	assert.Error(t, err)
+	assert.ErrorIs(t, rank.Validate("a10"), rank.ErrInvalidKey)
+	assert.ErrorIs(t, rank.Validate("!"), rank.ErrInvalidKey)
+}
+
+func TestPriority_JSON(t *testing.T) {
+	var p model.Priority
+	require.NoError(t, json.Unmarshal([]byte(`"p1"`), &p))
+	assert.Equal(t, model.PriorityP1, p)
+	raw, err := json.Marshal(model.PriorityP0)
+	require.NoError(t, err)
+	assert.Equal(t, `"P0"`, string(raw))
+	for _, input := range []string{`"P5"`, `"high"`, `1`} {
+		assert.Error(t, json.Unmarshal([]byte(input), &p), input)
+	}
+}
+
+func TestTaskRepository_MoveTask(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+		for _, name := range []string{"a", "b", "c", "d"} {
+			_, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: name})
+			require.NoError(t, err)
+		}
+		order := func() []string {
+			page, err := repo.ListTasks(ctx, defaultScope, repository.TaskQuery{Sort: "rank"})
+			require.NoError(t, err)
+			return names(page.Tasks)
+		}
+		require.Equal(t, []string{"a", "b", "c", "d"}, order())
+
+		moved, err := repo.MoveTask(ctx, defaultScope, 4, repository.Move{AfterID: 1}, 1)
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), moved.Version)
+		assert.Equal(t, []string{"a", "d", "b", "c"}, order())
+
+		_, err = repo.MoveTask(ctx, defaultScope, 1, repository.Move{BeforeID: 3}, repository.AnyVersion)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"d", "b", "a", "c"}, order())
+		_, err = repo.MoveTask(ctx, defaultScope, 3, repository.Move{BeforeID: 4}, repository.AnyVersion)
+		require.NoError(t, err)
+		_, err = repo.MoveTask(ctx, defaultScope, 2, repository.Move{AfterID: 1}, repository.AnyVersion)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c", "d", "a", "b"}, order())
+
+		_, err = repo.MoveTask(ctx, defaultScope, 2, repository.Move{AfterID: 2}, repository.AnyVersion)
+		assert.ErrorIs(t, err, repository.ErrInvalidMove)
+		_, err = repo.MoveTask(ctx, defaultScope, 2, repository.Move{AfterID: 99}, repository.AnyVersion)
+		assert.ErrorIs(t, err, repository.ErrInvalidMove)
+		_, err = repo.MoveTask(ctx, defaultScope, 2, repository.Move{AfterID: 1}, 1)
+		assert.ErrorIs(t, err, repository.ErrVersionMismatch)
+
+		page, err := repo.ListTaskEvents(ctx, defaultScope, 4, "", 10)
+		require.NoError(t, err)
+		assert.Contains(t, page.Events[1].Changes, "rank")
+	})
+}
+
+func TestTaskAPI_Priority(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+	for _, body := range []string{`{"name":"later"}`, `{"name":"urgent","priority":"P0"}`, `{"name":"soon","priority":"P1"}`} {
+		require.Equal(t, http.StatusCreated, conditional(r, "POST", "/workspaces/1/tasks", nil, body).Code)
+	}
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"x","priority":"P9"}`).Code)
+
+	list := func(query string) []string {
+		w := conditional(r, "GET", "/workspaces/1/tasks?"+query, nil, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response dto.TaskListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		out := make([]string, 0, len(response.Data))
+		for _, task := range response.Data {
+			out = append(out, task.Name+":"+task.Priority.String())
+		}
+		return out
+	}
+	assert.Equal(t, []string{"urgent:P0", "soon:P1", "later:P2"}, list("sort=priority"))
+	assert.Equal(t, []string{"urgent:P0", "soon:P1"}, list("priority=P0,P1"))
+	assert.Equal(t, []string{"later:P2", "urgent:P0", "soon:P1"}, list("sort=rank"))
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "GET", "/workspaces/1/tasks?priority=urgent", nil, "").Code)
+
+	w := conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": mergePatch}, `{"priority":"P3"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, model.PriorityP3, decodeTask(t, w.Body.Bytes()).Priority)
+	// PUT 未帶 priority 時回到預設值
+	w = conditional(r, "PUT", "/workspaces/1/tasks/1", nil, `{"name":"later"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, model.DefaultPriority, decodeTask(t, w.Body.Bytes()).Priority)
+
+	w = conditional(r, "POST", "/workspaces/1/tasks/1/move", nil, `{"after_id":3}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"urgent:P0", "soon:P1", "later:P2"}, list("sort=rank"))
+	for _, body := range []string{`{}`, `{"after_id":2,"before_id":3}`, `{"after_id":42}`} {
+		assert.Equal(t, http.StatusBadRequest, conditional(r, "POST", "/workspaces/1/tasks/1/move", nil, body).Code, body)
+	}
+	assert.Equal(t, http.StatusNotFound, conditional(r, "POST", "/workspaces/1/tasks/42/move", nil, `{"after_id":1}`).Code)
+}
+
+func TestMigrate_BackfillsRank(t *testing.T) {
+	db, err := orm.InitDB(config.DatabaseConfig{DSN: "sqlite://" + filepath.Join(t.TempDir(), "legacy.db")}, "error")
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&legacyTask{}))
+	require.NoError(t, db.Create(&[]legacyTask{{Name: "first"}, {Name: "second"}, {Name: "third"}}).Error)
+
+	_, err = migrate.New(db, migrate.All()).Up()
+	require.NoError(t, err)
+
+	var tasks []model.Task
+	require.NoError(t, db.Order("position").Find(&tasks).Error)
+	assert.Equal(t, []string{"first", "second", "third"}, names(tasks))
+	for _, task := range tasks {
+		assert.Equal(t, model.DefaultPriority, task.Priority)
+		assert.NoError(t, rank.Validate(task.Rank))
+	}
+}
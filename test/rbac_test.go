@@ -0,0 +1,89 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"task-api/dto"
+	"task-api/model"
+	"task-api/pkg/auth"
+	"task-api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRBAC_Roles(t *testing.T) {
+	r, keys, _ := setupAuthRouter(t)
+	issue := func(name string, role auth.Role) string {
+		_, key, err := auth.IssueKey(context.Background(), keys, name, role, nil)
+		require.NoError(t, err)
+		return "Bearer " + key
+	}
+	viewer := issue("Viewer", auth.RoleViewer)
+	owner := issue(testTask.Assignee, auth.RoleMember) // testTask is assigned to this member
+	other := issue("Other", auth.RoleMember)
+	admin := issue("Admin", auth.RoleAdmin)
+
+	cases := []struct {
+		name   string
+		auth   string
+		method string
+		path   string
+		body   string
+		status int
+		errMsg string
+	}{
+		{"viewer lists", viewer, "GET", "/workspaces/1/tasks", "", http.StatusOK, ""},
+		{"viewer reads", viewer, "GET", "/workspaces/1/tasks/1", "", http.StatusOK, ""},
+		{"viewer creates", viewer, "POST", "/workspaces/1/tasks", `{"name":"x"}`, http.StatusForbidden, `lacks permission "tasks:create"`},
+		{"viewer updates", viewer, "PUT", "/workspaces/1/tasks/1", `{"name":"x"}`, http.StatusForbidden, "tasks:update"},
+		{"member creates for self", owner, "POST", "/workspaces/1/tasks", `{"name":"x"}`, http.StatusCreated, ""},
+		{"member creates for others", owner, "POST", "/workspaces/1/tasks", `{"name":"x","assignee":"Other"}`, http.StatusForbidden, "assigned to themselves"},
+		{"member updates own task", owner, "PUT", "/workspaces/1/tasks/1", `{"name":"x"}`, http.StatusOK, ""},
+		{"member reassigns own task", owner, "PUT", "/workspaces/1/tasks/1", `{"name":"x","assignee":"Other"}`, http.StatusForbidden, "reassign"},
+		{"member updates other's task", other, "PUT", "/workspaces/1/tasks/1", `{"name":"x"}`, http.StatusForbidden, "not assigned to you"},
+		{"member updates missing task", other, "PUT", "/workspaces/1/tasks/999", `{"name":"x"}`, http.StatusNotFound, ""},
+		{"member deletes", owner, "DELETE", "/workspaces/1/tasks/1", "", http.StatusForbidden, "tasks:delete"},
+		{"member manages keys", owner, "GET", "/api-keys", "", http.StatusForbidden, "api_keys:manage"},
+		{"admin creates for others", admin, "POST", "/workspaces/1/tasks", `{"name":"x","assignee":"Other"}`, http.StatusCreated, ""},
+		{"admin updates any task", admin, "PUT", "/workspaces/1/tasks/1", `{"name":"x","assignee":"Other"}`, http.StatusOK, ""},
+		{"admin deletes", admin, "DELETE", "/workspaces/1/tasks/1", "", http.StatusNoContent, ""},
+	}
+	for _, tc := range cases {
+		w := request(r, tc.method, tc.path, tc.auth, []byte(tc.body))
+		assert.Equal(t, tc.status, w.Code, tc.name)
+		if tc.errMsg != "" {
+			var body dto.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), tc.name)
+			assert.Contains(t, body.Error, tc.errMsg, tc.name)
+		}
+	}
+
+	// members creating without an assignee get the task assigned to themselves
+	w := request(r, "POST", "/workspaces/1/tasks", owner, []byte(`{"name":"mine"}`))
+	assert.Contains(t, w.Body.String(), `"assignee":"`+testTask.Assignee+`"`)
+}
+
+func TestTaskRepository_OwnershipScope(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+		task, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "mine", Assignee: "alice"})
+		require.NoError(t, err)
+
+		_, err = repo.UpdateTask(ctx, repository.Scope{WorkspaceID: repository.DefaultWorkspaceID, Assignee: "bob"}, map[string]interface{}{"name": "stolen"}, task.ID, repository.AnyVersion)
+		assert.ErrorIs(t, err, repository.ErrNotOwner)
+		_, err = repo.UpdateTask(ctx, repository.Scope{WorkspaceID: repository.DefaultWorkspaceID, Assignee: "bob"}, map[string]interface{}{}, task.ID, repository.AnyVersion)
+		assert.ErrorIs(t, err, repository.ErrNotOwner)
+		_, err = repo.UpdateTask(ctx, repository.Scope{WorkspaceID: repository.DefaultWorkspaceID, Assignee: "bob"}, map[string]interface{}{"name": "x"}, task.ID+100, repository.AnyVersion)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		updated, err := repo.UpdateTask(ctx, repository.Scope{WorkspaceID: repository.DefaultWorkspaceID, Assignee: "alice"}, map[string]interface{}{"name": "renamed"}, task.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		assert.Equal(t, "renamed", updated.Name)
+	})
+}
@@ -0,0 +1,179 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/handler"
+	"task-api/model"
+	"task-api/pkg/health"
+	"task-api/pkg/realtime"
+	"task-api/repository"
+	"task-api/router"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRealtimeServer(t *testing.T, cfg config.RealtimeConfig) (*httptest.Server, *repository.TaskRepository, *realtime.Hub) {
+	db := openDB(t, "sqlite")
+	appCfg := config.Default()
+	appCfg.Server.Mode = "test"
+	hub := realtime.NewHub(cfg)
+	repo := repository.NewTaskRepository(db).WithPublisher(hub)
+	workspaces := repository.NewWorkspaceRepository(db)
+	srv := httptest.NewServer(router.SetupRouter(router.Handlers{
+		Task:      handler.NewTaskHandler(repo, appCfg.Limits, appCfg.Concurrency),
+		Workspace: handler.NewWorkspaceHandler(workspaces),
+		Realtime:  handler.NewRealtimeHandler(hub, repo, workspaces, nil),
+		Health:    handler.NewHealthHandler(&health.Readiness{}, nil, nil),
+	}, appCfg))
+	t.Cleanup(srv.Close)
+	t.Cleanup(hub.Close)
+	return srv, repo, hub
+}
+
+func dialRealtime(t *testing.T, srv *httptest.Server, name string) *websocket.Conn {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/realtime?name="+name, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func sendRealtime(t *testing.T, conn *websocket.Conn, request dto.RealtimeRequest) {
+	t.Helper()
+	require.NoError(t, conn.WriteJSON(request))
+}
+
+func readRealtime(t *testing.T, conn *websocket.Conn) dto.RealtimeMessage {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var msg dto.RealtimeMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func TestRealtime_SubscriptionsAndChanges(t *testing.T) {
+	srv, repo, _ := setupRealtimeServer(t, config.Default().Realtime)
+	ctx := context.Background()
+	board := dialRealtime(t, srv, "board")
+
+	sendRealtime(t, board, dto.RealtimeRequest{Type: "subscribe", WorkspaceID: 1})
+	assert.Equal(t, dto.RealtimeMessage{Type: "subscribed", WorkspaceID: 1}, readRealtime(t, board))
+
+	send(t, "POST", srv.URL+"/workspaces/1/tasks", "application/json", `{"name":"ship it"}`)
+	msg := readRealtime(t, board)
+	assert.Equal(t, "change", msg.Type)
+	assert.Equal(t, "task.created", msg.Event)
+	assert.Equal(t, uint(1), msg.TaskID)
+	require.NotNil(t, msg.Change)
+	assert.Equal(t, uint(1), msg.Change.ID)
+	assert.Equal(t, "ship it", msg.Change.Task.Name)
+
+	// 回滾的交易不會推送；其他 workspace 的變更也不會
+	err := repo.Transaction(ctx, func(tx repository.RepositoryInterface) error {
+		if _, err := tx.CreateTask(ctx, defaultScope, &model.Task{Name: "rolled back"}); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	require.Error(t, err)
+	_, err = repo.CreateTask(ctx, repository.Scope{WorkspaceID: 2}, &model.Task{Name: "elsewhere"})
+	require.NoError(t, err)
+	send(t, "PATCH", srv.URL+"/workspaces/1/tasks/1", mergePatch, `{"status":"done"}`)
+	msg = readRealtime(t, board)
+	assert.Equal(t, "task.completed", msg.Event)
+	assert.Equal(t, model.StatusDone, msg.Change.Task.Status)
+
+	// 只訂閱單一 task 的連線只收到該 task 的變更
+	detail := dialRealtime(t, srv, "detail")
+	sendRealtime(t, detail, dto.RealtimeRequest{Type: "subscribe", WorkspaceID: 1, TaskID: 4})
+	assert.Equal(t, "task not found", readRealtime(t, detail).Message)
+	send(t, "POST", srv.URL+"/workspaces/1/tasks", "application/json", `{"name":"another"}`)
+	sendRealtime(t, detail, dto.RealtimeRequest{Type: "subscribe", WorkspaceID: 1, TaskID: 1})
+	assert.Equal(t, "subscribed", readRealtime(t, detail).Type)
+	send(t, "POST", srv.URL+"/workspaces/1/tasks/1/reopen", "", "")
+	msg = readRealtime(t, detail)
+	assert.Equal(t, "task.updated", msg.Event)
+	assert.Equal(t, "reopened", msg.Change.Type)
+	assert.Equal(t, "task.created", readRealtime(t, board).Event)
+	assert.Equal(t, "task.updated", readRealtime(t, board).Event)
+
+	sendRealtime(t, board, dto.RealtimeRequest{Type: "unsubscribe", WorkspaceID: 1})
+	assert.Equal(t, "unsubscribed", readRealtime(t, board).Type)
+	for _, request := range []dto.RealtimeRequest{{Type: "subscribe"}, {Type: "shout", WorkspaceID: 1}, {Type: "subscribe", WorkspaceID: 9}} {
+		sendRealtime(t, board, request)
+		msg := readRealtime(t, board)
+		assert.Equal(t, "error", msg.Type, request)
+		assert.NotEmpty(t, msg.Message)
+	}
+}
+
+func TestRealtime_Presence(t *testing.T) {
+	srv, _, _ := setupRealtimeServer(t, config.Default().Realtime)
+	send(t, "POST", srv.URL+"/workspaces/1/tasks", "application/json", `{"name":"shared"}`)
+	alice := dialRealtime(t, srv, "alice")
+	bob := dialRealtime(t, srv, "bob")
+
+	sendRealtime(t, alice, dto.RealtimeRequest{Type: "presence", WorkspaceID: 1, TaskID: 1, State: "editing"})
+	sendRealtime(t, alice, dto.RealtimeRequest{Type: "presence", WorkspaceID: 1, TaskID: 1, State: "typing"})
+	assert.Equal(t, "error", readRealtime(t, alice).Type)
+
+	// 訂閱時會收到目前在場的人
+	sendRealtime(t, bob, dto.RealtimeRequest{Type: "subscribe", WorkspaceID: 1})
+	msg := readRealtime(t, bob)
+	assert.Equal(t, []dto.PresenceEntry{{TaskID: 1, User: "alice", State: "editing"}}, msg.Presence)
+
+	sendRealtime(t, alice, dto.RealtimeRequest{Type: "presence", WorkspaceID: 1, TaskID: 1, State: "viewing"})
+	assert.Equal(t, dto.RealtimeMessage{Type: "presence", WorkspaceID: 1, TaskID: 1, User: "alice", State: "viewing"}, readRealtime(t, bob))
+
+	// 斷線時清除 presence
+	require.NoError(t, alice.Close())
+	assert.Equal(t, dto.RealtimeMessage{Type: "presence", WorkspaceID: 1, TaskID: 1, User: "alice"}, readRealtime(t, bob))
+}
+
+func TestRealtime_DropsSlowClients(t *testing.T) {
+	cfg := config.Default().Realtime
+	cfg.SendBuffer = 1
+	srv, _, hub := setupRealtimeServer(t, cfg)
+	slow := dialRealtime(t, srv, "slow")
+	sendRealtime(t, slow, dto.RealtimeRequest{Type: "subscribe", WorkspaceID: 1})
+	assert.Equal(t, "subscribed", readRealtime(t, slow).Type)
+
+	// 一次提交的大量變更會一起排入佇列，塞滿 buffer 的連線會被關閉
+	changes := make([]repository.TaskChange, 1000)
+	for i := range changes {
+		changes[i] = repository.TaskChange{Event: model.TaskEvent{ID: uint(i + 1), WorkspaceID: 1, TaskID: 1, Type: model.TaskUpdated}, Name: model.WebhookTaskUpdated}
+	}
+	hub.Publish(changes)
+	for {
+		require.NoError(t, slow.SetReadDeadline(time.Now().Add(5*time.Second)))
+		if _, _, err := slow.ReadMessage(); err != nil {
+			assert.True(t, websocket.IsCloseError(err, websocket.CloseTryAgainLater), err)
+			break
+		}
+	}
+
+	// 其他連線不受影響；關閉 hub 時以 going away 結束
+	other := dialRealtime(t, srv, "other")
+	sendRealtime(t, other, dto.RealtimeRequest{Type: "subscribe", WorkspaceID: 1})
+	assert.Equal(t, "subscribed", readRealtime(t, other).Type)
+	hub.Close()
+	require.NoError(t, other.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, _, err := other.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/realtime", http.Header{"Origin": {"https://evil.example.com"}})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
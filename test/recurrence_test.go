@@ -0,0 +1,187 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/model"
+	"task-api/pkg/recurrence"
+	"task-api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRecurrence_Parse(t *testing.T) {
+	for input, want := range map[string]string{
+		"FREQ=WEEKLY;BYDAY=MO":              "FREQ=WEEKLY;BYDAY=MO",
+		"RRULE:FREQ=DAILY;INTERVAL=2":       "FREQ=DAILY;INTERVAL=2",
+		"FREQ=MONTHLY;BYMONTHDAY=1;COUNT=3": "FREQ=MONTHLY;COUNT=3;BYMONTHDAY=1",
+	} {
+		rule, err := recurrence.Parse(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, rule.String())
+	}
+	for _, input := range []string{"", "BYDAY=MO", "FREQ=SOMETIMES", "FREQ=DAILY;COUNT=-1", "DTSTART:20250601T090000Z\nRRULE:FREQ=DAILY"} {
+		_, err := recurrence.Parse(input)
+		assert.ErrorIs(t, err, recurrence.ErrInvalidRule, input)
+	}
+
+	rule, err := recurrence.Parse("FREQ=WEEKLY;BYDAY=MO,TH")
+	require.NoError(t, err)
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC) // Monday
+	next, ok := rule.Next(start, start)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2025, 6, 5, 9, 0, 0, 0, time.UTC), next)
+	next, _ = rule.Next(start, time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2025, 6, 23, 9, 0, 0, 0, time.UTC), next)
+
+	rule, err = recurrence.Parse("FREQ=DAILY;UNTIL=20250603T000000Z")
+	require.NoError(t, err)
+	_, ok = rule.Next(start, start)
+	assert.False(t, ok)
+}
+
+// fixedClock returns a clock for WithClock and a function that moves it.
+func fixedClock(start time.Time) (func() time.Time, func(time.Time)) {
+	now := start
+	return func() time.Time { return now }, func(t time.Time) { now = t }
+}
+
+func TestTaskRepository_RecurringTask(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		clock, set := fixedClock(time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC))
+		repo := repository.NewTaskRepository(db).WithClock(clock)
+		ctx := context.Background()
+		done := map[string]interface{}{"status": model.StatusDone}
+		copyOf := func(fields map[string]interface{}) map[string]interface{} {
+			out := map[string]interface{}{}
+			for k, v := range fields {
+				out[k] = v
+			}
+			return out
+		}
+
+		_, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "backup", Recurrence: "FREQ=WEEKLY"})
+		assert.ErrorIs(t, err, repository.ErrInvalidRecurrence)
+		_, err = repo.CreateTask(ctx, defaultScope, &model.Task{Name: "backup", Recurrence: "FREQ=HOURLY;BYDAY=XX"})
+		assert.ErrorIs(t, err, repository.ErrInvalidRecurrence)
+
+		due := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+		first, err := repo.CreateTask(ctx, defaultScope, &model.Task{
+			Name: "backup", DueDate: &due, Assignee: "Barney", Tags: []string{"ops"}, Priority: model.PriorityP1,
+			Recurrence: "RRULE:FREQ=WEEKLY;BYDAY=MO",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO", first.Recurrence)
+		_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"due_date": nil}, first.ID, repository.AnyVersion)
+		assert.ErrorIs(t, err, repository.ErrInvalidRecurrence)
+
+		series := func() []model.Task {
+			page, err := repo.ListTasks(ctx, defaultScope, repository.TaskQuery{SeriesID: &first.ID})
+			require.NoError(t, err)
+			return page.Tasks
+		}
+
+		_, err = repo.UpdateTask(ctx, defaultScope, copyOf(done), first.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		tasks := series()
+		require.Len(t, tasks, 2)
+		second := tasks[1]
+		assert.Equal(t, model.StatusTodo, second.Status)
+		assert.Equal(t, time.Date(2025, 6, 9, 9, 0, 0, 0, time.UTC), second.DueDate.UTC())
+		assert.Equal(t, first.ID, *second.SeriesID)
+		assert.Equal(t, []string{"ops"}, []string(second.Tags))
+		assert.Equal(t, "Barney", second.Assignee)
+		assert.Equal(t, model.PriorityP1, second.Priority)
+
+		// 晚了兩週才完成：跳過錯過的日期，下一次排在現在之後
+		set(time.Date(2025, 6, 25, 12, 0, 0, 0, time.UTC))
+		completed, err := repo.UpdateTask(ctx, defaultScope, copyOf(done), second.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 6, 25, 12, 0, 0, 0, time.UTC), completed.CompletedAt.UTC())
+		tasks = series()
+		require.Len(t, tasks, 3)
+		assert.Equal(t, time.Date(2025, 6, 30, 9, 0, 0, 0, time.UTC), tasks[2].DueDate.UTC())
+
+		// 重新開啟再完成不會重複產生
+		_, err = repo.ReopenTask(ctx, defaultScope, second.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		_, err = repo.UpdateTask(ctx, defaultScope, copyOf(done), second.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		assert.Len(t, series(), 3)
+
+		// 修改整個系列只影響未完成的那一次；清空規則結束系列
+		updated, err := repo.UpdateSeries(ctx, defaultScope, map[string]interface{}{"name": "backup v2", "recurrence": ""}, first.ID)
+		require.NoError(t, err)
+		require.Len(t, updated, 1)
+		assert.Equal(t, tasks[2].ID, updated[0].ID)
+		assert.Equal(t, []string{"backup", "backup", "backup v2"}, names(series()))
+		_, err = repo.UpdateTask(ctx, defaultScope, copyOf(done), tasks[2].ID, repository.AnyVersion)
+		require.NoError(t, err)
+		assert.Len(t, series(), 3)
+	})
+}
+
+func TestTaskRepository_RecurrenceCount(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		clock, _ := fixedClock(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+		repo := repository.NewTaskRepository(db).WithClock(clock)
+		ctx := context.Background()
+		due := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+		task, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "standup", DueDate: &due, Recurrence: "FREQ=DAILY;COUNT=2"})
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"status": model.StatusDone}, task.ID+uint(i), repository.AnyVersion)
+			require.NoError(t, err)
+		}
+		page, err := repo.ListTasks(ctx, defaultScope, repository.TaskQuery{SeriesID: &task.ID})
+		require.NoError(t, err)
+		require.Len(t, page.Tasks, 2)
+		assert.Equal(t, time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC), page.Tasks[1].DueDate.UTC())
+	})
+}
+
+func TestTaskAPI_Recurrence(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+	w := conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"report","due_date":"2030-01-07T09:00:00Z","recurrence":"FREQ=WEEKLY"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "FREQ=WEEKLY", decodeTask(t, w.Body.Bytes()).Recurrence)
+	for _, body := range []string{`{"name":"x","recurrence":"FREQ=WEEKLY"}`, `{"name":"x","due_date":"2030-01-07T09:00:00Z","recurrence":"WEEKLY"}`} {
+		assert.Equal(t, http.StatusBadRequest, conditional(r, "POST", "/workspaces/1/tasks", nil, body).Code, body)
+	}
+
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": mergePatch}, `{"status":"done"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	list := func(path string) dto.TaskListResponse {
+		w := conditional(r, "GET", path, nil, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response dto.TaskListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	occurrences := list("/workspaces/1/tasks/2/series")
+	require.Len(t, occurrences.Data, 2)
+	assert.Equal(t, "2030-01-14T09:00:00Z", occurrences.Data[1].DueDate.UTC().Format(time.RFC3339))
+	assert.Equal(t, uint(1), *occurrences.Data[1].SeriesID)
+
+	// 單次修改只改這一次
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/2", map[string]string{"Content-Type": mergePatch}, `{"due_date":"2030-01-15T09:00:00Z"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/2/series", nil, `{"name":"weekly report","recurrence":"FREQ=WEEKLY;INTERVAL=2"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	occurrences = list("/workspaces/1/tasks/1/series")
+	assert.Equal(t, "report", occurrences.Data[0].Name)
+	assert.Equal(t, "weekly report", occurrences.Data[1].Name)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2", occurrences.Data[1].Recurrence)
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "PATCH", "/workspaces/1/tasks/2/series", nil, `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "PATCH", "/workspaces/1/tasks/2/series", nil, `{"recurrence":"FREQ=NEVER"}`).Code)
+	assert.Equal(t, http.StatusNotFound, conditional(r, "GET", "/workspaces/1/tasks/42/series", nil, "").Code)
+}
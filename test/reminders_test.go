@@ -0,0 +1,369 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"task-api/config"
+	"task-api/model"
+	"task-api/pkg/migrate"
+	"task-api/pkg/reminder"
+	"task-api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// recordingNotifier collects notifications; while fail is set it rejects them.
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []reminder.Notification
+	fail bool
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification reminder.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.fail {
+		return errors.New("notifier down")
+	}
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+// take returns the notifications sent since the last call as "kind offset task".
+func (n *recordingNotifier) take() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := []string{}
+	for _, s := range n.sent {
+		out = append(out, strings.Join(strings.Fields(s.Kind+" "+s.Offset+" "+s.Task.Name), " "))
+	}
+	n.sent = nil
+	return out
+}
+
+func TestTaskRepository_ReminderOffsets(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+
+		task, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "report", Reminders: model.Reminders{"1h", "24h0m0s", "60m", "90s"}})
+		require.NoError(t, err)
+		assert.Equal(t, model.Reminders{"24h", "1h", "1m30s"}, task.Reminders)
+
+		for _, offset := range []string{"-1h", "0s", "tomorrow"} {
+			_, err = repo.CreateTask(ctx, defaultScope, &model.Task{Name: "x", Reminders: model.Reminders{offset}})
+			assert.ErrorIs(t, err, repository.ErrInvalidReminder, offset)
+		}
+		updated, err := repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"reminders": model.Reminders(nil)}, task.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		assert.Empty(t, updated.Reminders)
+	})
+}
+
+func TestScheduler_RemindersAndOverdue(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		due := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+		clock, _ := fixedClock(due.Add(-48 * time.Hour))
+		repo := repository.NewTaskRepository(db).WithClock(clock)
+		ctx := context.Background()
+		notifier := &recordingNotifier{}
+		scheduler := reminder.NewScheduler(repo, notifier, config.Default().Reminders)
+
+		_, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "report", DueDate: &due, Reminders: model.Reminders{"24h", "1h"}})
+		require.NoError(t, err)
+		_, err = repo.CreateTask(ctx, defaultScope, &model.Task{Name: "no reminders", DueDate: &due})
+		require.NoError(t, err)
+		done, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "done", DueDate: &due, Status: model.StatusDone})
+		require.NoError(t, err)
+		_, err = repo.CreateTask(ctx, defaultScope, &model.Task{Name: "undated", Reminders: model.Reminders{"1h"}})
+		require.NoError(t, err)
+
+		for _, step := range []struct {
+			at   time.Time
+			want []string
+		}{
+			{due.Add(-25 * time.Hour), []string{}},
+			{due.Add(-24 * time.Hour), []string{"reminder 24h report"}},
+			{due.Add(-23 * time.Hour), []string{}},
+			{due.Add(-30 * time.Minute), []string{"reminder 1h report"}},
+			{due, []string{"overdue report", "overdue no reminders"}},
+			{due.Add(time.Hour), []string{}},
+		} {
+			scheduler.Run(ctx, step.at)
+			assert.Equal(t, step.want, notifier.take(), step.at)
+		}
+
+		// 重新啟動（新的 scheduler）不會重複寄送
+		reminder.NewScheduler(repository.NewTaskRepository(db), notifier, config.Default().Reminders).Run(ctx, due.Add(2*time.Hour))
+		assert.Empty(t, notifier.take())
+
+		task, err := repo.GetTaskByID(ctx, defaultScope, 1)
+		require.NoError(t, err)
+		require.NotNil(t, task.OverdueAt)
+		assert.Equal(t, due, task.OverdueAt.UTC())
+		assert.Equal(t, uint(2), task.Version)
+		history, err := repo.ListTaskEvents(ctx, defaultScope, 1, "", 10)
+		require.NoError(t, err)
+		require.Len(t, history.Events, 2)
+		assert.Equal(t, model.TaskOverdue, history.Events[1].Type)
+		assert.Contains(t, history.Events[1].Changes, "overdue_at")
+		done, err = repo.GetTaskByID(ctx, defaultScope, done.ID)
+		require.NoError(t, err)
+		assert.Nil(t, done.OverdueAt)
+
+		page, err := repo.ListTasks(ctx, defaultScope, repository.TaskQuery{Overdue: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"report", "no reminders"}, names(page.Tasks))
+
+		// 延後到期日會清除逾期，並依新的到期日再次提醒
+		later := due.Add(48 * time.Hour)
+		task, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"due_date": later}, 1, repository.AnyVersion)
+		require.NoError(t, err)
+		assert.Nil(t, task.OverdueAt)
+		scheduler.Run(ctx, later.Add(-time.Hour))
+		assert.Equal(t, []string{"reminder 1h report"}, notifier.take())
+	})
+}
+
+// next_reminder_at 隨到期日、提醒與狀態更新，scheduler 只讀到期的 task。
+func TestTaskRepository_NextReminderAt(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+		due := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+		next := func(id uint) *time.Time {
+			var task model.Task
+			require.NoError(t, db.Unscoped().Where("id = ?", id).First(&task).Error)
+			if task.NextReminderAt == nil {
+				return nil
+			}
+			at := task.NextReminderAt.UTC()
+			return &at
+		}
+		at := func(d time.Duration) *time.Time {
+			t := due.Add(d)
+			return &t
+		}
+
+		task, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "report", DueDate: &due, Reminders: model.Reminders{"1h", "24h"}})
+		require.NoError(t, err)
+		assert.Equal(t, at(-24*time.Hour), next(task.ID))
+		undated, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "undated", Reminders: model.Reminders{"1h"}})
+		require.NoError(t, err)
+		assert.Nil(t, next(undated.ID))
+
+		for _, step := range []struct {
+			fields map[string]interface{}
+			want   *time.Time
+		}{
+			{map[string]interface{}{"reminders": model.Reminders{"30m"}}, at(-30 * time.Minute)},
+			{map[string]interface{}{"reminders": model.Reminders(nil)}, at(0)},
+			{map[string]interface{}{"due_date": due.Add(time.Hour), "reminders": model.Reminders{"2h"}}, at(-time.Hour)},
+			{map[string]interface{}{"name": "renamed"}, at(-time.Hour)},
+			{map[string]interface{}{"status": model.StatusDone}, nil},
+		} {
+			_, err := repo.UpdateTask(ctx, defaultScope, step.fields, task.ID, repository.AnyVersion)
+			require.NoError(t, err)
+			assert.Equal(t, step.want, next(task.ID), step.fields)
+		}
+		_, err = repo.ReopenTask(ctx, defaultScope, task.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		assert.Equal(t, at(-time.Hour), next(task.ID))
+
+		// 認領後排到下一個提醒，最後的逾期通知之後就沒有了
+		claimed, err := repo.ClaimReminders(ctx, due.Add(-time.Hour))
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, "2h", claimed[0].Offset)
+		assert.Equal(t, at(time.Hour), next(task.ID))
+		claimed, err = repo.ClaimReminders(ctx, due.Add(2*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, model.ReminderOverdue, claimed[0].Kind)
+		assert.Nil(t, next(task.ID))
+
+		// 送不出去的通知放回排程
+		require.NoError(t, repo.ReleaseReminder(ctx, claimed[0].ID))
+		assert.Equal(t, at(time.Hour), next(task.ID))
+
+		// migration 依既有的到期日與提醒回填
+		m := migrate.New(db, migrate.All())
+		_, err = m.Down(len(migrate.All()) - 14)
+		require.NoError(t, err)
+		_, err = m.Up()
+		require.NoError(t, err)
+		assert.Equal(t, at(-time.Hour), next(task.ID))
+		assert.Nil(t, next(undated.ID))
+	})
+}
+
+func TestScheduler_ClaimsInBatches(t *testing.T) {
+	db := openDB(t, "sqlite")
+	repo := repository.NewTaskRepository(db)
+	ctx := context.Background()
+	due := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	tasks := make([]model.Task, 250)
+	for i := range tasks {
+		tasks[i] = model.Task{Name: "bulk", DueDate: &due, Reminders: model.Reminders{"1h"}}
+	}
+	for i := range tasks {
+		_, err := repo.CreateTask(ctx, defaultScope, &tasks[i])
+		require.NoError(t, err)
+	}
+
+	claimed, err := repo.ClaimReminders(ctx, due.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, claimed, len(tasks))
+	claimed, err = repo.ClaimReminders(ctx, due.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+}
+
+func TestScheduler_SkipsStaleRemindersAndRetriesFailures(t *testing.T) {
+	db := openDB(t, "sqlite")
+	repo := repository.NewTaskRepository(db)
+	ctx := context.Background()
+	notifier := &recordingNotifier{fail: true}
+	scheduler := reminder.NewScheduler(repo, notifier, config.Default().Reminders)
+
+	due := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	_, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "report", DueDate: &due, Reminders: model.Reminders{"24h", "1h"}})
+	require.NoError(t, err)
+
+	// 停機期間錯過 24h 的提醒：只寄最接近的那一個
+	scheduler.Run(ctx, due.Add(-30*time.Minute))
+	assert.Empty(t, notifier.take())
+	notifier.fail = false
+	scheduler.Run(ctx, due.Add(-20*time.Minute))
+	assert.Equal(t, []string{"reminder 1h report"}, notifier.take())
+	scheduler.Run(ctx, due.Add(-10*time.Minute))
+	assert.Empty(t, notifier.take())
+
+	var sent []model.TaskReminder
+	require.NoError(t, db.Find(&sent).Error)
+	require.Len(t, sent, 1)
+	assert.NotNil(t, sent[0].SentAt)
+
+	// 逾期通知失敗後重試，但 task 只標記一次
+	notifier.fail = true
+	scheduler.Run(ctx, due)
+	notifier.fail = false
+	scheduler.Run(ctx, due.Add(time.Minute))
+	assert.Equal(t, []string{"overdue report"}, notifier.take())
+	task, err := repo.GetTaskByID(ctx, defaultScope, 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), task.Version)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received reminder.Notification
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	notifier := reminder.NewNotifier(config.RemindersConfig{Notifier: "webhook", WebhookURL: server.URL})
+	notification := reminder.Notification{Kind: model.ReminderBefore, Offset: "1h", Task: model.Task{ID: 3, Name: "report"}}
+	require.NoError(t, notifier.Notify(context.Background(), notification))
+	assert.Equal(t, "1h", received.Offset)
+	assert.Equal(t, "report", received.Task.Name)
+
+	status = http.StatusBadGateway
+	assert.ErrorContains(t, notifier.Notify(context.Background(), notification), "502")
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	mail := make(chan string, 1)
+	go serveSMTP(l, mail)
+
+	notifier := reminder.NewNotifier(config.RemindersConfig{
+		Notifier: "smtp", SMTPAddr: l.Addr().String(), SMTPFrom: "task-api@localhost", SMTPTo: []string{"team@localhost"},
+	})
+	due := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	err = notifier.Notify(context.Background(), reminder.Notification{
+		Kind: model.ReminderOverdue, DueDate: due, Task: model.Task{ID: 3, Name: "report", Assignee: "Barney"},
+	})
+	require.NoError(t, err)
+	message := <-mail
+	assert.Contains(t, message, "To: team@localhost")
+	assert.Contains(t, message, `Subject: Task 3 "report" is overdue`)
+	assert.Contains(t, message, "Due: 2025-06-10T12:00:00Z")
+	assert.Contains(t, message, "Assignee: Barney")
+}
+
+// serveSMTP answers one SMTP session just well enough for net/smtp and
+// sends the message it received to mail.
+func serveSMTP(l net.Listener, mail chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch command := strings.ToUpper(strings.Fields(line + " x")[0]); command {
+		case "EHLO", "HELO", "MAIL", "RCPT", "RSET", "NOOP":
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			mail <- data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown command")
+		}
+	}
+}
+
+func TestTaskAPI_Reminders(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+	w := conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"report","due_date":"2030-01-07T09:00:00Z","reminders":["1h","1440m"]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, []string{"24h", "1h"}, decodeTask(t, w.Body.Bytes()).Reminders)
+	w = conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"report","reminders":["soon"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid reminder")
+
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": jsonPatch}, `[{"op":"add","path":"/reminders/-","value":"10m"}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"24h", "1h", "10m"}, decodeTask(t, w.Body.Bytes()).Reminders)
+
+	w = conditional(r, "GET", "/workspaces/1/tasks?overdue=true", nil, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"data":[]}`, w.Body.String())
+}
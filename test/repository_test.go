@@ -0,0 +1,158 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task-api/model"
+	"task-api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var defaultScope = repository.Scope{WorkspaceID: repository.DefaultWorkspaceID}
+
+func seedTasks(t *testing.T, repo *repository.TaskRepository) {
+	t.Helper()
+	due := func(day int) *time.Time {
+		d := time.Date(2025, 6, day, 10, 0, 0, 0, time.UTC)
+		return &d
+	}
+	tasks := []model.Task{
+		{Name: "alpha", Status: model.StatusTodo, Assignee: "Barney", Tags: []string{"doc", "urgent"}, DueDate: due(3)},
+		{Name: "bravo", Status: model.StatusDone, Assignee: "Irene", Tags: []string{"doc"}, DueDate: due(1)},
+		{Name: "charlie", Status: model.StatusTodo, Assignee: "Barney", Tags: []string{"internal"}},
+		{Name: "delta", Status: model.StatusTodo, Assignee: "Irene", Tags: []string{"urgent", "internal"}, DueDate: due(2)},
+		{Name: "echo", Status: model.StatusDone, Assignee: "Barney"},
+	}
+	for i := range tasks {
+		_, err := repo.CreateTask(context.Background(), defaultScope, &tasks[i])
+		require.NoError(t, err)
+	}
+}
+
+func names(tasks []model.Task) []string {
+	out := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		out = append(out, task.Name)
+	}
+	return out
+}
+
+func TestListTasks_Filters(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		seedTasks(t, repo)
+
+		from := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+		cases := []struct {
+			name  string
+			query repository.TaskQuery
+			want  []string
+		}{
+			{"status", repository.TaskQuery{Status: model.StatusTodo}, []string{"alpha", "charlie", "delta"}},
+			{"assignee", repository.TaskQuery{Assignee: "Irene"}, []string{"bravo", "delta"}},
+			{"tags any", repository.TaskQuery{Tags: []string{"urgent", "internal"}}, []string{"alpha", "charlie", "delta"}},
+			{"tags all", repository.TaskQuery{Tags: []string{"urgent", "internal"}, TagMatch: repository.TagMatchAll}, []string{"delta"}},
+			{"due range", repository.TaskQuery{DueAfter: &from}, []string{"alpha", "delta"}},
+			{"combined", repository.TaskQuery{Assignee: "Barney", Tags: []string{"doc"}}, []string{"alpha"}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				page, err := repo.ListTasks(context.Background(), defaultScope, tc.query)
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, names(page.Tasks))
+				assert.Empty(t, page.NextCursor)
+			})
+		}
+	})
+}
+
+func TestListTasks_SortAndPaginate(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		seedTasks(t, repo)
+
+		cases := []struct {
+			sort string
+			desc bool
+			want []string
+		}{
+			{"id", false, []string{"alpha", "bravo", "charlie", "delta", "echo"}},
+			{"name", true, []string{"echo", "delta", "charlie", "bravo", "alpha"}},
+			{"assignee", false, []string{"alpha", "charlie", "echo", "bravo", "delta"}},
+			{"due_date", false, []string{"bravo", "delta", "alpha", "charlie", "echo"}},
+			{"due_date", true, []string{"echo", "charlie", "alpha", "delta", "bravo"}},
+		}
+		for _, tc := range cases {
+			t.Run(tc.sort, func(t *testing.T) {
+				var got []string
+				query := repository.TaskQuery{Sort: tc.sort, Desc: tc.desc, Limit: 2}
+				for pages := 0; ; pages++ {
+					require.Less(t, pages, 5)
+					page, err := repo.ListTasks(context.Background(), defaultScope, query)
+					require.NoError(t, err)
+					got = append(got, names(page.Tasks)...)
+					if page.NextCursor == "" {
+						break
+					}
+					query.Cursor = page.NextCursor
+				}
+				assert.Equal(t, tc.want, got)
+			})
+		}
+	})
+}
+
+func TestListTasks_InvalidSortAndCursor(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		seedTasks(t, repo)
+
+		_, err := repo.ListTasks(context.Background(), defaultScope, repository.TaskQuery{Sort: "tags"})
+		assert.ErrorIs(t, err, repository.ErrInvalidSort)
+
+		_, err = repo.ListTasks(context.Background(), defaultScope, repository.TaskQuery{Cursor: "not-a-cursor"})
+		assert.ErrorIs(t, err, repository.ErrInvalidCursor)
+
+		page, err := repo.ListTasks(context.Background(), defaultScope, repository.TaskQuery{Sort: "name", Limit: 1})
+		require.NoError(t, err)
+		_, err = repo.ListTasks(context.Background(), defaultScope, repository.TaskQuery{Sort: "id", Cursor: page.NextCursor})
+		assert.ErrorIs(t, err, repository.ErrInvalidCursor)
+	})
+}
+
+func TestTaskRepository_NotFound(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+
+		_, err := repo.GetTaskByID(context.Background(), defaultScope, 42)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		_, err = repo.UpdateTask(context.Background(), defaultScope, map[string]interface{}{"name": "renamed"}, 42, repository.AnyVersion)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		_, err = repo.UpdateTask(context.Background(), defaultScope, map[string]interface{}{}, 42, repository.AnyVersion)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		assert.ErrorIs(t, repo.DeleteTask(context.Background(), defaultScope, 42, repository.AnyVersion), repository.ErrNotFound)
+	})
+}
+
+func TestTaskRepository_UpdateReturnsTask(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		created, err := repo.CreateTask(context.Background(), defaultScope, &model.Task{Name: "before", Assignee: "Barney"})
+		require.NoError(t, err)
+
+		updated, err := repo.UpdateTask(context.Background(), defaultScope, map[string]interface{}{"name": "after", "status": model.StatusDone}, created.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		assert.Equal(t, "after", updated.Name)
+		assert.Equal(t, model.StatusDone, updated.Status)
+		assert.NotNil(t, updated.CompletedAt)
+		assert.Equal(t, "Barney", updated.Assignee)
+	})
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"task-api/config"
+	"task-api/handler"
+	"task-api/pkg/health"
+	"task-api/router"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupConfiguredRouter(cfg *config.Config) http.Handler {
+	return router.SetupRouter(router.Handlers{
+		Task:      handler.NewTaskHandler(&mockRepo{}, cfg.Limits, cfg.Concurrency),
+		Workspace: handler.NewWorkspaceHandler(&mockWorkspaces{}),
+		Health:    handler.NewHealthHandler(&health.Readiness{}, nil, nil),
+	}, cfg)
+}
+
+func TestRouter_CORS(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	cfg.CORS.AllowedOrigins = []string{"https://board.example.com"}
+	r := setupConfiguredRouter(cfg)
+
+	req, _ := http.NewRequest("OPTIONS", "/workspaces/1/tasks", nil)
+	req.Header.Set("Origin", "https://board.example.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://board.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	req, _ = http.NewRequest("GET", "/workspaces/1/tasks", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestRouter_BodyLimit(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	cfg.Limits.MaxBodyBytes = 64
+	r := setupConfiguredRouter(cfg)
+
+	body := `{"name":"` + strings.Repeat("a", 100) + `"}`
+	req, _ := http.NewRequest("POST", "/workspaces/1/tasks", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestRouter_PageSizeLimit(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	cfg.Limits.MaxPageSize = 10
+	r := setupConfiguredRouter(cfg)
+
+	req, _ := http.NewRequest("GET", "/workspaces/1/tasks?limit=11", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "limit must not exceed 10")
+}
@@ -0,0 +1,123 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"task-api/config"
+	"task-api/handler"
+	"task-api/pkg/health"
+	"task-api/pkg/server"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_GracefulShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	readiness := &health.Readiness{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	r := gin.New()
+	r.GET("/readyz", handler.NewHealthHandler(readiness, nil, nil).Readyz)
+	r.GET("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+
+	cfg := config.Default().Server
+	cfg.DrainDelay = config.Duration{Duration: 50 * time.Millisecond}
+	srv := server.New(cfg, r, readiness)
+	closed := make(chan struct{})
+	srv.OnShutdown(func() error {
+		close(closed)
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	base := "http://" + ln.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- srv.Serve(ctx, ln) }()
+
+	resp, err := http.Get(base + "/readyz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	slowBody := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(base + "/slow")
+		if err != nil {
+			slowBody <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		slowBody <- string(b)
+	}()
+	<-started
+
+	cancel()
+	require.Eventually(t, readiness.Draining, time.Second, 5*time.Millisecond)
+
+	// During the drain delay the listener is still open and reports not ready.
+	resp, err = http.Get(base + "/readyz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	select {
+	case <-closed:
+		t.Fatal("closers ran before in-flight request finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	assert.Equal(t, "done", <-slowBody)
+	require.NoError(t, <-runErr)
+	<-closed
+
+	_, err = http.Get(base + "/readyz")
+	assert.Error(t, err)
+}
+
+func TestServer_ShutdownTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	started := make(chan struct{})
+	r := gin.New()
+	r.GET("/stuck", func(c *gin.Context) {
+		close(started)
+		<-c.Request.Context().Done()
+	})
+
+	cfg := config.Default().Server
+	cfg.ShutdownTimeout = config.Duration{Duration: 50 * time.Millisecond}
+	srv := server.New(cfg, r, &health.Readiness{})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- srv.Serve(ctx, ln) }()
+
+	go http.Get("http://" + ln.Addr().String() + "/stuck")
+	<-started
+	cancel()
+
+	select {
+	case err := <-runErr:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown did not honour the deadline")
+	}
+}
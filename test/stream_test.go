@@ -0,0 +1,292 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/handler"
+	"task-api/model"
+	"task-api/pkg/health"
+	"task-api/pkg/migrate"
+	"task-api/repository"
+	"task-api/router"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// openStream connects to path and parses its events in the background;
+// comments show up as events named ":".
+func openStream(t *testing.T, url string, headers map[string]string) (<-chan sseEvent, *http.Response) {
+	t.Helper()
+	req, err := http.NewRequest("GET", url, nil)
+	require.NoError(t, err)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	events := make(chan sseEvent, 100)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var event sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if event != (sseEvent{}) {
+					events <- event
+				}
+				event = sseEvent{}
+			case strings.HasPrefix(line, ":"):
+				events <- sseEvent{Event: ":"}
+			default:
+				field, value, _ := strings.Cut(line, ":")
+				value = strings.TrimPrefix(value, " ")
+				switch field {
+				case "id":
+					event.ID = value
+				case "event":
+					event.Event = value
+				case "data":
+					event.Data = value
+				}
+			}
+		}
+	}()
+	return events, resp
+}
+
+// nextEvent waits for the next event other than a heartbeat.
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	for {
+		select {
+		case event, ok := <-events:
+			require.True(t, ok, "stream ended")
+			if event.Event != ":" {
+				return event
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event within 5s")
+		}
+	}
+}
+
+func decodeStreamEvent(t *testing.T, event sseEvent) dto.TaskStreamEvent {
+	t.Helper()
+	var data dto.TaskStreamEvent
+	require.NoError(t, json.Unmarshal([]byte(event.Data), &data))
+	return data
+}
+
+func setupStreamServer(t *testing.T) (*httptest.Server, *handler.StreamHandler) {
+	db := openDB(t, "sqlite")
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	cfg.Stream = config.StreamConfig{PollInterval: config.Duration{Duration: 20 * time.Millisecond}, Heartbeat: config.Duration{Duration: 50 * time.Millisecond}}
+	repo := repository.NewTaskRepository(db)
+	stream := handler.NewStreamHandler(repo, cfg.Stream)
+	srv := httptest.NewServer(router.SetupRouter(router.Handlers{
+		Task:      handler.NewTaskHandler(repo, cfg.Limits, cfg.Concurrency),
+		Workspace: handler.NewWorkspaceHandler(repository.NewWorkspaceRepository(db)),
+		Stream:    stream,
+		Health:    handler.NewHealthHandler(&health.Readiness{}, nil, nil),
+	}, cfg))
+	t.Cleanup(srv.Close)
+	t.Cleanup(stream.Close)
+	return srv, stream
+}
+
+func send(t *testing.T, method, url, contentType, body string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Less(t, resp.StatusCode, 300, "%s %s", method, url)
+}
+
+func TestStreamTasks_PushesChanges(t *testing.T) {
+	srv, _ := setupStreamServer(t)
+	tasks := srv.URL + "/workspaces/1/tasks"
+	send(t, "POST", tasks, "application/json", `{"name":"before the stream"}`)
+
+	events, resp := openStream(t, tasks+"/stream", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/event-stream")
+	ready := nextEvent(t, events)
+	assert.Equal(t, "ready", ready.Event)
+	assert.Equal(t, "1", ready.ID, "starts at the current position")
+
+	send(t, "POST", tasks, "application/json", `{"name":"ship it"}`)
+	send(t, "PATCH", tasks+"/2", mergePatch, `{"status":"done"}`)
+	send(t, "DELETE", tasks+"/2", "", "")
+
+	created := nextEvent(t, events)
+	assert.Equal(t, "task.created", created.Event)
+	assert.Equal(t, "2", created.ID)
+	data := decodeStreamEvent(t, created)
+	assert.Equal(t, "created", data.Type)
+	assert.Equal(t, uint(2), data.TaskID)
+	assert.Equal(t, uint(1), data.Version)
+	assert.Equal(t, "ship it", data.Task.Name)
+
+	completed := nextEvent(t, events)
+	assert.Equal(t, "task.completed", completed.Event)
+	assert.Equal(t, "done", decodeStreamEvent(t, completed).Changes["status"].After)
+	assert.Equal(t, "task.deleted", nextEvent(t, events).Event)
+
+	// 沒有變更時送出 heartbeat
+	select {
+	case event := <-events:
+		assert.Equal(t, ":", event.Event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no heartbeat")
+	}
+}
+
+func TestStreamTasks_ResumesFromLastEventID(t *testing.T) {
+	srv, stream := setupStreamServer(t)
+	tasks := srv.URL + "/workspaces/1/tasks"
+	send(t, "POST", tasks, "application/json", `{"name":"one","assignee":"alice"}`)
+	send(t, "POST", tasks, "application/json", `{"name":"two","assignee":"bob"}`)
+	send(t, "PATCH", tasks+"/1", mergePatch, `{"name":"one, renamed"}`)
+
+	events, _ := openStream(t, tasks+"/stream", map[string]string{"Last-Event-ID": "1"})
+	ready := nextEvent(t, events)
+	assert.Equal(t, "1", ready.ID)
+	assert.Equal(t, "2", nextEvent(t, events).ID)
+	renamed := nextEvent(t, events)
+	assert.Equal(t, "3", renamed.ID)
+	assert.Equal(t, "task.updated", renamed.Event)
+	assert.Equal(t, "one, renamed", decodeStreamEvent(t, renamed).Task.Name)
+
+	// 篩選條件與 GET /tasks 相同；query 參數供無法設定 header 的客戶端使用
+	filtered, _ := openStream(t, tasks+"/stream?assignee=alice&last_event_id=0", nil)
+	assert.Equal(t, "ready", nextEvent(t, filtered).Event)
+	assert.Equal(t, "1", nextEvent(t, filtered).ID)
+	assert.Equal(t, "3", nextEvent(t, filtered).ID)
+	send(t, "POST", tasks, "application/json", `{"name":"three","assignee":"bob"}`)
+	send(t, "POST", tasks, "application/json", `{"name":"four","assignee":"alice"}`)
+	four := nextEvent(t, filtered)
+	assert.Equal(t, "5", four.ID)
+	assert.Equal(t, "four", decodeStreamEvent(t, four).Task.Name)
+
+	// Close 結束所有串流
+	stream.Close()
+	for range filtered {
+	}
+	for range events {
+	}
+
+	for _, query := range []string{"?last_event_id=abc", "?status=unknown"} {
+		resp, err := http.Get(tasks + "/stream" + query)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
+
+// 串流依提交順序編號：較早配到 id 但較晚提交的事件不會被跳過。
+func TestTaskRepository_ListTaskChangesInCommitOrder(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		if db.Dialector.Name() == "sqlite" {
+			t.Skip("sqlite runs one writer at a time")
+		}
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+		a, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "a"})
+		require.NoError(t, err)
+		b, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "b"})
+		require.NoError(t, err)
+		after, err := repo.LatestTaskEventSeq(ctx, defaultScope)
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), after)
+
+		var first *repository.TaskChangePage
+		err = repo.Transaction(ctx, func(tx repository.RepositoryInterface) error {
+			if _, err := tx.UpdateTask(ctx, defaultScope, map[string]interface{}{"name": "a2"}, a.ID, repository.AnyVersion); err != nil {
+				return err
+			}
+			// 在另一個連線上較晚開始、較早提交
+			if _, err := repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"name": "b2"}, b.ID, repository.AnyVersion); err != nil {
+				return err
+			}
+			first, err = repo.ListTaskChanges(ctx, defaultScope, repository.TaskQuery{}, after)
+			return err
+		})
+		require.NoError(t, err)
+		require.Len(t, first.Changes, 1)
+		assert.Equal(t, b.ID, first.Changes[0].Event.TaskID)
+
+		second, err := repo.ListTaskChanges(ctx, defaultScope, repository.TaskQuery{}, first.LastSeq)
+		require.NoError(t, err)
+		require.Len(t, second.Changes, 1)
+		assert.Equal(t, a.ID, second.Changes[0].Event.TaskID)
+		assert.Less(t, second.Changes[0].Event.ID, first.Changes[0].Event.ID)
+		assert.Equal(t, first.LastSeq+1, second.LastSeq)
+	})
+}
+
+// 篩選條件比對的是 task 現在的狀態，不是事件當時的狀態。
+func TestTaskRepository_ListTaskChangesFiltersCurrentState(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+		task, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "handover", Assignee: "alice"})
+		require.NoError(t, err)
+		_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"assignee": "bob"}, task.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		_, err = repo.CreateTask(ctx, defaultScope, &model.Task{Name: "other"})
+		require.NoError(t, err)
+
+		// alice 的串流看不到她當時負責的建立事件
+		page, err := repo.ListTaskChanges(ctx, defaultScope, repository.TaskQuery{Assignee: "alice"}, 0)
+		require.NoError(t, err)
+		assert.Empty(t, page.Changes)
+		assert.Equal(t, uint(3), page.LastSeq)
+
+		page, err = repo.ListTaskChanges(ctx, defaultScope, repository.TaskQuery{Assignee: "bob"}, 0)
+		require.NoError(t, err)
+		require.Len(t, page.Changes, 2)
+		assert.Equal(t, []uint{1, 2}, []uint{page.Changes[0].Event.Seq, page.Changes[1].Event.Seq})
+		assert.Equal(t, "bob", page.Changes[0].Task.Assignee)
+
+		// migration 依 id 為既有的事件編號
+		m := migrate.New(db, migrate.All())
+		_, err = m.Down(len(migrate.All()) - 15)
+		require.NoError(t, err)
+		_, err = m.Up()
+		require.NoError(t, err)
+		latest, err := repo.LatestTaskEventSeq(ctx, defaultScope)
+		require.NoError(t, err)
+		assert.Equal(t, uint(3), latest)
+		page, err = repo.ListTaskChanges(ctx, defaultScope, repository.TaskQuery{}, 1)
+		require.NoError(t, err)
+		require.Len(t, page.Changes, 2)
+		assert.Equal(t, "other", page.Changes[1].Task.Name)
+	})
+}
@@ -0,0 +1,194 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/model"
+	"task-api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// createTree creates root ← child ← grandchild and a second child of root.
+func createTree(t *testing.T, repo *repository.TaskRepository) (root, child, grandchild, sibling *model.Task) {
+	t.Helper()
+	ctx := context.Background()
+	create := func(name string, parent *model.Task) *model.Task {
+		task := &model.Task{Name: name}
+		if parent != nil {
+			task.ParentID = &parent.ID
+		}
+		created, err := repo.CreateTask(ctx, defaultScope, task)
+		require.NoError(t, err)
+		return created
+	}
+	root = create("root", nil)
+	child = create("child", root)
+	grandchild = create("grandchild", child)
+	sibling = create("sibling", root)
+	return
+}
+
+func TestTaskRepository_SubtaskParent(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+		root, child, grandchild, _ := createTree(t, repo)
+
+		missing := uint(99)
+		_, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "lost", ParentID: &missing})
+		assert.ErrorIs(t, err, repository.ErrInvalidParent)
+		other := repository.Scope{WorkspaceID: 2}
+		_, err = repo.CreateTask(ctx, other, &model.Task{Name: "elsewhere", ParentID: &root.ID})
+		assert.ErrorIs(t, err, repository.ErrInvalidParent)
+
+		// root 不能掛到自己或自己的後代底下
+		for _, parent := range []uint{root.ID, child.ID, grandchild.ID} {
+			_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"parent_id": &parent}, root.ID, repository.AnyVersion)
+			assert.ErrorIs(t, err, repository.ErrInvalidParent, parent)
+		}
+
+		moved, err := repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"parent_id": &root.ID}, grandchild.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		assert.Equal(t, root.ID, *moved.ParentID)
+
+		page, err := repo.ListTasks(ctx, defaultScope, repository.TaskQuery{ParentID: &root.ID, Sort: "rank"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"child", "grandchild", "sibling"}, names(page.Tasks))
+	})
+}
+
+func TestTaskRepository_TaskTreeAndProgress(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+		root, child, grandchild, sibling := createTree(t, repo)
+
+		tree, err := repo.GetTaskTree(ctx, defaultScope, root.ID, 1)
+		require.NoError(t, err)
+		require.Len(t, tree.Children, 2)
+		assert.Equal(t, "child", tree.Children[0].Task.Name)
+		assert.Empty(t, tree.Children[0].Children)
+		tree, err = repo.GetTaskTree(ctx, defaultScope, root.ID, 5)
+		require.NoError(t, err)
+		require.Len(t, tree.Children[0].Children, 1)
+		assert.Equal(t, "grandchild", tree.Children[0].Children[0].Task.Name)
+
+		_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"status": model.StatusDone}, grandchild.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"status": model.StatusCancelled}, sibling.ID, repository.AnyVersion)
+		require.NoError(t, err)
+
+		progress, err := repo.TaskProgress(ctx, defaultScope, []uint{root.ID, child.ID, grandchild.ID})
+		require.NoError(t, err)
+		assert.Equal(t, repository.Progress{Descendants: 3, Done: 1, Cancelled: 1}, progress[root.ID])
+		assert.Equal(t, 50, progress[root.ID].Percent())
+		assert.Equal(t, 100, progress[child.ID].Percent())
+		assert.NotContains(t, progress, grandchild.ID)
+	})
+}
+
+func TestTaskRepository_SubtaskDeletePolicies(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		ctx := context.Background()
+
+		t.Run("cascade", func(t *testing.T) {
+			repo := repository.NewTaskRepository(db)
+			root, child, grandchild, _ := createTree(t, repo)
+			require.NoError(t, repo.DeleteTask(ctx, defaultScope, child.ID, repository.AnyVersion))
+			_, err := repo.GetTaskByID(ctx, defaultScope, grandchild.ID)
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+
+			// 之後才刪除的 sibling 不會因為還原 root 而一起回來
+			require.NoError(t, repo.DeleteTask(ctx, defaultScope, root.ID, repository.AnyVersion))
+			_, err = repo.RestoreTask(ctx, defaultScope, child.ID)
+			require.NoError(t, err)
+			restored, err := repo.GetTaskByID(ctx, defaultScope, child.ID)
+			require.NoError(t, err)
+			assert.Nil(t, restored.ParentID, "parent is still in the trash")
+			_, err = repo.GetTaskByID(ctx, defaultScope, grandchild.ID)
+			assert.NoError(t, err)
+
+			_, err = repo.RestoreTask(ctx, defaultScope, root.ID)
+			require.NoError(t, err)
+			page, err := repo.ListTasks(ctx, defaultScope, repository.TaskQuery{ParentID: &root.ID})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"sibling"}, names(page.Tasks))
+			require.NoError(t, db.Exec("DELETE FROM tasks").Error)
+		})
+
+		t.Run("orphan", func(t *testing.T) {
+			repo := repository.NewTaskRepository(db).WithSubtaskPolicy(repository.SubtaskPolicy{OnDelete: repository.DeleteOrphan})
+			root, child, grandchild, _ := createTree(t, repo)
+			require.NoError(t, repo.DeleteTask(ctx, defaultScope, root.ID, repository.AnyVersion))
+			orphan, err := repo.GetTaskByID(ctx, defaultScope, child.ID)
+			require.NoError(t, err)
+			assert.Nil(t, orphan.ParentID)
+			assert.Equal(t, child.Version+1, orphan.Version)
+			kept, err := repo.GetTaskByID(ctx, defaultScope, grandchild.ID)
+			require.NoError(t, err)
+			assert.Equal(t, child.ID, *kept.ParentID)
+			require.NoError(t, db.Exec("DELETE FROM tasks").Error)
+		})
+
+		t.Run("restrict", func(t *testing.T) {
+			repo := repository.NewTaskRepository(db).WithSubtaskPolicy(repository.SubtaskPolicy{OnDelete: repository.DeleteRestrict, OnComplete: repository.CompleteRestrict})
+			root, _, grandchild, _ := createTree(t, repo)
+			assert.ErrorIs(t, repo.DeleteTask(ctx, defaultScope, root.ID, repository.AnyVersion), repository.ErrHasSubtasks)
+			assert.NoError(t, repo.DeleteTask(ctx, defaultScope, grandchild.ID, repository.AnyVersion))
+
+			_, err := repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"status": model.StatusDone}, root.ID, repository.AnyVersion)
+			assert.ErrorIs(t, err, repository.ErrOpenSubtasks)
+		})
+	})
+}
+
+func TestTaskAPI_Subtasks(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+	for _, body := range []string{`{"name":"epic"}`, `{"name":"story","parent_id":1}`, `{"name":"chore","parent_id":2}`} {
+		w := conditional(r, "POST", "/workspaces/1/tasks", nil, body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"x","parent_id":42}`).Code)
+	w := conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": mergePatch}, `{"parent_id":3}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid parent")
+
+	w = conditional(r, "PUT", "/workspaces/1/tasks/3", nil, `{"name":"chore","parent_id":2,"status":"done"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = conditional(r, "GET", "/workspaces/1/tasks/1", nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	task := decodeTask(t, w.Body.Bytes())
+	require.NotNil(t, task.Progress)
+	assert.Equal(t, 50, *task.Progress)
+
+	w = conditional(r, "GET", "/workspaces/1/tasks/1/subtasks", nil, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list dto.TaskListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, "story", list.Data[0].Name)
+	assert.Equal(t, 100, *list.Data[0].Progress)
+	assert.Equal(t, http.StatusNotFound, conditional(r, "GET", "/workspaces/1/tasks/42/subtasks", nil, "").Code)
+
+	w = conditional(r, "GET", "/workspaces/1/tasks/1/tree?depth=1", nil, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var tree dto.TaskTreeResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tree))
+	require.Len(t, tree.Children, 1)
+	assert.Empty(t, tree.Children[0].Children)
+	assert.Equal(t, 100, *tree.Children[0].Progress)
+
+	w = conditional(r, "GET", "/workspaces/1/tasks/1/tree", nil, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tree))
+	assert.Equal(t, "chore", tree.Children[0].Children[0].Name)
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "GET", "/workspaces/1/tasks/1/tree?depth=100", nil, "").Code)
+}
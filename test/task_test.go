@@ -2,6 +2,7 @@ package test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"task-api/config"
 	"task-api/dto"
 	"task-api/handler"
 	"task-api/model"
@@ -16,51 +18,167 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/gorm"
 )
 
 // mockRepo implements TaskRepository for testing
 type mockRepo struct{}
 
-func (m *mockRepo) CreateTask(task *model.Task) (*model.Task, error) {
+func (m *mockRepo) CreateTask(ctx context.Context, scope repository.Scope, task *model.Task) (*model.Task, error) {
 	task.ID = 1
 	return task, nil
 }
 
-func (m *mockRepo) GetTaskByID(id uint) (*model.Task, error) {
+func (m *mockRepo) GetTaskByID(ctx context.Context, scope repository.Scope, id uint) (*model.Task, error) {
 	if id == 1 {
 		return &testTask, nil
 	}
-	return nil, gorm.ErrRecordNotFound
+	return nil, repository.ErrNotFound
 }
 
-func (m *mockRepo) GetAllTasks() ([]model.Task, error) {
-	return []model.Task{testTask}, nil
+func (m *mockRepo) ListTasks(ctx context.Context, scope repository.Scope, query repository.TaskQuery) (*repository.TaskPage, error) {
+	return &repository.TaskPage{Tasks: []model.Task{testTask}}, nil
 }
 
-func (m *mockRepo) UpdateTask(fields map[string]interface{}, id uint) error {
+func (m *mockRepo) UpdateTask(ctx context.Context, scope repository.Scope, fields map[string]interface{}, id uint, version uint) (*model.Task, error) {
 	if id != 1 {
-		return gorm.ErrRecordNotFound
+		return nil, repository.ErrNotFound
+	}
+	if version != repository.AnyVersion && version != testTask.Version {
+		return nil, repository.ErrVersionMismatch
+	}
+	if scope.Assignee != "" && scope.Assignee != testTask.Assignee {
+		return nil, repository.ErrNotOwner
+	}
+	updated := testTask
+	updated.Version++
+	if name, ok := fields["name"].(string); ok {
+		updated.Name = name
+	}
+	return &updated, nil
+}
+
+func (m *mockRepo) DeleteTask(ctx context.Context, scope repository.Scope, id uint, version uint) error {
+	if id != 1 {
+		return repository.ErrNotFound
+	}
+	if version != repository.AnyVersion && version != testTask.Version {
+		return repository.ErrVersionMismatch
 	}
 	return nil
 }
 
-func (m *mockRepo) DeleteTask(id uint) (bool, error) {
-	if id == 1 {
-		return true, nil
+func (m *mockRepo) RestoreTask(ctx context.Context, scope repository.Scope, id uint) (*model.Task, error) {
+	if id != 1 {
+		return nil, repository.ErrNotFound
 	}
-	return false, nil
+	return nil, repository.ErrNotDeleted
+}
+
+func (m *mockRepo) ReopenTask(ctx context.Context, scope repository.Scope, id uint, version uint) (*model.Task, error) {
+	if id != 1 {
+		return nil, repository.ErrNotFound
+	}
+	return nil, repository.ErrNotClosed
+}
+
+func (m *mockRepo) MoveTask(ctx context.Context, scope repository.Scope, id uint, move repository.Move, version uint) (*model.Task, error) {
+	if id != 1 {
+		return nil, repository.ErrNotFound
+	}
+	moved := testTask
+	moved.Version++
+	return &moved, nil
+}
+
+func (m *mockRepo) UpdateSeries(ctx context.Context, scope repository.Scope, fields map[string]interface{}, id uint) ([]model.Task, error) {
+	if id != 1 {
+		return nil, repository.ErrNotFound
+	}
+	return []model.Task{testTask}, nil
+}
+
+func (m *mockRepo) ListTaskEvents(ctx context.Context, scope repository.Scope, id uint, cursor string, limit int) (*repository.TaskEventPage, error) {
+	if id != 1 {
+		return nil, repository.ErrNotFound
+	}
+	return &repository.TaskEventPage{}, nil
+}
+
+func (m *mockRepo) ListTaskChanges(ctx context.Context, scope repository.Scope, query repository.TaskQuery, after uint) (*repository.TaskChangePage, error) {
+	return &repository.TaskChangePage{LastSeq: after}, nil
+}
+
+func (m *mockRepo) LatestTaskEventSeq(ctx context.Context, scope repository.Scope) (uint, error) {
+	return 0, nil
+}
+
+func (m *mockRepo) GetTaskTree(ctx context.Context, scope repository.Scope, id uint, depth int) (*repository.TaskNode, error) {
+	if id != 1 {
+		return nil, repository.ErrNotFound
+	}
+	return &repository.TaskNode{Task: testTask}, nil
+}
+
+func (m *mockRepo) TaskProgress(ctx context.Context, scope repository.Scope, ids []uint) (map[uint]repository.Progress, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) AddDependency(ctx context.Context, scope repository.Scope, id uint, blockerID uint) (*model.TaskDependency, error) {
+	if id != 1 {
+		return nil, repository.ErrNotFound
+	}
+	return &model.TaskDependency{WorkspaceID: scope.WorkspaceID, BlockerID: blockerID, BlockedID: id}, nil
+}
+
+func (m *mockRepo) RemoveDependency(ctx context.Context, scope repository.Scope, id uint, blockerID uint) error {
+	if id != 1 {
+		return repository.ErrNotFound
+	}
+	return repository.ErrDependencyNotFound
+}
+
+func (m *mockRepo) GetTaskGraph(ctx context.Context, scope repository.Scope, id uint) (*repository.TaskGraph, error) {
+	if id != 1 {
+		return nil, repository.ErrNotFound
+	}
+	return &repository.TaskGraph{Tasks: []model.Task{testTask}, Order: []uint{testTask.ID}}, nil
+}
+
+func (m *mockRepo) Transaction(ctx context.Context, fn func(repo repository.RepositoryInterface) error) error {
+	return fn(m)
 }
 
 // 確保 mockRepo 符合 interface，放在 mockRepo 定義後
 var _ repository.RepositoryInterface = (*mockRepo)(nil)
 
+// mockWorkspaces only knows the default workspace.
+type mockWorkspaces struct{}
+
+func (m *mockWorkspaces) CreateWorkspace(ctx context.Context, ws *model.Workspace) (*model.Workspace, error) {
+	ws.ID = 2
+	return ws, nil
+}
+
+func (m *mockWorkspaces) GetWorkspace(ctx context.Context, id uint) (*model.Workspace, error) {
+	if id == repository.DefaultWorkspaceID {
+		return &model.Workspace{ID: id, Name: "default"}, nil
+	}
+	return nil, repository.ErrWorkspaceNotFound
+}
+
+func (m *mockWorkspaces) ListWorkspaces(ctx context.Context, ids []uint) ([]model.Workspace, error) {
+	return []model.Workspace{{ID: repository.DefaultWorkspaceID, Name: "default"}}, nil
+}
+
+var _ repository.WorkspaceRepositoryInterface = (*mockWorkspaces)(nil)
+
 var testTask = model.Task{
 	ID:        1,
 	Name:      "Test Task",
-	Status:    0,
+	Status:    model.StatusTodo,
 	Assignee:  "Tester",
 	Tags:      []string{"test"},
+	Version:   1,
 	CreatedAt: time.Now(),
 	UpdatedAt: time.Now(),
 }
@@ -68,11 +186,13 @@ var testTask = model.Task{
 func setupRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
-	h := handler.NewTaskHandler(&mockRepo{})
+	h := handler.NewTaskHandler(&mockRepo{}, config.Default().Limits, config.Default().Concurrency)
 
 	r.POST("/tasks", h.CreateTask)
 	r.GET("/tasks", h.GetTasks)
+	r.GET("/tasks/:id", h.GetTask)
 	r.PUT("/tasks/:id", h.UpdateTask)
+	r.PATCH("/tasks/:id", h.PatchTask)
 	r.DELETE("/tasks/:id", h.DeleteTask)
 	return r
 }
@@ -106,12 +226,25 @@ func TestGetTasks(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "Test Task")
+	assert.Contains(t, w.Body.String(), `"data"`)
+}
+
+func TestGetTasks_InvalidQuery(t *testing.T) {
+	router := setupRouter()
+
+	for _, query := range []string{"status=2", "tags_match=some", "limit=101", "due_after=tomorrow"} {
+		req, _ := http.NewRequest("GET", "/tasks?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
 }
 
 func TestGetTaskByID(t *testing.T) {
 	router := setupRouter()
 
-	req, _ := http.NewRequest("GET", "/tasks?id=1", nil)
+	req, _ := http.NewRequest("GET", "/tasks/1", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -161,7 +294,7 @@ func TestCreateTask_NameTooLong(t *testing.T) {
 func TestGetTaskByID_NotFound(t *testing.T) {
 	router := setupRouter()
 
-	req, _ := http.NewRequest("GET", "/tasks?id=999", nil) // id=999 不存在
+	req, _ := http.NewRequest("GET", "/tasks/999", nil) // id=999 不存在
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -172,7 +305,7 @@ func TestGetTaskByID_NotFound(t *testing.T) {
 func TestGetTaskByID_InvalidID(t *testing.T) {
 	router := setupRouter()
 
-	req, _ := http.NewRequest("GET", "/tasks?id=abc", nil) // 非數字
+	req, _ := http.NewRequest("GET", "/tasks/abc", nil) // 非數字
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -190,7 +323,22 @@ func TestUpdateTask_Success(t *testing.T) {
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Updated Task")
+}
+
+func TestUpdateTask_NotFound(t *testing.T) {
+	router := setupRouter()
+
+	updateBody := `{"name":"Updated Task"}`
+	req, _ := http.NewRequest("PUT", "/tasks/999", bytes.NewBufferString(updateBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "task not found")
 }
 
 func TestUpdateTask_InvalidID(t *testing.T) {
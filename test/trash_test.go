@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/model"
+	"task-api/pkg/trash"
+	"task-api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestTaskRepository_SoftDelete(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+
+		kept, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "kept"})
+		require.NoError(t, err)
+		task, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "trashed"})
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteTask(ctx, defaultScope, task.ID, repository.AnyVersion))
+
+		_, err = repo.GetTaskByID(ctx, defaultScope, task.ID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"name": "x"}, task.ID, repository.AnyVersion)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		assert.ErrorIs(t, repo.DeleteTask(ctx, defaultScope, task.ID, repository.AnyVersion), repository.ErrNotFound)
+
+		for _, tc := range []struct {
+			query repository.TaskQuery
+			want  []string
+		}{
+			{repository.TaskQuery{}, []string{"kept"}},
+			{repository.TaskQuery{IncludeDeleted: true}, []string{"kept", "trashed"}},
+			{repository.TaskQuery{OnlyDeleted: true, Sort: "deleted_at"}, []string{"trashed"}},
+		} {
+			page, err := repo.ListTasks(ctx, defaultScope, tc.query)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, names(page.Tasks))
+		}
+		// 刪除、還原各自遞增 version
+		page, err := repo.ListTasks(ctx, defaultScope, repository.TaskQuery{OnlyDeleted: true})
+		require.NoError(t, err)
+		require.Len(t, page.Tasks, 1)
+		assert.Equal(t, uint(2), page.Tasks[0].Version)
+
+		_, err = repo.RestoreTask(ctx, repository.Scope{WorkspaceID: 99}, task.ID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = repo.RestoreTask(ctx, defaultScope, kept.ID)
+		assert.ErrorIs(t, err, repository.ErrNotDeleted)
+		restored, err := repo.RestoreTask(ctx, defaultScope, task.ID)
+		require.NoError(t, err)
+		assert.False(t, restored.DeletedAt.Valid)
+		assert.Equal(t, uint(3), restored.Version)
+
+		require.NoError(t, repo.DeleteTask(ctx, defaultScope, task.ID, restored.Version))
+		n, err := repo.PurgeDeletedTasks(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		n, err = repo.PurgeDeletedTasks(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		_, err = repo.RestoreTask(ctx, defaultScope, task.ID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		page, err = repo.ListTasks(ctx, defaultScope, repository.TaskQuery{IncludeDeleted: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kept"}, names(page.Tasks))
+	})
+}
+
+func TestTrash_HTTP(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+	require.Equal(t, http.StatusCreated, conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"oops"}`).Code)
+	require.Equal(t, http.StatusCreated, conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"fine"}`).Code)
+
+	require.Equal(t, http.StatusNoContent, conditional(r, "DELETE", "/workspaces/1/tasks/1", nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, conditional(r, "GET", "/workspaces/1/tasks/1", nil, "").Code)
+
+	list := func(path string) []dto.TaskResponse {
+		w := conditional(r, "GET", path, nil, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body dto.TaskListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Data
+	}
+	assert.Len(t, list("/workspaces/1/tasks"), 1)
+	assert.Len(t, list("/workspaces/1/tasks?include_deleted=true"), 2)
+	trashed := list("/workspaces/1/trash?sort=-deleted_at")
+	require.Len(t, trashed, 1)
+	assert.Equal(t, "oops", trashed[0].Name)
+	assert.NotNil(t, trashed[0].DeletedAt)
+
+	w := conditional(r, "POST", "/workspaces/1/tasks/1/restore", nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	assert.NotContains(t, w.Body.String(), "deleted_at")
+	assert.Equal(t, http.StatusConflict, conditional(r, "POST", "/workspaces/1/tasks/1/restore", nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, conditional(r, "POST", "/workspaces/1/tasks/99/restore", nil, "").Code)
+	assert.Empty(t, list("/workspaces/1/trash"))
+}
+
+type fakePurgeStore struct {
+	cutoffs chan time.Time
+}
+
+func (s *fakePurgeStore) PurgeDeletedTasks(ctx context.Context, cutoff time.Time) (int64, error) {
+	s.cutoffs <- cutoff
+	return 0, nil
+}
+
+func TestPurger(t *testing.T) {
+	store := &fakePurgeStore{cutoffs: make(chan time.Time, 10)}
+	cfg := config.TrashConfig{Retention: config.Duration{Duration: 24 * time.Hour}, PurgeInterval: config.Duration{Duration: time.Hour}}
+
+	now := time.Date(2025, 6, 20, 10, 0, 0, 0, time.UTC)
+	trash.NewPurger(store, cfg).Purge(context.Background(), now)
+	assert.Equal(t, now.Add(-24*time.Hour), <-store.cutoffs)
+
+	// Start 會立即清一次，取消後 Wait 返回
+	ctx, cancel := context.WithCancel(context.Background())
+	purger := trash.NewPurger(store, cfg)
+	purger.Start(ctx)
+	select {
+	case <-store.cutoffs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("purger did not run on start")
+	}
+	cancel()
+	require.NoError(t, purger.Wait())
+
+	// retention 0 keeps deleted tasks forever
+	purger = trash.NewPurger(store, config.TrashConfig{})
+	purger.Start(context.Background())
+	require.NoError(t, purger.Wait())
+	assert.Empty(t, store.cutoffs)
+}
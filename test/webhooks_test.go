@@ -0,0 +1,269 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/handler"
+	"task-api/model"
+	"task-api/pkg/health"
+	"task-api/pkg/webhook"
+	"task-api/repository"
+	"task-api/router"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// receiver is an httptest webhook endpoint that answers with the queued
+// statuses, then 204, and records what it received.
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	secret   string
+	statuses []int
+	received []dto.WebhookPayload
+	headers  []http.Header
+}
+
+func newReceiver(t *testing.T, secret string, statuses ...int) *receiver {
+	rec := &receiver{secret: secret, statuses: statuses}
+	rec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		assert.Equal(t, webhook.Sign(rec.secret, body), r.Header.Get(webhook.HeaderSignature))
+		if len(rec.statuses) > 0 {
+			status := rec.statuses[0]
+			rec.statuses = rec.statuses[1:]
+			w.WriteHeader(status)
+			return
+		}
+		var payload dto.WebhookPayload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		rec.received = append(rec.received, payload)
+		rec.headers = append(rec.headers, r.Header.Clone())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(rec.Close)
+	return rec
+}
+
+func (rec *receiver) events() []string {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	out := []string{}
+	for _, payload := range rec.received {
+		out = append(out, payload.Event)
+	}
+	return out
+}
+
+func setupWebhookRouter(t *testing.T) (http.Handler, *gorm.DB) {
+	db := openDB(t, "sqlite")
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	return router.SetupRouter(router.Handlers{
+		Task:      handler.NewTaskHandler(repository.NewTaskRepository(db).WithWebhooks(webhook.Encode), cfg.Limits, cfg.Concurrency),
+		Workspace: handler.NewWorkspaceHandler(repository.NewWorkspaceRepository(db)),
+		Webhook:   handler.NewWebhookHandler(repository.NewWebhookRepository(db), cfg.Limits),
+		Health:    handler.NewHealthHandler(&health.Readiness{}, nil, nil),
+	}, cfg), db
+}
+
+func createWebhook(t *testing.T, r http.Handler, body string) dto.CreatedWebhookResponse {
+	t.Helper()
+	w := conditional(r, "POST", "/workspaces/1/webhooks", nil, body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created dto.CreatedWebhookResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	return created
+}
+
+func TestWebhookAPI(t *testing.T) {
+	r, _ := setupWebhookRouter(t)
+
+	created := createWebhook(t, r, `{"url":"https://chat.example.com/hooks"}`)
+	assert.Len(t, created.Secret, 64)
+	assert.Empty(t, created.Events)
+	created = createWebhook(t, r, `{"url":"http://ci.example.com/hooks","secret":"0123456789abcdef","events":["task.completed","task.created","task.completed"]}`)
+	assert.Equal(t, "0123456789abcdef", created.Secret)
+	assert.Equal(t, []string{"task.completed", "task.created"}, created.Events)
+
+	for _, body := range []string{`{"url":"ftp://example.com"}`, `{"url":"https://example.com","events":["task.renamed"]}`, `{"url":"https://example.com","secret":"short"}`} {
+		assert.Equal(t, http.StatusBadRequest, conditional(r, "POST", "/workspaces/1/webhooks", nil, body).Code, body)
+	}
+
+	w := conditional(r, "GET", "/workspaces/1/webhooks", nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret")
+	var list []dto.WebhookResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list, 2)
+
+	assert.Equal(t, http.StatusOK, conditional(r, "GET", "/workspaces/1/webhooks/2", nil, "").Code)
+	assert.Equal(t, http.StatusNoContent, conditional(r, "DELETE", "/workspaces/1/webhooks/2", nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, conditional(r, "GET", "/workspaces/1/webhooks/2", nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, conditional(r, "DELETE", "/workspaces/1/webhooks/2", nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, conditional(r, "GET", "/workspaces/1/webhooks/2/deliveries", nil, "").Code)
+}
+
+func TestWebhooks_DeliverSignedTaskEvents(t *testing.T) {
+	r, db := setupWebhookRouter(t)
+	all := newReceiver(t, "all-events-secret")
+	completed := newReceiver(t, "completed-secret")
+	createWebhook(t, r, `{"url":"`+all.URL+`","secret":"all-events-secret"}`)
+	createWebhook(t, r, `{"url":"`+completed.URL+`","secret":"completed-secret","events":["task.completed"]}`)
+
+	require.Equal(t, http.StatusCreated, conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"ship it"}`).Code)
+	require.Equal(t, http.StatusOK, conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": mergePatch}, `{"name":"ship it now"}`).Code)
+	require.Equal(t, http.StatusOK, conditional(r, "PATCH", "/workspaces/1/tasks/1", map[string]string{"Content-Type": mergePatch}, `{"status":"done"}`).Code)
+	require.Equal(t, http.StatusNoContent, conditional(r, "DELETE", "/workspaces/1/tasks/1", nil, "").Code)
+	assert.Empty(t, all.events(), "nothing is sent before the dispatcher runs")
+
+	webhook.NewDispatcher(repository.NewWebhookRepository(db), config.Default().Webhooks).Run(context.Background())
+	assert.Equal(t, []string{"task.created", "task.updated", "task.completed", "task.deleted"}, all.events())
+	assert.Equal(t, []string{"task.completed"}, completed.events())
+
+	payload := all.received[1]
+	assert.Equal(t, "ship it now", payload.Task.Name)
+	assert.Equal(t, uint(2), payload.Task.Version)
+	assert.Equal(t, "ship it now", payload.Changes["name"].After)
+	assert.Equal(t, model.StatusDone, all.received[2].Task.Status)
+	assert.Equal(t, "task.completed", all.headers[2].Get(webhook.HeaderEvent))
+	assert.NotEmpty(t, all.headers[2].Get(webhook.HeaderDelivery))
+
+	w := conditional(r, "GET", "/workspaces/1/webhooks/1/deliveries?limit=3", nil, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var log dto.WebhookDeliveryListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &log))
+	require.Len(t, log.Data, 3)
+	assert.Equal(t, "task.deleted", log.Data[0].Event)
+	assert.Equal(t, model.DeliveryDelivered, log.Data[0].Status)
+	assert.Equal(t, 1, log.Data[0].Attempts)
+	assert.Equal(t, http.StatusNoContent, log.Data[0].ResponseStatus)
+	assert.Nil(t, log.Data[0].NextAttemptAt)
+	assert.Contains(t, string(log.Data[0].Payload), `"event":"task.deleted"`)
+	require.NotEmpty(t, log.NextCursor)
+	w = conditional(r, "GET", "/workspaces/1/webhooks/1/deliveries?cursor="+log.NextCursor, nil, "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &log))
+	require.Len(t, log.Data, 1)
+	assert.Equal(t, "task.created", log.Data[0].Event)
+}
+
+func TestWebhooks_RetryWithBackoff(t *testing.T) {
+	db := openDB(t, "sqlite")
+	tasks := repository.NewTaskRepository(db).WithWebhooks(webhook.Encode)
+	webhooks := repository.NewWebhookRepository(db)
+	ctx := context.Background()
+	flaky := newReceiver(t, "flaky-secret", http.StatusInternalServerError, http.StatusBadGateway)
+	down := newReceiver(t, "down-secret", http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	for _, rec := range []*receiver{flaky, down} {
+		_, err := webhooks.CreateWebhook(ctx, &model.Webhook{WorkspaceID: 1, URL: rec.URL, Secret: rec.secret})
+		require.NoError(t, err)
+	}
+
+	// 交易回滾時不會留下待送的 webhook
+	err := tasks.Transaction(ctx, func(repo repository.RepositoryInterface) error {
+		if _, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "rolled back"}); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	require.Error(t, err)
+	_, err = tasks.CreateTask(ctx, defaultScope, &model.Task{Name: "flaky"})
+	require.NoError(t, err)
+
+	cfg := config.Default().Webhooks
+	cfg.MaxAttempts = 3
+	start := time.Now()
+	clock, set := fixedClock(start)
+	dispatcher := webhook.NewDispatcher(webhooks, cfg).WithClock(clock)
+	for _, step := range []time.Duration{0, 10 * time.Second, 30 * time.Second, 60 * time.Second, 90 * time.Second, time.Hour} {
+		set(start.Add(step))
+		dispatcher.Run(ctx)
+	}
+	assert.Equal(t, []string{"task.created"}, flaky.events())
+	assert.Empty(t, down.events())
+
+	deliveries := func(webhookID uint) model.WebhookDelivery {
+		page, err := webhooks.ListWebhookDeliveries(ctx, 1, webhookID, "", "", 10)
+		require.NoError(t, err)
+		require.Len(t, page.Deliveries, 1)
+		return page.Deliveries[0]
+	}
+	delivered := deliveries(1)
+	assert.Equal(t, model.DeliveryDelivered, delivered.Status)
+	assert.Equal(t, 3, delivered.Attempts)
+	assert.Empty(t, delivered.LastError)
+	// 30 秒後第二次、再 60 秒後第三次；第三次之後放棄
+	failed := deliveries(2)
+	assert.Equal(t, model.DeliveryFailed, failed.Status)
+	assert.Equal(t, 3, failed.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, failed.ResponseStatus)
+	assert.Contains(t, failed.LastError, "503")
+
+	page, err := webhooks.ListWebhookDeliveries(ctx, 1, 2, model.DeliveryPending, "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, page.Deliveries)
+	assert.Equal(t, []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute},
+		[]time.Duration{webhook.Backoff(30*time.Second, 1), webhook.Backoff(30*time.Second, 2), webhook.Backoff(30*time.Second, 3)})
+}
+
+// 每次送出前才認領一筆：前面的接收端再慢，後面的 delivery 的租約也不會在送出前
+// 到期，另一個 dispatcher 不會重複送出。
+func TestWebhooks_SlowReceiverDoesNotCauseDuplicates(t *testing.T) {
+	db := openDB(t, "sqlite")
+	tasks := repository.NewTaskRepository(db).WithWebhooks(webhook.Encode)
+	webhooks := repository.NewWebhookRepository(db)
+	ctx := context.Background()
+	cfg := config.Default().Webhooks
+	clock, set := fixedClock(time.Now())
+	first := webhook.NewDispatcher(webhooks, cfg).WithClock(clock)
+	other := webhook.NewDispatcher(webhooks, cfg).WithClock(clock)
+
+	var mu sync.Mutex
+	var sent []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sent = append(sent, r.Header.Get(webhook.HeaderDelivery))
+		calls := len(sent)
+		mu.Unlock()
+		// 每次嘗試都用掉整個 timeout；第二次時第一批認領的租約早已到期
+		set(clock().Add(cfg.Timeout.Duration))
+		if calls == 2 {
+			other.Run(ctx)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	_, err := webhooks.CreateWebhook(ctx, &model.Webhook{WorkspaceID: 1, URL: server.URL, Secret: "slow-secret"})
+	require.NoError(t, err)
+	for _, name := range []string{"first", "second"} {
+		_, err := tasks.CreateTask(ctx, defaultScope, &model.Task{Name: name})
+		require.NoError(t, err)
+	}
+
+	set(time.Now())
+	first.Run(ctx)
+	assert.Equal(t, []string{"1", "2"}, sent)
+	page, err := webhooks.ListWebhookDeliveries(ctx, 1, 1, model.DeliveryDelivered, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Deliveries, 2)
+	for _, delivery := range page.Deliveries {
+		assert.Equal(t, 1, delivery.Attempts)
+	}
+	// 送達時間是各次嘗試當下的時間
+	assert.WithinDuration(t, page.Deliveries[1].DeliveredAt.Add(cfg.Timeout.Duration), *page.Deliveries[0].DeliveredAt, time.Millisecond)
+}
@@ -0,0 +1,160 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"task-api/config"
+	"task-api/model"
+	"task-api/pkg/migrate"
+	"task-api/pkg/orm"
+	"task-api/pkg/workflow"
+	"task-api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestStatus_Parse(t *testing.T) {
+	for input, want := range map[string]model.Status{"0": model.StatusTodo, "1": model.StatusDone, "in_review": model.StatusInReview} {
+		got, err := model.ParseStatus(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got)
+	}
+	for _, input := range []string{"", "2", "Done", "closed"} {
+		_, err := model.ParseStatus(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestWorkflow_Parse(t *testing.T) {
+	w, err := workflow.Parse(nil)
+	require.NoError(t, err)
+	assert.True(t, w.Allows(model.StatusTodo, model.StatusInProgress))
+	assert.True(t, w.Allows(model.StatusDone, model.StatusDone))
+	assert.False(t, w.Allows(model.StatusDone, model.StatusTodo))
+	assert.False(t, w.Allows(model.StatusBlocked, model.StatusDone))
+
+	w, err = workflow.Parse(map[string][]string{"todo": {"done"}, "done": {"todo"}})
+	require.NoError(t, err)
+	assert.True(t, w.Allows(model.StatusDone, model.StatusTodo))
+	assert.False(t, w.Allows(model.StatusTodo, model.StatusInProgress))
+
+	_, err = workflow.Parse(map[string][]string{"todo": {"finished"}, "later": nil})
+	assert.ErrorContains(t, err, `unknown status "finished"`)
+	assert.ErrorContains(t, err, `unknown status "later"`)
+}
+
+func TestTaskRepository_StatusTransitions(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repo := repository.NewTaskRepository(db)
+		ctx := context.Background()
+		status := func(id uint, s model.Status) (*model.Task, error) {
+			return repo.UpdateTask(ctx, defaultScope, map[string]interface{}{"status": s}, id, repository.AnyVersion)
+		}
+
+		task, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "ship"})
+		require.NoError(t, err)
+		assert.Equal(t, model.StatusTodo, task.Status)
+		assert.Nil(t, task.CompletedAt)
+
+		_, err = status(task.ID, model.StatusInProgress)
+		require.NoError(t, err)
+		done, err := status(task.ID, model.StatusDone)
+		require.NoError(t, err)
+		require.NotNil(t, done.CompletedAt)
+
+		_, err = status(task.ID, model.StatusTodo)
+		assert.ErrorIs(t, err, repository.ErrInvalidTransition)
+		assert.EqualError(t, err, "status transition not allowed: done to todo")
+
+		_, err = repo.ReopenTask(ctx, defaultScope, task.ID, done.Version-1)
+		assert.ErrorIs(t, err, repository.ErrVersionMismatch)
+		reopened, err := repo.ReopenTask(ctx, defaultScope, task.ID, done.Version)
+		require.NoError(t, err)
+		assert.Equal(t, model.StatusTodo, reopened.Status)
+		assert.Nil(t, reopened.CompletedAt)
+		_, err = repo.ReopenTask(ctx, defaultScope, task.ID, repository.AnyVersion)
+		assert.ErrorIs(t, err, repository.ErrNotClosed)
+
+		page, err := repo.ListTaskEvents(ctx, defaultScope, task.ID, "", 10)
+		require.NoError(t, err)
+		last := page.Events[len(page.Events)-1]
+		assert.Equal(t, model.TaskReopened, last.Type)
+		assert.Equal(t, "todo", last.Changes["status"].After)
+		assert.Contains(t, last.Changes, "completed_at")
+
+		// 自訂流程可直接從 done 回到 todo
+		custom := repository.NewTaskRepository(db).WithWorkflow(workflow.Workflow{model.StatusTodo: {model.StatusDone}, model.StatusDone: {model.StatusTodo}})
+		_, err = custom.UpdateTask(ctx, defaultScope, map[string]interface{}{"status": model.StatusDone}, task.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		back, err := custom.UpdateTask(ctx, defaultScope, map[string]interface{}{"status": model.StatusTodo}, task.ID, repository.AnyVersion)
+		require.NoError(t, err)
+		assert.Nil(t, back.CompletedAt)
+	})
+}
+
+func TestTaskAPI_Status(t *testing.T) {
+	r := setupConcurrencyRouter(t, config.ConcurrencyConfig{})
+	require.Equal(t, http.StatusCreated, conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"open"}`).Code)
+	require.Equal(t, http.StatusCreated, conditional(r, "POST", "/workspaces/1/tasks", nil, `{"name":"closed"}`).Code)
+
+	w := conditional(r, "PUT", "/workspaces/1/tasks/2", nil, `{"name":"closed","status":"in_progress"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	// 舊版用戶端送 1 表示完成
+	w = conditional(r, "PUT", "/workspaces/1/tasks/2", nil, `{"name":"closed","status":1}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	task := decodeTask(t, w.Body.Bytes())
+	assert.Equal(t, model.StatusDone, task.Status)
+	assert.NotNil(t, task.CompletedAt)
+
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "PUT", "/workspaces/1/tasks/1", nil, `{"name":"open","status":"finished"}`).Code)
+	w = conditional(r, "PATCH", "/workspaces/1/tasks/2", map[string]string{"Content-Type": mergePatch}, `{"status":"in_progress"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "done to in_progress")
+
+	for query, want := range map[string]string{"status=1": "closed", "status=done": "closed", "status=0": "open", "status=todo": "open"} {
+		w = conditional(r, "GET", "/workspaces/1/tasks?"+query, nil, "")
+		require.Equal(t, http.StatusOK, w.Code, query)
+		assert.Contains(t, w.Body.String(), want, query)
+	}
+	assert.Equal(t, http.StatusBadRequest, conditional(r, "GET", "/workspaces/1/tasks?status=finished", nil, "").Code)
+
+	assert.Equal(t, http.StatusConflict, conditional(r, "POST", "/workspaces/1/tasks/1/reopen", nil, "").Code)
+	assert.Equal(t, http.StatusPreconditionFailed, conditional(r, "POST", "/workspaces/1/tasks/2/reopen", map[string]string{"If-Match": `"1"`}, "").Code)
+	w = conditional(r, "POST", "/workspaces/1/tasks/2/reopen", nil, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	task = decodeTask(t, w.Body.Bytes())
+	assert.Equal(t, model.StatusTodo, task.Status)
+	assert.Nil(t, task.CompletedAt)
+}
+
+func TestMigrate_ConvertsLegacyStatus(t *testing.T) {
+	db, err := orm.InitDB(config.DatabaseConfig{DSN: "sqlite://" + filepath.Join(t.TempDir(), "legacy.db")}, "error")
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&legacyTask{}))
+	require.NoError(t, db.Create(&[]legacyTask{{Name: "open"}, {Name: "closed", Status: 1}}).Error)
+
+	m := migrate.New(db, migrate.All())
+	_, err = m.Up()
+	require.NoError(t, err)
+
+	var tasks []model.Task
+	require.NoError(t, db.Order("id").Find(&tasks).Error)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, model.StatusTodo, tasks[0].Status)
+	assert.Nil(t, tasks[0].CompletedAt)
+	assert.Equal(t, model.StatusDone, tasks[1].Status)
+	require.NotNil(t, tasks[1].CompletedAt)
+	assert.True(t, tasks[1].CompletedAt.Equal(tasks[1].UpdatedAt))
+
+	// 回退到 0008 之前
+	_, err = m.Down(len(migrate.All()) - 7)
+	require.NoError(t, err)
+	var legacy []legacyTask
+	require.NoError(t, db.Order("id").Find(&legacy).Error)
+	assert.Equal(t, []int{0, 1}, []int{legacy[0].Status, legacy[1].Status})
+}
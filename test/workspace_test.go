@@ -0,0 +1,136 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"task-api/config"
+	"task-api/dto"
+	"task-api/handler"
+	"task-api/model"
+	"task-api/pkg/auth"
+	"task-api/pkg/health"
+	"task-api/repository"
+	"task-api/router"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestTaskRepository_WorkspaceIsolation(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		ctx := context.Background()
+		workspaces := repository.NewWorkspaceRepository(db)
+		other, err := workspaces.CreateWorkspace(ctx, &model.Workspace{Name: "other"})
+		require.NoError(t, err)
+		_, err = workspaces.CreateWorkspace(ctx, &model.Workspace{Name: "other"})
+		assert.ErrorIs(t, err, repository.ErrWorkspaceExists)
+		otherScope := repository.Scope{WorkspaceID: other.ID}
+
+		repo := repository.NewTaskRepository(db)
+		task, err := repo.CreateTask(ctx, defaultScope, &model.Task{Name: "private"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(repository.DefaultWorkspaceID), task.WorkspaceID)
+
+		_, err = repo.GetTaskByID(ctx, otherScope, task.ID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = repo.UpdateTask(ctx, otherScope, map[string]interface{}{"name": "hijacked"}, task.ID, repository.AnyVersion)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = repo.UpdateTask(ctx, otherScope, map[string]interface{}{}, task.ID, repository.AnyVersion)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		assert.ErrorIs(t, repo.DeleteTask(ctx, otherScope, task.ID, repository.AnyVersion), repository.ErrNotFound)
+		page, err := repo.ListTasks(ctx, otherScope, repository.TaskQuery{})
+		require.NoError(t, err)
+		assert.Empty(t, page.Tasks)
+
+		got, err := repo.GetTaskByID(ctx, defaultScope, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "private", got.Name)
+	})
+}
+
+func TestWorkspaces_HTTPIsolation(t *testing.T) {
+	db := openDB(t, "sqlite")
+	ctx := context.Background()
+	keys := repository.NewAPIKeyRepository(db)
+	workspaces := repository.NewWorkspaceRepository(db)
+	tasks := repository.NewTaskRepository(db)
+
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	r := router.SetupRouter(router.Handlers{
+		Task:      handler.NewTaskHandler(tasks, cfg.Limits, cfg.Concurrency),
+		Workspace: handler.NewWorkspaceHandler(workspaces),
+		APIKey:    handler.NewAPIKeyHandler(keys, workspaces),
+		Health:    handler.NewHealthHandler(&health.Readiness{}, nil, nil),
+		Auth:      auth.New(keys, nil, "", ""),
+	}, cfg)
+
+	_, root, err := auth.IssueKey(ctx, keys, "root", auth.RoleAdmin, nil)
+	require.NoError(t, err)
+	root = "Bearer " + root
+
+	w := request(r, "POST", "/workspaces", root, []byte(`{"name":"team-b"}`))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var teamB dto.WorkspaceResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &teamB))
+	// 名稱重複是 409，不回傳資料庫的錯誤訊息
+	w = request(r, "POST", "/workspaces", root, []byte(`{"name":"team-b"}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"workspace already exists"`)
+
+	w = request(r, "POST", "/api-keys", root, []byte(fmt.Sprintf(`{"name":"b-admin","role":"admin","workspace_id":%d}`, teamB.ID)))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var bKey dto.CreatedAPIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &bKey))
+	bAdmin := "Bearer " + bKey.Key
+	assert.Equal(t, http.StatusNotFound, request(r, "POST", "/api-keys", root, []byte(`{"name":"x","workspace_id":999}`)).Code)
+
+	// a task in the default workspace
+	w = request(r, "POST", "/workspaces/1/tasks", root, []byte(`{"name":"secret"}`))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var task dto.TaskResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+	defaultTask := fmt.Sprintf("/workspaces/1/tasks/%d", task.ID)
+	viaTeamB := fmt.Sprintf("/workspaces/%d/tasks/%d", teamB.ID, task.ID)
+
+	// the task is invisible through another workspace, even for a global admin
+	assert.Equal(t, http.StatusNotFound, request(r, "GET", viaTeamB, root, nil).Code)
+	assert.Equal(t, http.StatusNotFound, request(r, "PUT", viaTeamB, root, []byte(`{"name":"x"}`)).Code)
+	assert.Equal(t, http.StatusNotFound, request(r, "DELETE", viaTeamB, root, nil).Code)
+
+	// a key bound to team-b cannot reach the default workspace at all
+	for _, tc := range []struct{ method, path, body string }{
+		{"GET", "/workspaces/1", ""},
+		{"GET", "/workspaces/1/tasks", ""},
+		{"GET", defaultTask, ""},
+		{"PUT", defaultTask, `{"name":"x"}`},
+		{"DELETE", defaultTask, ""},
+		{"POST", "/workspaces/1/tasks", `{"name":"x"}`},
+	} {
+		w := request(r, tc.method, tc.path, bAdmin, []byte(tc.body))
+		assert.Equal(t, http.StatusNotFound, w.Code, tc.method+" "+tc.path)
+		assert.Contains(t, w.Body.String(), "workspace not found", tc.method+" "+tc.path)
+	}
+	w = request(r, "GET", fmt.Sprintf("/workspaces/%d/tasks", teamB.ID), bAdmin, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret")
+
+	// listing only shows accessible workspaces; tenant-bound admins can't manage the platform
+	w = request(r, "GET", "/workspaces", bAdmin, nil)
+	var list []dto.WorkspaceResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, teamB.ID, list[0].ID)
+	w = request(r, "POST", "/workspaces", bAdmin, []byte(`{"name":"team-c"}`))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "all workspaces")
+
+	got, err := tasks.GetTaskByID(ctx, defaultScope, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", got.Name)
+}